@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"exchangeapp/internal/app"
 	"exchangeapp/internal/config"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -19,6 +21,17 @@ func main() {
 		log.Fatalf("配置加载失败：%v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-likes" {
+		reconcileLikes(cfg)
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cascade-deleted-threads" {
+		if err := app.CascadeDeletedThreads(cfg); err != nil {
+			log.Fatalf("补删已删除帖子的回复与点赞失败：%v", err)
+		}
+		return
+	}
+
 	s, err := app.NewServer(cfg)
 	if err != nil {
 		log.Fatalf("新建服务失败：%v", err)
@@ -40,3 +53,18 @@ func main() {
 		log.Printf("服务关闭失败：%v", err)
 	}
 }
+
+// 一次性点赞数对账：server reconcile-likes
+func reconcileLikes(cfg *config.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	resp, err := app.ReconcileLikes(ctx, cfg)
+	if resp != nil {
+		out, _ := json.MarshalIndent(resp, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		log.Fatalf("点赞数对账失败：%v", err)
+	}
+}
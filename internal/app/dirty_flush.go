@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDirtyLease   = 30 * time.Second
+	defaultDrainTimeout = 5 * time.Second
+	// 积压时每轮最多处理的批数，避免单轮占用过久
+	maxBatchesPerFlush = 10
+	writeAttempts      = 3
+	writeRetryBackoff  = 50 * time.Millisecond
+)
+
+// 区分停机与卸任 leader：Server.Shutdown 在取消后台任务前调用 begin 传入关闭的截止 context，
+// 只有停机时才回写剩余的 dirty ID；卸任时留给新 leader 回写，避免两个实例同时写库
+type shutdownSignal struct {
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func newShutdownSignal() *shutdownSignal {
+	return &shutdownSignal{}
+}
+
+func (s *shutdownSignal) begin(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+}
+
+// 正在停机时返回关闭的截止 context，否则返回 nil
+func (s *shutdownSignal) deadline() context.Context {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx
+}
+
+// 至少一次：领取的 ID 在回写成功后才确认，未确认的在租约到期后重新领取
+type dirtyClaimer interface {
+	ClaimDirty(limit int, lease time.Duration) ([]uint, int64, error)
+	AckDirty(ids []uint, token int64) error
+	PendingDirty() (int64, error)
+}
+
+// 按批领取 dirty ID，一次批量读取 Redis、一条语句写库、一次确认。
+// 读取失败或写库重试后仍失败时该批不确认，等租约到期重试，不影响同一轮的其他批；
+// ctx 取消（如卸任 leader）时不再领取新批。返回确认的 ID 数，以及队列是否可能还有剩余
+func flushDirty[V any](
+	ctx context.Context,
+	q dirtyClaimer,
+	batch int,
+	lease, backoff time.Duration,
+	read func(ids []uint) (map[uint]V, error),
+	write func(vals map[uint]V) error) (flushed int, more bool) {
+	for i := 0; i < maxBatchesPerFlush; i++ {
+		if ctx.Err() != nil {
+			return flushed, true
+		}
+		ids, token, err := q.ClaimDirty(batch, lease)
+		if err != nil || len(ids) == 0 {
+			return flushed, false
+		}
+		flushed += flushBatch(q, ids, token, backoff, read, write)
+		if len(ids) < batch {
+			return flushed, false
+		}
+	}
+	return flushed, true
+}
+
+func flushBatch[V any](
+	q dirtyClaimer,
+	ids []uint,
+	token int64,
+	backoff time.Duration,
+	read func(ids []uint) (map[uint]V, error),
+	write func(vals map[uint]V) error) int {
+	vals, err := read(ids)
+	if err != nil {
+		return 0
+	}
+
+	// Redis 中已不存在的计数无需回写，直接确认
+	acked := ids
+	if len(vals) > 0 {
+		if err := retryWrite(backoff, func() error { return write(vals) }); err != nil {
+			acked = acked[:0:0]
+			for _, id := range ids {
+				if _, ok := vals[id]; !ok {
+					acked = append(acked, id)
+				}
+			}
+		}
+	}
+	if err := q.AckDirty(acked, token); err != nil {
+		return 0
+	}
+	return len(acked)
+}
+
+// 停机时在 timeout 内持续回写，直到队列中没有可领取的 ID，同时不超过 parent 的截止时间。
+// 返回回写的 ID 数与剩余未回写的 ID 数（含写库失败仍在租约中的）
+func drainDirty[V any](
+	parent context.Context,
+	q dirtyClaimer,
+	batch int,
+	lease, backoff, timeout time.Duration,
+	read func(ids []uint) (map[uint]V, error),
+	write func(vals map[uint]V) error) (flushed int, left int64, err error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	for more := true; more && ctx.Err() == nil; {
+		var n int
+		n, more = flushDirty(ctx, q, batch, lease, backoff, read, write)
+		flushed += n
+	}
+	left, err = q.PendingDirty()
+	return flushed, left, err
+}
+
+func logDrain(name string, flushed int, left int64, err error) {
+	if err != nil {
+		log.Printf("停机回写 %s：回写 %d 个，统计剩余失败：%v", name, flushed, err)
+		return
+	}
+	log.Printf("停机回写 %s：回写 %d 个，剩余 %d 个", name, flushed, left)
+}
+
+func retryWrite(backoff time.Duration, write func() error) error {
+	var err error
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		if err = write(); err == nil {
+			return nil
+		}
+		if attempt < writeAttempts {
+			time.Sleep(backoff * time.Duration(attempt))
+		}
+	}
+	return err
+}
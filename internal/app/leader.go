@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultLeaderTTL = 15 * time.Second
+
+// 多实例部署时只有持有 leader 租约的实例运行后台任务。
+// 续约失败或租约被接管时取消任务并重新竞选，leader 退出后其他实例在租约过期前后自动接管。
+// fencing token 不传给 workers，写库时不校验；防止两个实例写同一计数只依赖 dirty 队列的领取租约
+type LeaderElector struct {
+	lease    leaderLease
+	owner    string
+	ttl      time.Duration
+	interval time.Duration
+}
+
+type leaderLease interface {
+	Acquire(owner string) (int64, error)
+	Renew(owner string, token int64) (bool, error)
+	Release(owner string, token int64) error
+}
+
+func NewLeaderElector(lease leaderLease, owner string, ttl time.Duration) *LeaderElector {
+	if ttl <= 0 {
+		ttl = defaultLeaderTTL
+	}
+
+	return &LeaderElector{
+		lease:    lease,
+		owner:    owner,
+		ttl:      ttl,
+		interval: ttl / 3,
+	}
+}
+
+// 阻塞直到 ctx 取消；当选期间运行 workers，卸任时取消它们并等待退出后再释放租约
+func (e *LeaderElector) Run(ctx context.Context, workers ...func(ctx context.Context)) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		token, err := e.lease.Acquire(e.owner)
+		if err != nil {
+			log.Printf("竞选后台任务 leader 失败：%v", err)
+		} else if token > 0 {
+			e.lead(ctx, token, ticker, workers)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *LeaderElector) lead(ctx context.Context, token int64, ticker *time.Ticker, workers []func(ctx context.Context)) {
+	log.Printf("当选后台任务 leader：%s，token %d", e.owner, token)
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(len(workers))
+	for _, w := range workers {
+		go func() {
+			defer wg.Done()
+			w(leaderCtx)
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+		if err := e.lease.Release(e.owner, token); err != nil {
+			log.Printf("%v", err)
+		}
+		log.Printf("卸任后台任务 leader：%s，token %d", e.owner, token)
+	}()
+
+	// 续约出错时不确定租约是否仍有效，同样卸任，避免与接管者同时运行
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := e.lease.Renew(e.owner, token)
+			if err != nil {
+				log.Printf("%v", err)
+			}
+			if err != nil || !ok {
+				return
+			}
+		}
+	}
+}
+
+// 实例标识：主机名、进程号加随机后缀，同一主机上的多个进程也不会冲突
+func newLeaderOwner() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成实例标识失败：%w", err)
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b)), nil
+}
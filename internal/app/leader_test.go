@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 内存版租约，不模拟过期：释放或 revoke 后才能被他人获取
+type memLease struct {
+	mu     sync.Mutex
+	holder string
+	token  int64
+	fence  int64
+}
+
+func (l *memLease) Acquire(owner string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != "" {
+		return 0, nil
+	}
+	l.fence++
+	l.holder, l.token = owner, l.fence
+	return l.token, nil
+}
+
+func (l *memLease) Renew(owner string, token int64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holder == owner && l.token == token, nil
+}
+
+func (l *memLease) Release(owner string, token int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == owner && l.token == token {
+		l.holder = ""
+	}
+	return nil
+}
+
+// 模拟租约过期后被他人接管
+func (l *memLease) revoke() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder = ""
+}
+
+type leaderRecorder struct {
+	mu     sync.Mutex
+	active map[string]bool
+	starts []string
+}
+
+func (r *leaderRecorder) worker(owner string) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		r.mu.Lock()
+		r.active[owner] = true
+		r.starts = append(r.starts, owner)
+		r.mu.Unlock()
+		<-ctx.Done()
+		r.mu.Lock()
+		r.active[owner] = false
+		r.mu.Unlock()
+	}
+}
+
+func (r *leaderRecorder) snapshot() (active []string, starts int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for owner, ok := range r.active {
+		if ok {
+			active = append(active, owner)
+		}
+	}
+	return active, len(r.starts)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLeaderElectorSingleLeaderAndFailover(t *testing.T) {
+	lease := &memLease{}
+	rec := &leaderRecorder{active: make(map[string]bool)}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	doneA := make(chan struct{})
+	go func() {
+		NewLeaderElector(lease, "a", 30*time.Millisecond).Run(ctxA, rec.worker("a"))
+		close(doneA)
+	}()
+	waitFor(t, func() bool { active, _ := rec.snapshot(); return len(active) == 1 })
+	go NewLeaderElector(lease, "b", 30*time.Millisecond).Run(ctxB, rec.worker("b"))
+
+	time.Sleep(50 * time.Millisecond)
+	if active, starts := rec.snapshot(); len(active) != 1 || active[0] != "a" || starts != 1 {
+		t.Fatalf("expected only a to lead, active=%v starts=%d", active, starts)
+	}
+
+	// a 退出时先停止任务再释放租约，b 随后接管
+	cancelA()
+	<-doneA
+	waitFor(t, func() bool { active, _ := rec.snapshot(); return len(active) == 1 && active[0] == "b" })
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	if lease.token != 2 {
+		t.Fatalf("expected fencing token to increase on failover, got %d", lease.token)
+	}
+}
+
+func TestLeaderElectorStepsDownWhenLeaseLost(t *testing.T) {
+	lease := &memLease{}
+	rec := &leaderRecorder{active: make(map[string]bool)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go NewLeaderElector(lease, "a", 30*time.Millisecond).Run(ctx, rec.worker("a"))
+	waitFor(t, func() bool { active, _ := rec.snapshot(); return len(active) == 1 })
+
+	// 租约被接管后续约失败，任务被取消，之后可重新当选
+	lease.revoke()
+	_, _ = lease.Acquire("b")
+	waitFor(t, func() bool { active, _ := rec.snapshot(); return len(active) == 0 })
+
+	_ = lease.Release("b", 2)
+	waitFor(t, func() bool { active, starts := rec.snapshot(); return len(active) == 1 && starts == 2 })
+}
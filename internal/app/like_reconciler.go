@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/db"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 定期对账点赞数，发现偏差时记录日志
+type LikeReconciler struct {
+	svc      likeReconcileRunner
+	interval time.Duration
+}
+
+type likeReconcileRunner interface {
+	Reconcile(ctx context.Context) (*dto.LikeReconcileResp, error)
+}
+
+func NewLikeReconciler(svc likeReconcileRunner, interval time.Duration) *LikeReconciler {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &LikeReconciler{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+func (r *LikeReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcileOnce(ctx)
+		}
+	}
+}
+
+func (r *LikeReconciler) reconcileOnce(ctx context.Context) {
+	resp, err := r.svc.Reconcile(ctx)
+	if err != nil {
+		log.Printf("点赞数对账失败：%v", err)
+	}
+	if resp != nil && resp.Drifted > 0 {
+		log.Printf("点赞数对账：检查 %d 个帖子，修正 %d 个偏差 %+v", resp.Checked, resp.Drifted, resp.Drifts)
+	}
+}
+
+func newLikeReconcileService(gormDB *gorm.DB, rdb *redis.Client, cfg *config.Config) *service.LikeReconcileService {
+	return service.NewLikeReconcileService(
+		repository.NewLikeReconcileRepository(gormDB),
+		repository.NewRedisLikeCounter(rdb, repository.LikeTargetThread),
+		cfg.LikeReconcile.Batch,
+	)
+}
+
+// 命令行一次性对账，不启动 HTTP 服务与后台任务
+func ReconcileLikes(ctx context.Context, cfg *config.Config) (*dto.LikeReconcileResp, error) {
+	gormDB, err := db.NewMySQL(&cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if sqlDB, err := gormDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	rdb, err := db.NewRedis(&cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	defer rdb.Close()
+
+	return newLikeReconcileService(gormDB, rdb, cfg).Reconcile(ctx)
+}
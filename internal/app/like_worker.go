@@ -2,37 +2,53 @@ package app
 
 import (
 	"context"
-	"errors"
 	"exchangeapp/internal/repository"
 	"time"
 )
 
+// 把一种点赞目标的 dirty 计数回写到库，每种目标各跑一个实例；
+// 停机时在 drain 时长内回写剩余的 dirty ID 后退出
 type LikeCountFlusher struct {
 	counter  likeCounter
-	writer   repository.ThreadLikeCountWriter
+	writer   repository.LikeCountWriter
+	name     string
 	batch    int
 	interval time.Duration
+	lease    time.Duration
+	backoff  time.Duration
+	drain    time.Duration
+	shutdown *shutdownSignal
 }
 
 type likeCounter interface {
-	PopDirty(limit int) ([]uint, error)
-	GetLikeCount(threadID uint) (int64, error)
-	MarkDirty(threadID uint) error
+	dirtyClaimer
+	GetLikeCounts(ids []uint) (map[uint]int64, error)
 }
 
-func NewLikeCountFlusher(counter likeCounter, writer repository.ThreadLikeCountWriter, batch int, interval time.Duration) *LikeCountFlusher {
+func NewLikeCountFlusher(counter likeCounter, writer repository.LikeCountWriter, name string, batch int, interval, lease, drain time.Duration, shutdown *shutdownSignal) *LikeCountFlusher {
 	if batch <= 0 {
 		batch = 200
 	}
 	if interval <= 0 {
 		interval = time.Second
 	}
+	if lease <= 0 {
+		lease = defaultDirtyLease
+	}
+	if drain <= 0 {
+		drain = defaultDrainTimeout
+	}
 
 	return &LikeCountFlusher{
 		counter:  counter,
 		writer:   writer,
+		name:     name,
 		batch:    batch,
 		interval: interval,
+		lease:    lease,
+		backoff:  writeRetryBackoff,
+		drain:    drain,
+		shutdown: shutdown,
 	}
 }
 
@@ -43,34 +59,31 @@ func (f *LikeCountFlusher) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			// 卸任 leader 时不回写，只在停机时回写
+			if shutdownCtx := f.shutdown.deadline(); shutdownCtx != nil {
+				f.drainOnce(shutdownCtx)
+			}
 			return
 		case <-ticker.C:
-			f.flushOnce()
+			f.flushOnce(ctx)
 		}
 	}
 }
 
-func (f *LikeCountFlusher) flushOnce() {
-	if f.batch <= 0 {
-		return
-	}
+func (f *LikeCountFlusher) flushOnce(ctx context.Context) {
+	flushDirty(ctx, f.counter, f.batch, f.lease, f.backoff, f.counter.GetLikeCounts, f.writer.SetLikeCounts)
+}
 
-	ids, err := f.counter.PopDirty(f.batch)
-	if err != nil {
-		return
-	}
+func (f *LikeCountFlusher) drainOnce(ctx context.Context) {
+	flushed, left, err := drainDirty(ctx, f.counter, f.batch, f.lease, f.backoff, f.drain, f.counter.GetLikeCounts, f.writer.SetLikeCounts)
+	logDrain(f.name, flushed, left, err)
+}
 
-	for _, id := range ids {
-		val, err := f.counter.GetLikeCount(id)
-		if err != nil {
-			if !errors.Is(err, repository.ErrLikeCountNotFound) {
-				_ = f.counter.MarkDirty(id)
-			}
-			continue
-		}
+// 踩数与点赞数共用 LikeCountFlusher，回写到 downvote_count
+type downvoteCountWriter struct {
+	store repository.DownvoteCountStore
+}
 
-		if err := f.writer.SetLikeCount(id, val); err != nil {
-			_ = f.counter.MarkDirty(id)
-		}
-	}
+func (w downvoteCountWriter) SetLikeCounts(counts map[uint]int64) error {
+	return w.store.SetDownvoteCounts(counts)
 }
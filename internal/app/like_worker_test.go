@@ -1,120 +1,435 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"exchangeapp/internal/repository"
 )
 
-type fakeCounter struct {
-	popDirtyIDs []uint
-	popDirtyErr error
+// 内存版 dirty 队列，语义与 claimDirtyScript 一致：领取时带租约移入 processing，
+// 确认后删除，租约到期后可被重新领取，同一 ID 不会同时被两个租约持有
+type leaseQueue struct {
+	now        time.Time
+	dirty      []uint
+	processing map[uint]int64
+	claimErr   error
 
-	getVals map[uint]int64
-	getErrs map[uint]error
+	claimCalls int
+	acks       []uint
+}
 
-	popCalls  int
-	getCalls  []uint
-	markCalls []uint
+func newLeaseQueue(ids ...uint) *leaseQueue {
+	return &leaseQueue{
+		now:        time.Unix(1000, 0),
+		dirty:      ids,
+		processing: make(map[uint]int64),
+	}
 }
 
-func (f *fakeCounter) PopDirty(limit int) ([]uint, error) {
-	f.popCalls++
-	return f.popDirtyIDs, f.popDirtyErr
+func (q *leaseQueue) MarkDirty(id uint) error {
+	for _, d := range q.dirty {
+		if d == id {
+			return nil
+		}
+	}
+	q.dirty = append(q.dirty, id)
+	return nil
 }
 
-func (f *fakeCounter) GetLikeCount(threadID uint) (int64, error) {
-	f.getCalls = append(f.getCalls, threadID)
-	if f.getErrs != nil {
-		if err, ok := f.getErrs[threadID]; ok {
-			return 0, err
+func (q *leaseQueue) ClaimDirty(limit int, lease time.Duration) ([]uint, int64, error) {
+	q.claimCalls++
+	if q.claimErr != nil {
+		return nil, 0, q.claimErr
+	}
+	now := q.now.UnixMilli()
+	token := now + lease.Milliseconds()
+
+	var ids []uint
+	for id, deadline := range q.processing {
+		if deadline <= now {
+			ids = append(ids, id)
 		}
 	}
-	if f.getVals != nil {
-		if val, ok := f.getVals[threadID]; ok {
-			return val, nil
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	var kept []uint
+	for _, id := range q.dirty {
+		if len(ids) >= limit {
+			kept = append(kept, id)
+			continue
 		}
+		if deadline, ok := q.processing[id]; ok {
+			if deadline > now {
+				kept = append(kept, id)
+			}
+			continue
+		}
+		ids = append(ids, id)
 	}
-	return 0, errors.New("missing value")
+	q.dirty = kept
+
+	for _, id := range ids {
+		q.processing[id] = token
+	}
+	return ids, token, nil
 }
 
-func (f *fakeCounter) MarkDirty(threadID uint) error {
-	f.markCalls = append(f.markCalls, threadID)
+func (q *leaseQueue) AckDirty(ids []uint, token int64) error {
+	for _, id := range ids {
+		if q.processing[id] == token {
+			delete(q.processing, id)
+			q.acks = append(q.acks, id)
+		}
+	}
 	return nil
 }
 
-type setCall struct {
-	id  uint
-	val int64
+func (q *leaseQueue) PendingDirty() (int64, error) {
+	return int64(len(q.dirty) + len(q.processing)), nil
 }
 
-type fakeWriter struct {
-	calls   []setCall
-	failIDs map[uint]error
+func (q *leaseQueue) pending() []uint {
+	var ids []uint
+	for id := range q.processing {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+type fakeCounter struct {
+	*leaseQueue
+
+	vals   map[uint]int64
+	getErr error
+
+	getCalls [][]uint
 }
 
-func (f *fakeWriter) SetLikeCount(threadID uint, value int64) error {
-	f.calls = append(f.calls, setCall{id: threadID, val: value})
-	if f.failIDs != nil {
-		if err, ok := f.failIDs[threadID]; ok {
-			return err
+// 只返回 Redis 中存在的计数，与 MGET/管道读取一致
+func (f *fakeCounter) GetLikeCounts(ids []uint) (map[uint]int64, error) {
+	f.getCalls = append(f.getCalls, ids)
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	res := make(map[uint]int64, len(ids))
+	for _, id := range ids {
+		if val, ok := f.vals[id]; ok {
+			res[id] = val
 		}
 	}
+	return res, nil
+}
+
+// failures 为前几次调用返回的错误；failID 所在的批始终失败；crashID 所在的批模拟进程退出
+type fakeWriter struct {
+	calls    []map[uint]int64
+	failures []error
+	failID   uint
+	crashID  uint
+	delay    time.Duration
+}
+
+func (f *fakeWriter) SetLikeCounts(counts map[uint]int64) error {
+	if _, ok := counts[f.crashID]; ok && f.crashID != 0 {
+		panic("crash")
+	}
+	time.Sleep(f.delay)
+	f.calls = append(f.calls, counts)
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return err
+	}
+	if _, ok := counts[f.failID]; ok && f.failID != 0 {
+		return errors.New("write error")
+	}
 	return nil
 }
 
+func newTestFlusher(counter *fakeCounter, writer *fakeWriter, batch int) *LikeCountFlusher {
+	f := NewLikeCountFlusher(counter, writer, repository.LikeTargetThread, batch, time.Second, time.Minute, time.Second, nil)
+	f.backoff = 0
+	return f
+}
+
+func flushUntilCrash(f *LikeCountFlusher) (crashed bool) {
+	defer func() {
+		crashed = recover() != nil
+	}()
+	f.flushOnce(context.Background())
+	return false
+}
+
 func TestLikeCountFlusherFlushOnceBatchZeroUsesDefault(t *testing.T) {
-	counter := &fakeCounter{}
-	flusher := NewLikeCountFlusher(counter, &fakeWriter{}, 0, time.Second)
-	flusher.flushOnce()
+	counter := &fakeCounter{leaseQueue: newLeaseQueue()}
+	flusher := NewLikeCountFlusher(counter, &fakeWriter{}, repository.LikeTargetThread, 0, time.Second, 0, 0, nil)
+	flusher.flushOnce(context.Background())
 
-	if counter.popCalls != 1 {
-		t.Fatalf("expected PopDirty called once, got %d", counter.popCalls)
+	if counter.claimCalls != 1 {
+		t.Fatalf("expected ClaimDirty called once, got %d", counter.claimCalls)
+	}
+	if flusher.batch != 200 || flusher.lease != defaultDirtyLease || flusher.drain != defaultDrainTimeout {
+		t.Fatalf("expected defaults, got batch=%d lease=%s drain=%s", flusher.batch, flusher.lease, flusher.drain)
 	}
 }
 
-func TestLikeCountFlusherFlushOncePopDirtyError(t *testing.T) {
-	counter := &fakeCounter{popDirtyErr: errors.New("boom")}
-	flusher := NewLikeCountFlusher(counter, &fakeWriter{}, 10, time.Second)
-	flusher.flushOnce()
+func TestLikeCountFlusherFlushOnceClaimError(t *testing.T) {
+	counter := &fakeCounter{leaseQueue: newLeaseQueue(1)}
+	counter.claimErr = errors.New("boom")
+	newTestFlusher(counter, &fakeWriter{}, 10).flushOnce(context.Background())
 
-	if counter.popCalls != 1 {
-		t.Fatalf("expected 1 PopDirty call, got %d", counter.popCalls)
-	}
 	if len(counter.getCalls) != 0 {
-		t.Fatalf("expected no GetLikeCount calls, got %d", len(counter.getCalls))
+		t.Fatalf("expected no GetLikeCounts calls, got %d", len(counter.getCalls))
 	}
-	if len(counter.markCalls) != 0 {
-		t.Fatalf("expected no MarkDirty calls, got %d", len(counter.markCalls))
+	if len(counter.acks) != 0 {
+		t.Fatalf("expected no acks, got %v", counter.acks)
 	}
 }
 
-func TestLikeCountFlusherFlushOnceMixedResults(t *testing.T) {
+func TestLikeCountFlusherWritesOneStatementPerBatch(t *testing.T) {
 	counter := &fakeCounter{
-		popDirtyIDs: []uint{1, 2, 3, 4},
-		getVals: map[uint]int64{
-			1: 10,
-			4: 7,
-		},
-		getErrs: map[uint]error{
-			2: repository.ErrLikeCountNotFound,
-			3: errors.New("read error"),
-		},
-	}
-	writer := &fakeWriter{failIDs: map[uint]error{4: errors.New("write error")}}
-	flusher := NewLikeCountFlusher(counter, writer, 10, time.Second)
-	flusher.flushOnce()
-
-	wantCalls := []setCall{{id: 1, val: 10}, {id: 4, val: 7}}
-	if !reflect.DeepEqual(writer.calls, wantCalls) {
-		t.Fatalf("unexpected SetLikeCount calls: %+v", writer.calls)
-	}
-
-	wantDirty := []uint{3, 4}
-	if !reflect.DeepEqual(counter.markCalls, wantDirty) {
-		t.Fatalf("unexpected MarkDirty calls: %+v", counter.markCalls)
+		leaseQueue: newLeaseQueue(1, 2, 3, 4, 5),
+		vals:       map[uint]int64{1: 10, 2: 20, 4: 40, 5: 50},
+	}
+	writer := &fakeWriter{}
+	newTestFlusher(counter, writer, 2).flushOnce(context.Background())
+
+	want := []map[uint]int64{{1: 10, 2: 20}, {4: 40}, {5: 50}}
+	if !reflect.DeepEqual(writer.calls, want) {
+		t.Fatalf("unexpected SetLikeCounts calls: %v", writer.calls)
+	}
+	if want := [][]uint{{1, 2}, {3, 4}, {5}}; !reflect.DeepEqual(counter.getCalls, want) {
+		t.Fatalf("expected one read per batch, got %v", counter.getCalls)
+	}
+	// Redis 中已不存在的 3 无需回写，直接确认
+	if want := []uint{1, 2, 3, 4, 5}; !reflect.DeepEqual(counter.acks, want) {
+		t.Fatalf("unexpected acks: %v", counter.acks)
+	}
+}
+
+func TestLikeCountFlusherRetriesWrite(t *testing.T) {
+	counter := &fakeCounter{leaseQueue: newLeaseQueue(1), vals: map[uint]int64{1: 3}}
+	writer := &fakeWriter{failures: []error{errors.New("deadlock"), errors.New("deadlock")}}
+	newTestFlusher(counter, writer, 10).flushOnce(context.Background())
+
+	if len(writer.calls) != writeAttempts {
+		t.Fatalf("expected %d attempts, got %d", writeAttempts, len(writer.calls))
+	}
+	if want := []uint{1}; !reflect.DeepEqual(counter.acks, want) {
+		t.Fatalf("expected ack after retry succeeded, got %v", counter.acks)
+	}
+}
+
+func TestLikeCountFlusherIsolatesFailedBatch(t *testing.T) {
+	counter := &fakeCounter{
+		leaseQueue: newLeaseQueue(1, 2, 3, 4, 5),
+		vals:       map[uint]int64{1: 1, 3: 3, 4: 4, 5: 5},
+	}
+	writer := &fakeWriter{failID: 3}
+	newTestFlusher(counter, writer, 2).flushOnce(context.Background())
+
+	if len(writer.calls) != 1+writeAttempts+1 {
+		t.Fatalf("unexpected SetLikeCounts calls: %v", writer.calls)
+	}
+	if want := []uint{1, 2, 5}; !reflect.DeepEqual(counter.acks, want) {
+		t.Fatalf("expected other batches acked, got %v", counter.acks)
+	}
+	if want := []uint{3, 4}; !reflect.DeepEqual(counter.pending(), want) {
+		t.Fatalf("expected failed batch to stay leased, got %v", counter.pending())
+	}
+}
+
+func TestLikeCountFlusherReadErrorKeepsBatchLeased(t *testing.T) {
+	counter := &fakeCounter{leaseQueue: newLeaseQueue(1, 2), getErr: errors.New("read error")}
+	writer := &fakeWriter{}
+	newTestFlusher(counter, writer, 10).flushOnce(context.Background())
+
+	if len(writer.calls) != 0 || len(counter.acks) != 0 {
+		t.Fatalf("expected nothing written or acked, calls=%v acks=%v", writer.calls, counter.acks)
+	}
+	if want := []uint{1, 2}; !reflect.DeepEqual(counter.pending(), want) {
+		t.Fatalf("expected batch to stay leased, got %v", counter.pending())
+	}
+}
+
+func TestLikeCountFlusherRecoversAfterCrashMidBatch(t *testing.T) {
+	queue := newLeaseQueue(1, 2, 3, 4)
+	counter := &fakeCounter{
+		leaseQueue: queue,
+		vals:       map[uint]int64{1: 1, 2: 2, 3: 3, 4: 4},
+	}
+	writer := &fakeWriter{crashID: 3}
+	if !flushUntilCrash(newTestFlusher(counter, writer, 2)) {
+		t.Fatalf("expected simulated crash")
+	}
+	if want := []uint{1, 2}; !reflect.DeepEqual(queue.acks, want) {
+		t.Fatalf("expected only written batch acked, got %v", queue.acks)
+	}
+	if want := []uint{3, 4}; !reflect.DeepEqual(queue.pending(), want) {
+		t.Fatalf("expected unwritten batch to stay leased, got %v", queue.pending())
+	}
+
+	// 重启后的新实例在租约内不会领取，到期后重新领取并回写
+	writer = &fakeWriter{}
+	restarted := newTestFlusher(counter, writer, 2)
+	restarted.flushOnce(context.Background())
+	if len(writer.calls) != 0 {
+		t.Fatalf("expected no writes before lease expiry, got %v", writer.calls)
+	}
+
+	queue.now = queue.now.Add(time.Minute)
+	restarted.flushOnce(context.Background())
+	if want := []map[uint]int64{{3: 3, 4: 4}}; !reflect.DeepEqual(writer.calls, want) {
+		t.Fatalf("unexpected writes after recovery: %v", writer.calls)
+	}
+	if len(queue.pending()) != 0 || len(queue.dirty) != 0 {
+		t.Fatalf("expected queue drained, pending=%v dirty=%v", queue.pending(), queue.dirty)
+	}
+}
+
+func TestLikeCountFlusherCrashWithConcurrentLike(t *testing.T) {
+	queue := newLeaseQueue(1)
+	counter := &fakeCounter{leaseQueue: queue, vals: map[uint]int64{1: 5}}
+	if !flushUntilCrash(newTestFlusher(counter, &fakeWriter{crashID: 1}, 10)) {
+		t.Fatalf("expected simulated crash")
+	}
+
+	// 租约期间又有点赞：ID 回到 dirty，但不会被第二个租约同时持有
+	counter.vals[1] = 6
+	_ = queue.MarkDirty(1)
+	writer := &fakeWriter{}
+	flusher := newTestFlusher(counter, writer, 10)
+	flusher.flushOnce(context.Background())
+	if len(writer.calls) != 0 {
+		t.Fatalf("expected leased id not to be claimed twice, got %v", writer.calls)
+	}
+
+	queue.now = queue.now.Add(time.Minute)
+	flusher.flushOnce(context.Background())
+	flusher.flushOnce(context.Background())
+	if want := []map[uint]int64{{1: 6}}; !reflect.DeepEqual(writer.calls, want) {
+		t.Fatalf("unexpected writes: %v", writer.calls)
+	}
+	if len(queue.pending()) != 0 || len(queue.dirty) != 0 {
+		t.Fatalf("expected queue drained, pending=%v dirty=%v", queue.pending(), queue.dirty)
+	}
+}
+
+func TestLikeCountFlusherStaleAckIgnored(t *testing.T) {
+	queue := newLeaseQueue(1)
+	_, stale, _ := queue.ClaimDirty(10, time.Minute)
+
+	queue.now = queue.now.Add(time.Minute)
+	ids, token, _ := queue.ClaimDirty(10, time.Minute)
+	if !reflect.DeepEqual(ids, []uint{1}) {
+		t.Fatalf("expected expired lease reclaimed, got %v", ids)
+	}
+
+	_ = queue.AckDirty([]uint{1}, stale)
+	if want := []uint{1}; !reflect.DeepEqual(queue.pending(), want) {
+		t.Fatalf("stale ack should not release the new lease, pending=%v", queue.pending())
+	}
+	_ = queue.AckDirty([]uint{1}, token)
+	if len(queue.pending()) != 0 {
+		t.Fatalf("expected ack with current lease to release, pending=%v", queue.pending())
+	}
+}
+
+func TestLikeCountFlusherDrainsOnShutdown(t *testing.T) {
+	ids := make([]uint, 25)
+	vals := make(map[uint]int64)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+		vals[ids[i]] = int64(i)
+	}
+	counter := &fakeCounter{leaseQueue: newLeaseQueue(ids...), vals: vals}
+	writer := &fakeWriter{}
+	shutdown := newShutdownSignal()
+	flusher := NewLikeCountFlusher(counter, writer, repository.LikeTargetThread, 2, time.Hour, time.Minute, time.Second, shutdown)
+
+	// 还没到回写周期就停机：Run 退出前回写全部剩余 ID，超过单轮批数上限也继续
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdown.begin(context.Background())
+	cancel()
+	flusher.Run(ctx)
+
+	if len(writer.calls) != 13 || len(counter.acks) != 25 {
+		t.Fatalf("expected all ids drained, calls=%d acks=%d", len(writer.calls), len(counter.acks))
+	}
+	if left, _ := counter.PendingDirty(); left != 0 {
+		t.Fatalf("expected nothing left, got %d", left)
+	}
+}
+
+func TestLikeCountFlusherSkipsDrainOnStepDown(t *testing.T) {
+	counter := &fakeCounter{leaseQueue: newLeaseQueue(1, 2), vals: map[uint]int64{1: 1, 2: 2}}
+	writer := &fakeWriter{}
+	flusher := NewLikeCountFlusher(counter, writer, repository.LikeTargetThread, 2, time.Hour, time.Minute, time.Second, newShutdownSignal())
+
+	// 卸任 leader 取消 ctx 但没有停机，剩余 ID 留给新 leader
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	flusher.Run(ctx)
+
+	if len(writer.calls) != 0 {
+		t.Fatalf("expected no drain on step-down, got %d calls", len(writer.calls))
+	}
+	if left, _ := counter.PendingDirty(); left != 2 {
+		t.Fatalf("expected 2 left for the next leader, got %d", left)
+	}
+}
+
+func TestDrainDirtyReportsLeftBehind(t *testing.T) {
+	counter := &fakeCounter{
+		leaseQueue: newLeaseQueue(1, 2, 3, 4),
+		vals:       map[uint]int64{1: 1, 2: 2, 3: 3, 4: 4},
+	}
+	writer := &fakeWriter{failID: 3}
+	flushed, left, err := drainDirty(context.Background(), counter, 2, time.Minute, 0, time.Second, counter.GetLikeCounts, writer.SetLikeCounts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 写库失败的批仍在租约中，计入剩余
+	if flushed != 2 || left != 2 {
+		t.Fatalf("expected flushed=2 left=2, got flushed=%d left=%d", flushed, left)
+	}
+}
+
+func TestDrainDirtyStopsAtDeadline(t *testing.T) {
+	counter := &fakeCounter{
+		leaseQueue: newLeaseQueue(1, 2, 3, 4, 5),
+		vals:       map[uint]int64{1: 1, 2: 2, 3: 3, 4: 4, 5: 5},
+	}
+	writer := &fakeWriter{delay: 20 * time.Millisecond}
+	flushed, left, _ := drainDirty(context.Background(), counter, 1, time.Minute, 0, 10*time.Millisecond, counter.GetLikeCounts, writer.SetLikeCounts)
+
+	// 超时后不再领取新批，进行中的批照常完成
+	if flushed != 1 || left != 4 {
+		t.Fatalf("expected flushed=1 left=4, got flushed=%d left=%d", flushed, left)
+	}
+}
+
+func TestDrainDirtyStopsAtShutdownDeadline(t *testing.T) {
+	counter := &fakeCounter{
+		leaseQueue: newLeaseQueue(1, 2, 3, 4, 5),
+		vals:       map[uint]int64{1: 1, 2: 2, 3: 3, 4: 4, 5: 5},
+	}
+	writer := &fakeWriter{delay: 20 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	flushed, left, _ := drainDirty(ctx, counter, 1, time.Minute, 0, time.Second, counter.GetLikeCounts, writer.SetLikeCounts)
+
+	// drain 时长未到，但关闭服务的截止时间先到
+	if flushed != 1 || left != 4 {
+		t.Fatalf("expected flushed=1 left=4, got flushed=%d left=%d", flushed, left)
 	}
 }
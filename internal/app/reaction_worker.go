@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"exchangeapp/internal/repository"
+	"time"
+)
+
+// 与 LikeCountFlusher 相同，把一种目标的 dirty 回应计数按批整组回写到 reaction_counts
+type ReactionCountFlusher struct {
+	counter  reactionCounter
+	writer   repository.ReactionCountRepository
+	target   string
+	batch    int
+	interval time.Duration
+	lease    time.Duration
+	backoff  time.Duration
+	drain    time.Duration
+	shutdown *shutdownSignal
+}
+
+type reactionCounter interface {
+	dirtyClaimer
+	GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error)
+}
+
+func NewReactionCountFlusher(counter reactionCounter, writer repository.ReactionCountRepository, target string, batch int, interval, lease, drain time.Duration, shutdown *shutdownSignal) *ReactionCountFlusher {
+	if batch <= 0 {
+		batch = 200
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if lease <= 0 {
+		lease = defaultDirtyLease
+	}
+	if drain <= 0 {
+		drain = defaultDrainTimeout
+	}
+
+	return &ReactionCountFlusher{
+		counter:  counter,
+		writer:   writer,
+		target:   target,
+		batch:    batch,
+		interval: interval,
+		lease:    lease,
+		backoff:  writeRetryBackoff,
+		drain:    drain,
+		shutdown: shutdown,
+	}
+}
+
+func (f *ReactionCountFlusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 卸任 leader 时不回写，只在停机时回写
+			if shutdownCtx := f.shutdown.deadline(); shutdownCtx != nil {
+				f.drainOnce(shutdownCtx)
+			}
+			return
+		case <-ticker.C:
+			f.flushOnce(ctx)
+		}
+	}
+}
+
+func (f *ReactionCountFlusher) flushOnce(ctx context.Context) {
+	flushDirty(ctx, f.counter, f.batch, f.lease, f.backoff, f.counter.GetReactionCountsBatch, f.write)
+}
+
+func (f *ReactionCountFlusher) drainOnce(ctx context.Context) {
+	flushed, left, err := drainDirty(ctx, f.counter, f.batch, f.lease, f.backoff, f.drain, f.counter.GetReactionCountsBatch, f.write)
+	logDrain(f.target+" 回应数", flushed, left, err)
+}
+
+func (f *ReactionCountFlusher) write(counts map[uint]map[string]int64) error {
+	return f.writer.SetReactionCounts(f.target, counts)
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"exchangeapp/internal/repository"
+)
+
+type fakeReactionCounter struct {
+	*leaseQueue
+
+	vals   map[uint]map[string]int64
+	getErr error
+}
+
+func (f *fakeReactionCounter) GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	res := make(map[uint]map[string]int64, len(ids))
+	for _, id := range ids {
+		if counts, ok := f.vals[id]; ok {
+			res[id] = counts
+		}
+	}
+	return res, nil
+}
+
+type reactionSetCall struct {
+	target string
+	counts map[uint]map[string]int64
+}
+
+type fakeReactionWriter struct {
+	calls  []reactionSetCall
+	failID uint
+}
+
+func (f *fakeReactionWriter) GetReactionCounts(string, []uint) (map[uint]map[string]int64, error) {
+	return nil, nil
+}
+
+func (f *fakeReactionWriter) SetReactionCounts(target string, counts map[uint]map[string]int64) error {
+	f.calls = append(f.calls, reactionSetCall{target: target, counts: counts})
+	if _, ok := counts[f.failID]; ok {
+		return errors.New("write error")
+	}
+	return nil
+}
+
+func TestReactionCountFlusherFlushOnce(t *testing.T) {
+	counter := &fakeReactionCounter{
+		leaseQueue: newLeaseQueue(1, 2, 3, 4),
+		vals: map[uint]map[string]int64{
+			1: {"heart": 2, "laugh": 0},
+			3: {"hooray": 1},
+			4: {"heart": 1},
+		},
+	}
+	writer := &fakeReactionWriter{failID: 3}
+	flusher := NewReactionCountFlusher(counter, writer, repository.LikeTargetReply, 2, time.Second, time.Minute, time.Second, nil)
+	flusher.backoff = 0
+	flusher.flushOnce(context.Background())
+
+	first := reactionSetCall{target: "reply", counts: map[uint]map[string]int64{1: {"heart": 2, "laugh": 0}}}
+	if len(writer.calls) != 1+writeAttempts || !reflect.DeepEqual(writer.calls[0], first) {
+		t.Fatalf("unexpected SetReactionCounts calls: %+v", writer.calls)
+	}
+	if want := []uint{1, 2}; !reflect.DeepEqual(counter.acks, want) {
+		t.Fatalf("unexpected acks: %v", counter.acks)
+	}
+	if want := []uint{3, 4}; !reflect.DeepEqual(counter.pending(), want) {
+		t.Fatalf("expected failed batch to stay leased, got %v", counter.pending())
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"exchangeapp/internal/models"
 	"exchangeapp/internal/repository"
 	"exchangeapp/internal/service"
+	"exchangeapp/internal/storage"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +30,9 @@ type Server struct {
 
 	workerCancel context.CancelFunc
 	workerDone   chan struct{}
+	shutdown     *shutdownSignal
+	// 等待后台任务退出的最长时间，包括回写剩余 dirty ID
+	workerWait time.Duration
 }
 
 func NewServer(cfg *config.Config) (*Server, error) {
@@ -51,60 +57,224 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		}
 		return nil, err
 	}
+	closeAll := func() {
+		if sqlDB, dbErr := gormDB.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		rdb.Close()
+	}
 
 	userRepo := repository.NewUserRepository(gormDB)
 	userSvc := service.NewUserService(userRepo, cfg.JWT)
 	userHandler := handler.NewUserHandler(userSvc)
 
-	redisCounter := repository.NewRedisLikeCounter(rdb)
+	redisCounter := repository.NewRedisLikeCounter(rdb, repository.LikeTargetThread)
+	redisReplyCounter := repository.NewRedisLikeCounter(rdb, repository.LikeTargetReply)
+	redisThreadReactions := repository.NewRedisReactionCounter(rdb, repository.LikeTargetThread)
+	redisReplyReactions := repository.NewRedisReactionCounter(rdb, repository.LikeTargetReply)
+	reactionCountRepo := repository.NewReactionCountRepository(gormDB)
+	threadReactions := repository.NewCachedReactionCounter(reactionCountRepo, redisThreadReactions)
+	replyReactions := repository.NewCachedReactionCounter(reactionCountRepo, redisReplyReactions)
+	reactionTypes := service.NewReactionSet(cfg.Reactions.Types)
+
+	blobStore, err := storage.NewBlobStore(&cfg.Upload)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	uploadRepo := repository.NewUploadRepository(gormDB)
+	uploadSvc := service.NewUploadService(uploadRepo, blobStore, cfg.Upload)
+	uploadHandler := handler.NewUploadHandler(uploadSvc)
 
 	dbthreadRepo := repository.NewThreadRepository(gormDB)
 	threadRepo := repository.NewCachedThreadRepository(dbthreadRepo, rdb)
 	threadLikeRepo := repository.NewThreadLikeRepository(gormDB)
-	likeCounter := repository.NewCachedThreadLikeCounter(threadRepo, redisCounter)
-	threadSvc := service.NewThreadService(threadRepo, threadLikeRepo, likeCounter)
-	threadLikeSvc := service.NewThreadLikeService(threadRepo, threadLikeRepo, likeCounter)
+	likeCounter := repository.NewCachedLikeCounter(threadRepo, redisCounter)
+	bookmarkRepo := repository.NewBookmarkRepository(gormDB)
+	bookmarkSvc := service.NewBookmarkService(threadRepo, bookmarkRepo, likeCounter)
+	bookmarkHandler := handler.NewBookmarkHandler(bookmarkSvc)
+	mentionSvc := service.NewMentionService(userRepo, repository.NewMentionRepository(gormDB))
+	reputationSvc := service.NewReputationService(repository.NewReputationRepository(gormDB), userRepo, cfg.Reputation)
+	reputationHandler := handler.NewReputationHandler(reputationSvc)
+	replyRepo := repository.NewReplyRepository(gormDB)
+	replyLikeRepo := repository.NewReplyLikeRepository(gormDB)
+
+	// 未开启踩时计数器为 nil，服务层据此拒绝踩
+	threadDownvoteStore, ok := dbthreadRepo.(repository.DownvoteCountStore)
+	if !ok {
+		closeAll()
+		return nil, fmt.Errorf("线程仓库不支持踩数")
+	}
+	replyDownvoteStore, ok := replyLikeRepo.(repository.DownvoteCountStore)
+	if !ok {
+		closeAll()
+		return nil, fmt.Errorf("回复点赞仓库不支持踩数")
+	}
+	redisThreadDownvotes := repository.NewRedisDownvoteCounter(rdb, repository.LikeTargetThread)
+	redisReplyDownvotes := repository.NewRedisDownvoteCounter(rdb, repository.LikeTargetReply)
+	var threadDownvotes, replyDownvotes repository.LikeCounter
+	if cfg.Votes.Downvote {
+		threadDownvotes = repository.NewCachedDownvoteCounter(threadDownvoteStore, redisThreadDownvotes)
+		replyDownvotes = repository.NewCachedDownvoteCounter(replyDownvoteStore, redisReplyDownvotes)
+	}
+
+	threadSvc := service.NewThreadService(threadRepo, threadLikeRepo, likeCounter, uploadRepo, bookmarkRepo, mentionSvc, threadReactions, threadDownvotes, reputationSvc)
+	threadLikeSvc := service.NewThreadLikeService(threadRepo, threadLikeRepo, likeCounter, threadReactions, reactionTypes, repository.NewRedisLikedThreadCache(rdb), threadDownvotes, reputationSvc)
 	threadHandler := handler.NewThreadHandler(threadSvc)
 	threadLikeHandler := handler.NewThreadLikeHandler(threadLikeSvc)
 
-	replyRepo := repository.NewReplyRepository(gormDB)
-	replySvc := service.NewReplyService(replyRepo, threadRepo)
+	replyLikeCounter := repository.NewCachedLikeCounter(replyLikeRepo, redisReplyCounter)
+	replySvc := service.NewReplyService(replyRepo, threadRepo, uploadRepo, mentionSvc, replyLikeCounter, replyReactions, replyDownvotes, reputationSvc)
 	replyHandler := handler.NewReplyHandler(replySvc)
+	replyLikeSvc := service.NewReplyLikeService(replyRepo, replyLikeRepo, replyLikeCounter, replyReactions, reactionTypes, replyDownvotes)
+	replyLikeHandler := handler.NewReplyLikeHandler(replyLikeSvc)
+
+	trashRepo := repository.NewTrashRepository(gormDB)
+	trashRetention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	if trashRetention <= 0 {
+		trashRetention = 30 * 24 * time.Hour
+	}
+	trashSvc := service.NewTrashService(trashRepo, threadRepo, replyRepo, likeCounter, threadDownvotes, trashRetention)
+	trashHandler := handler.NewTrashHandler(trashSvc)
+
+	moderationRepo := repository.NewModerationRepository(gormDB)
+	moderationSvc := service.NewModerationService(threadRepo, replyRepo, moderationRepo, likeCounter, threadReactions, threadDownvotes)
+	moderationHandler := handler.NewModerationHandler(moderationSvc)
 
-	writer, ok := dbthreadRepo.(repository.ThreadLikeCountWriter)
+	likeReconcileSvc := newLikeReconcileService(gormDB, rdb, cfg)
+
+	writer, ok := dbthreadRepo.(repository.LikeCountWriter)
 	if !ok {
-		return nil, fmt.Errorf("线程仓库不支持 SetLikeCount")
+		closeAll()
+		return nil, fmt.Errorf("线程仓库不支持 SetLikeCounts")
 	}
 	batch := cfg.LikeWorker.Batch
 	interval := time.Duration(cfg.LikeWorker.IntervalSeconds) * time.Second
-	flusher := NewLikeCountFlusher(redisCounter, writer, batch, interval)
+	lease := time.Duration(cfg.LikeWorker.LeaseSeconds) * time.Second
+	drain := time.Duration(cfg.LikeWorker.DrainSeconds) * time.Second
+	if drain <= 0 {
+		drain = defaultDrainTimeout
+	}
+	shutdown := newShutdownSignal()
+	flusher := NewLikeCountFlusher(redisCounter, writer, redisCounter.QueueName(), batch, interval, lease, drain, shutdown)
+	replyWriter, ok := replyLikeRepo.(repository.LikeCountWriter)
+	if !ok {
+		closeAll()
+		return nil, fmt.Errorf("回复点赞仓库不支持 SetLikeCounts")
+	}
+	replyFlusher := NewLikeCountFlusher(redisReplyCounter, replyWriter, redisReplyCounter.QueueName(), batch, interval, lease, drain, shutdown)
+	// 关闭踩后仍回写队列里剩下的踩数
+	threadDownvoteFlusher := NewLikeCountFlusher(redisThreadDownvotes, downvoteCountWriter{threadDownvoteStore}, redisThreadDownvotes.QueueName(), batch, interval, lease, drain, shutdown)
+	replyDownvoteFlusher := NewLikeCountFlusher(redisReplyDownvotes, downvoteCountWriter{replyDownvoteStore}, redisReplyDownvotes.QueueName(), batch, interval, lease, drain, shutdown)
+	threadReactionFlusher := NewReactionCountFlusher(redisThreadReactions, reactionCountRepo, repository.LikeTargetThread, batch, interval, lease, drain, shutdown)
+	replyReactionFlusher := NewReactionCountFlusher(redisReplyReactions, reactionCountRepo, repository.LikeTargetReply, batch, interval, lease, drain, shutdown)
+
+	gcGrace := time.Duration(cfg.Upload.GCGraceHours) * time.Hour
+	gcInterval := time.Duration(cfg.Upload.GCIntervalMinutes) * time.Minute
+	uploadGC := NewUploadGC(uploadRepo, blobStore, gcGrace, gcInterval)
+
+	purgeInterval := time.Duration(cfg.Trash.PurgeIntervalMinutes) * time.Minute
+	trashPurger := NewTrashPurger(trashRepo, trashRetention, purgeInterval)
+	likeReconciler := NewLikeReconciler(likeReconcileSvc, time.Duration(cfg.LikeReconcile.IntervalMinutes)*time.Minute)
+
+	owner, err := newLeaderOwner()
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	leaderTTL := time.Duration(cfg.Leader.TTLSeconds) * time.Second
+	if leaderTTL <= 0 {
+		leaderTTL = defaultLeaderTTL
+	}
+	elector := NewLeaderElector(repository.NewRedisLeaderLease(rdb, "workers", leaderTTL), owner, leaderTTL)
+
+	// 后台任务只在 leader 实例上运行；手动触发的对账也在此 context 下运行，关闭服务时一并取消
 	ctx, cancel := context.WithCancel(context.Background())
+	likeReconcileHandler := handler.NewLikeReconcileHandler(likeReconcileSvc, ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		elector.Run(ctx,
+			flusher.Run,
+			replyFlusher.Run,
+			threadDownvoteFlusher.Run,
+			replyDownvoteFlusher.Run,
+			threadReactionFlusher.Run,
+			replyReactionFlusher.Run,
+			uploadGC.Run,
+			trashPurger.Run,
+			likeReconciler.Run,
+		)
+	}()
 	done := make(chan struct{})
 	go func() {
-		defer close(done)
-		flusher.Run(ctx)
+		wg.Wait()
+		close(done)
 	}()
 
 	e.POST("/register", userHandler.Register)
 	e.POST("/login", userHandler.Login)
 	e.GET("/threads", threadHandler.List)
 	e.GET("/threads/:id/replies", replyHandler.ListByThreadID)
-	e.GET("/threads/:id", threadHandler.Detail)
+	e.GET("/threads/:id/likes", threadLikeHandler.ListLikers)
+	e.GET("/replies/:id/children", replyHandler.ListChildren)
+	e.GET("/replies/:id/context", replyHandler.Context)
+	e.GET("/replies/:id/locate", replyHandler.Locate)
+	e.GET("/threads/:id", middleware.OptionalAuth(cfg.JWT.Secret), threadHandler.Detail)
+	e.GET("/users/:id", userHandler.Profile)
+	e.GET("/reputation/leaderboard", reputationHandler.Leaderboard)
 
 	authGroup := e.Group("/api")
 	authGroup.Use(middleware.Auth(cfg.JWT.Secret))
 	authGroup.GET("/me", userHandler.Me)
+	authGroup.GET("/me/privacy", userHandler.Privacy)
+	authGroup.PUT("/me/privacy", userHandler.UpdatePrivacy)
 	authGroup.GET("/me/threads", threadHandler.ListMine)
 	authGroup.GET("/me/replies", replyHandler.ListMine)
+	authGroup.GET("/me/trash", trashHandler.List)
+	authGroup.GET("/me/bookmarks", bookmarkHandler.ListMine)
 	authGroup.POST("/threads", threadHandler.Create)
 	authGroup.POST("/threads/:id/replies", replyHandler.Create)
 	authGroup.PUT("/threads/:id", threadHandler.Update)
 	authGroup.DELETE("/threads/:id", threadHandler.Delete)
 	authGroup.PUT("/replies/:id", replyHandler.Update)
 	authGroup.DELETE("/replies/:id", replyHandler.Delete)
+	authGroup.PUT("/threads/:id/accepted-reply", replyHandler.Accept)
+	authGroup.DELETE("/threads/:id/accepted-reply", replyHandler.Unaccept)
+	authGroup.POST("/threads/:id/restore", trashHandler.RestoreThread)
+	authGroup.POST("/replies/:id/restore", trashHandler.RestoreReply)
+	authGroup.POST("/threads/:id/merge", moderationHandler.Merge)
+	authGroup.POST("/threads/:id/split", moderationHandler.Split)
+	authGroup.POST("/admin/likes/reconcile", likeReconcileHandler.Reconcile)
+	authGroup.GET("/admin/likes/reconcile", likeReconcileHandler.Status)
+	authGroup.POST("/users/:id/reputation/penalties", reputationHandler.Penalize)
 	authGroup.POST("/threads/:id/like", threadLikeHandler.Like)
 	authGroup.DELETE("/threads/:id/like", threadLikeHandler.Unlike)
 	authGroup.GET("/threads/:id/like", threadLikeHandler.Status)
+	authGroup.POST("/likes/status", threadLikeHandler.BatchStatus)
+	authGroup.PUT("/threads/:id/reactions/:reaction", threadLikeHandler.React)
+	authGroup.DELETE("/threads/:id/reactions/:reaction", threadLikeHandler.Unreact)
+	authGroup.GET("/threads/:id/reactions", threadLikeHandler.Reactions)
+	authGroup.PUT("/threads/:id/vote", threadLikeHandler.Vote)
+	authGroup.DELETE("/threads/:id/vote", threadLikeHandler.Unvote)
+	authGroup.GET("/threads/:id/vote", threadLikeHandler.VoteStatus)
+	authGroup.POST("/replies/:id/like", replyLikeHandler.Like)
+	authGroup.DELETE("/replies/:id/like", replyLikeHandler.Unlike)
+	authGroup.GET("/replies/:id/like", replyLikeHandler.Status)
+	authGroup.PUT("/replies/:id/reactions/:reaction", replyLikeHandler.React)
+	authGroup.DELETE("/replies/:id/reactions/:reaction", replyLikeHandler.Unreact)
+	authGroup.GET("/replies/:id/reactions", replyLikeHandler.Reactions)
+	authGroup.PUT("/replies/:id/vote", replyLikeHandler.Vote)
+	authGroup.DELETE("/replies/:id/vote", replyLikeHandler.Unvote)
+	authGroup.GET("/replies/:id/vote", replyLikeHandler.VoteStatus)
+	authGroup.PUT("/threads/:id/bookmark", bookmarkHandler.Add)
+	authGroup.DELETE("/threads/:id/bookmark", bookmarkHandler.Remove)
+	authGroup.POST("/uploads", uploadHandler.Create)
+
+	if local, ok := blobStore.(*storage.LocalStore); ok && strings.HasPrefix(local.BaseURL(), "/") {
+		e.Static(local.BaseURL(), local.Dir())
+	}
 
 	e.GET("/healthz", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{
@@ -127,6 +297,8 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		httpSrv:      httpSrv,
 		workerCancel: cancel,
 		workerDone:   done,
+		shutdown:     shutdown,
+		workerWait:   drain + time.Second,
 	}, nil
 }
 
@@ -142,13 +314,17 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// 先标记停机再取消，后台任务据此回写剩余计数，且不超过 ctx 的截止时间
+	s.shutdown.begin(ctx)
 	if s.workerCancel != nil {
 		s.workerCancel()
 	}
+	// 回写最多 drain 时长，另留 1 秒给进行中的写库；不超过关闭服务的总超时
 	if s.workerDone != nil {
 		select {
 		case <-s.workerDone:
-		case <-time.After(2 * time.Second):
+		case <-time.After(s.workerWait):
+		case <-ctx.Done():
 		}
 	}
 
@@ -183,5 +359,86 @@ func (s *Server) Close() error {
 }
 
 func runMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(&models.User{}, &models.Thread{}, &models.Reply{}, &models.ThreadLike{})
+	hasThreads := db.Migrator().HasTable(&models.Thread{})
+	backfillReplyStats := hasThreads && !db.Migrator().HasColumn(&models.Thread{}, "ReplyCount")
+	backfillActivity := hasThreads && !db.Migrator().HasColumn(&models.Thread{}, "LastActivityAt")
+	backfillThreadScore := hasThreads && !db.Migrator().HasColumn(&models.Thread{}, "Score")
+	backfillReplyScore := db.Migrator().HasTable(&models.Reply{}) && !db.Migrator().HasColumn(&models.Reply{}, "Score")
+
+	if err := db.AutoMigrate(&models.User{}, &models.Thread{}, &models.Reply{}, &models.ThreadLike{}, &models.Upload{}, &models.ThreadBookmark{}, &models.Mention{}, &models.ReplyLike{}, &models.ReactionCount{}, &models.ReputationEvent{}); err != nil {
+		return err
+	}
+	// 点赞表扩展为表情回应后，唯一索引加入 reaction 列，旧索引需删除；
+	// thread_likes 的 idx_thread 已被 (thread_id, created_at, id) 联合索引覆盖
+	for _, idx := range []struct {
+		model interface{}
+		name  string
+	}{{&models.ThreadLike{}, "uidx_user_thread"}, {&models.ReplyLike{}, "uidx_user_reply"}, {&models.ThreadLike{}, "idx_thread"}} {
+		if db.Migrator().HasIndex(idx.model, idx.name) {
+			if err := db.Migrator().DropIndex(idx.model, idx.name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if backfillReplyStats {
+		if err := backfillThreadReplyStats(db); err != nil {
+			return err
+		}
+	}
+	if backfillActivity {
+		if err := db.Exec("UPDATE threads SET last_activity_at = GREATEST(created_at, COALESCE(last_reply_at, created_at))").Error; err != nil {
+			return err
+		}
+	}
+	// 加入踩之前没有踩数，净分即点赞数
+	if backfillThreadScore {
+		if err := db.Exec("UPDATE threads SET score = like_count").Error; err != nil {
+			return err
+		}
+	}
+	if backfillReplyScore {
+		if err := db.Exec("UPDATE replies SET score = like_count").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 一次性修正级联删除上线前删除的帖子，全表更新不放在启动迁移中：server cascade-deleted-threads
+func CascadeDeletedThreads(cfg *config.Config) error {
+	gormDB, err := db.NewMySQL(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := gormDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	return cascadeDeletedThreads(gormDB)
+}
+
+// 其回复、点赞按帖子的 deleted_at 补删，可重复执行
+func cascadeDeletedThreads(db *gorm.DB) error {
+	for _, table := range []string{"replies", "thread_likes"} {
+		if err := db.Exec(`
+UPDATE ` + table + ` c JOIN threads t ON c.thread_id = t.id
+SET c.deleted_at = t.deleted_at
+WHERE t.deleted_at IS NOT NULL AND c.deleted_at IS NULL`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reply_count 等冗余字段首次加列时，按现有回复补齐一次
+func backfillThreadReplyStats(db *gorm.DB) error {
+	return db.Exec(`
+UPDATE threads t SET
+	reply_count = (SELECT COUNT(*) FROM replies r WHERE r.thread_id = t.id AND r.deleted_at IS NULL),
+	last_reply_at = (SELECT MAX(r.created_at) FROM replies r WHERE r.thread_id = t.id AND r.deleted_at IS NULL),
+	last_reply_user_id = COALESCE((
+		SELECT r.user_id FROM replies r
+		WHERE r.thread_id = t.id AND r.deleted_at IS NULL
+		ORDER BY r.created_at DESC, r.id DESC LIMIT 1
+	), 0)`).Error
 }
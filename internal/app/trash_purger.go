@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+type TrashPurger struct {
+	repo      trashPurgeRepo
+	retention time.Duration
+	interval  time.Duration
+	batch     int
+}
+
+type trashPurgeRepo interface {
+	PurgeThreadsBefore(before time.Time, limit int) (int, error)
+	PurgeRepliesBefore(before time.Time, limit int) (int, error)
+}
+
+func NewTrashPurger(repo trashPurgeRepo, retention, interval time.Duration) *TrashPurger {
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &TrashPurger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		batch:     200,
+	}
+}
+
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purgeOnce(ctx)
+		}
+	}
+}
+
+// 先清帖子（连带其回复），再清单独删除的回复；每轮最多各处理 batch 条
+func (p *TrashPurger) purgeOnce(ctx context.Context) int {
+	before := time.Now().Add(-p.retention)
+	total := 0
+
+	n, err := p.repo.PurgeThreadsBefore(before, p.batch)
+	if err == nil {
+		total += n
+	}
+	if ctx.Err() != nil {
+		return total
+	}
+	n, err = p.repo.PurgeRepliesBefore(before, p.batch)
+	if err == nil {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeTrashPurgeRepo struct {
+	threadBefore time.Time
+	replyBefore  time.Time
+	limit        int
+}
+
+func (f *fakeTrashPurgeRepo) PurgeThreadsBefore(before time.Time, limit int) (int, error) {
+	f.threadBefore = before
+	f.limit = limit
+	return 2, nil
+}
+
+func (f *fakeTrashPurgeRepo) PurgeRepliesBefore(before time.Time, limit int) (int, error) {
+	f.replyBefore = before
+	return 3, nil
+}
+
+func TestTrashPurgerPurgeOnce(t *testing.T) {
+	repo := &fakeTrashPurgeRepo{}
+	p := NewTrashPurger(repo, 48*time.Hour, time.Minute)
+
+	n := p.purgeOnce(context.Background())
+	if n != 5 {
+		t.Fatalf("expected 5 purged, got %d", n)
+	}
+	if repo.limit != 200 {
+		t.Fatalf("expected batch 200, got %d", repo.limit)
+	}
+	cutoff := time.Now().Add(-48 * time.Hour)
+	if d := cutoff.Sub(repo.threadBefore); d < 0 || d > time.Second {
+		t.Fatalf("unexpected thread cutoff: %v", repo.threadBefore)
+	}
+	if !repo.replyBefore.Equal(repo.threadBefore) {
+		t.Fatalf("expected same cutoff for replies")
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/storage"
+	"log"
+	"time"
+)
+
+type UploadGC struct {
+	repo     uploadGCRepo
+	store    storage.BlobStore
+	grace    time.Duration
+	interval time.Duration
+	batch    int
+}
+
+type uploadGCRepo interface {
+	ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error)
+	DeleteUnattached(ids []uint) ([]uint, error)
+}
+
+func NewUploadGC(repo uploadGCRepo, store storage.BlobStore, grace, interval time.Duration) *UploadGC {
+	if grace <= 0 {
+		grace = 24 * time.Hour
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &UploadGC{
+		repo:     repo,
+		store:    store,
+		grace:    grace,
+		interval: interval,
+		batch:    200,
+	}
+}
+
+func (g *UploadGC) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.collectOnce(ctx)
+		}
+	}
+}
+
+// 先按未关联条件删除记录，再只删除这些记录的文件，列出之后才被关联的附件不受影响；
+// 文件删除失败时记录已不在，该文件不再重试
+func (g *UploadGC) collectOnce(ctx context.Context) int {
+	ups, err := g.repo.ListUnattachedBefore(time.Now().Add(-g.grace), g.batch)
+	if err != nil || len(ups) == 0 {
+		return 0
+	}
+
+	ids := make([]uint, len(ups))
+	keys := make(map[uint]string, len(ups))
+	for i, u := range ups {
+		ids[i] = u.ID
+		keys[u.ID] = u.Key
+	}
+	deleted, err := g.repo.DeleteUnattached(ids)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, id := range deleted {
+		if err := g.store.Delete(ctx, keys[id]); err != nil {
+			log.Printf("删除附件文件 %s 失败：%v", keys[id], err)
+			continue
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"exchangeapp/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type fakeUploadGCRepo struct {
+	ups []models.Upload
+	// 列出后被关联的附件，删除时跳过
+	attached map[uint]bool
+	before   time.Time
+	deleted  []uint
+}
+
+func (f *fakeUploadGCRepo) ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error) {
+	f.before = before
+	return f.ups, nil
+}
+
+func (f *fakeUploadGCRepo) DeleteUnattached(ids []uint) ([]uint, error) {
+	for _, id := range ids {
+		if !f.attached[id] {
+			f.deleted = append(f.deleted, id)
+		}
+	}
+	return f.deleted, nil
+}
+
+type fakeGCStore struct {
+	failKeys map[string]bool
+	deleted  []string
+}
+
+func (f *fakeGCStore) Put(context.Context, string, io.Reader, int64, string) error {
+	return nil
+}
+
+func (f *fakeGCStore) Delete(_ context.Context, key string) error {
+	if f.failKeys[key] {
+		return errors.New("delete failed")
+	}
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *fakeGCStore) URL(key string) string {
+	return key
+}
+
+func TestUploadGCCollectOnce(t *testing.T) {
+	repo := &fakeUploadGCRepo{
+		ups: []models.Upload{
+			{Model: gormModel(1), Key: "a"},
+			{Model: gormModel(2), Key: "b"},
+			{Model: gormModel(3), Key: "c"},
+			{Model: gormModel(4), Key: "d"},
+		},
+		attached: map[uint]bool{4: true},
+	}
+	store := &fakeGCStore{failKeys: map[string]bool{"b": true}}
+	gc := NewUploadGC(repo, store, time.Hour, time.Minute)
+
+	n := gc.collectOnce(context.Background())
+	if n != 2 {
+		t.Fatalf("expected 2 collected, got %d", n)
+	}
+	if !reflect.DeepEqual(repo.deleted, []uint{1, 2, 3}) {
+		t.Fatalf("unexpected deleted rows: %v", repo.deleted)
+	}
+	// 列出后被关联的附件保留文件
+	if !reflect.DeepEqual(store.deleted, []string{"a", "c"}) {
+		t.Fatalf("unexpected deleted blobs: %v", store.deleted)
+	}
+	if time.Since(repo.before) < time.Hour {
+		t.Fatalf("expected grace period applied, got %v", repo.before)
+	}
+}
+
+func gormModel(id uint) gorm.Model {
+	return gorm.Model{ID: id}
+}
@@ -10,11 +10,18 @@ import (
 )
 
 type Config struct {
-	App        AppConfig
-	Database   DatabaseConfig
-	Redis      RedisConfig
-	JWT        JWTConfig
-	LikeWorker LikeWorkerConfig
+	App           AppConfig
+	Database      DatabaseConfig
+	Redis         RedisConfig
+	JWT           JWTConfig
+	LikeWorker    LikeWorkerConfig    `mapstructure:"like_worker"`
+	LikeReconcile LikeReconcileConfig `mapstructure:"like_reconcile"`
+	Leader        LeaderConfig
+	Upload        UploadConfig
+	Trash         TrashConfig
+	Reactions     ReactionsConfig
+	Votes         VotesConfig
+	Reputation    ReputationConfig
 }
 
 type AppConfig struct {
@@ -38,12 +45,56 @@ type RedisConfig struct {
 
 type LikeWorkerConfig struct {
 	Batch           int
-	IntervalSeconds int
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// 领取 dirty ID 后未确认的租约时长，超时后由下一次回写重新领取
+	LeaseSeconds int `mapstructure:"lease_seconds"`
+	// 停机时回写剩余 dirty ID 的最长时间，同时受关闭服务的总超时限制
+	DrainSeconds int `mapstructure:"drain_seconds"`
+}
+
+type LikeReconcileConfig struct {
+	Batch           int
+	IntervalMinutes int `mapstructure:"interval_minutes"`
+}
+
+// 多实例部署时后台任务只在 leader 上运行，租约过期后由其他实例接管
+type LeaderConfig struct {
+	TTLSeconds int `mapstructure:"ttl_seconds"`
 }
 
 type JWTConfig struct {
 	Secret        string
-	ExpireMinutes uint
+	ExpireMinutes uint `mapstructure:"expire_minutes"`
+}
+
+type UploadConfig struct {
+	Driver            string
+	MaxSizeMB         int      `mapstructure:"max_size_mb"`
+	AllowedTypes      []string `mapstructure:"allowed_types"`
+	LocalDir          string   `mapstructure:"local_dir"`
+	PublicBaseURL     string   `mapstructure:"public_base_url"`
+	GCIntervalMinutes int      `mapstructure:"gc_interval_minutes"`
+	GCGraceHours      int      `mapstructure:"gc_grace_hours"`
+	S3                S3Config
+}
+
+type TrashConfig struct {
+	RetentionDays        int `mapstructure:"retention_days"`
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"`
+}
+
+// 可用的表情回应名称，like 即原来的点赞，始终可用
+type ReactionsConfig struct {
+	Types []string
+}
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
 }
 
 func NewConfig() (*Config, error) {
@@ -84,3 +135,17 @@ func NewConfig() (*Config, error) {
 
 	return cfg, nil
 }
+
+type VotesConfig struct {
+	// 是否允许踩，关闭时帖子和回复只能点赞；没有版块模型，全站统一生效
+	Downvote bool
+}
+
+type ReputationConfig struct {
+	// 帖子每收到一个赞，作者获得的声望
+	ThreadLiked int64 `mapstructure:"thread_liked"`
+	// 回复被采纳为答案，作者获得的声望
+	AnswerAccepted int64 `mapstructure:"answer_accepted"`
+	// 权限所需的最低声望，如 post_links: 10；未配置或不大于 0 时不限制
+	Privileges map[string]int64
+}
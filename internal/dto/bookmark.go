@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type BookmarkItemResp struct {
+	ThreadSummaryResp
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+type BookmarkListResp struct {
+	Items      []BookmarkItemResp `json:"items"`
+	Size       int                `json:"size"`
+	NextCursor string             `json:"next_cursor"`
+}
+
+type BookmarkStatusResp struct {
+	Bookmarked bool `json:"bookmarked"`
+}
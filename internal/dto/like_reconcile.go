@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type LikeDriftResp struct {
+	ThreadID uint  `json:"thread_id"`
+	Actual   int64 `json:"actual"`
+	Stored   int64 `json:"stored"`
+	// Redis 中没有该帖子的计数时为空
+	Cached *int64 `json:"cached,omitempty"`
+}
+
+type LikeReconcileResp struct {
+	Checked int `json:"checked"`
+	Drifted int `json:"drifted"`
+	// 最多列出前 100 条偏差
+	Drifts []LikeDriftResp `json:"drifts"`
+}
+
+// 对账状态只保存在本实例内存中，包括本实例上定期对账的结果
+type LikeReconcileStatusResp struct {
+	Running    bool       `json:"running"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// 尚未完成过对账时为空
+	Report *LikeReconcileResp `json:"report,omitempty"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+type MentionResp struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+type QuoteResp struct {
+	ReplyID uint `json:"reply_id"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type MergeThreadReq struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+type SplitThreadReq struct {
+	Title    string `json:"title" binding:"required,min=1,max=200"`
+	ReplyIDs []uint `json:"reply_ids" binding:"required,min=1,max=100"`
+}
+
+type ThreadRefResp struct {
+	ThreadID uint `json:"thread_id"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+type ReactionsResp struct {
+	Counts map[string]int64 `json:"counts"`
+	Mine   []string         `json:"mine"`
+}
+
+type ThreadLikerResp struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	LikedAt  time.Time `json:"liked_at"`
+}
+
+type ThreadLikerListResp struct {
+	Items      []ThreadLikerResp `json:"items"`
+	Size       int               `json:"size"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+type LikeStatusBatchReq struct {
+	ThreadIDs []uint `json:"thread_ids" binding:"required,min=1,max=100,dive,min=1"`
+}
@@ -3,15 +3,53 @@ package dto
 import "time"
 
 type CreateReplyReq struct {
-	Content string `json:"content" binding:"required,min=1,max=4000"`
+	Content       string `json:"content" binding:"required,min=1,max=4000"`
+	ContentFormat string `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	ParentID      uint   `json:"parent_id"`
+	AttachmentIDs []uint `json:"attachment_ids" binding:"omitempty,max=20"`
 }
 
 type ReplyResp struct {
-	ID        uint      `json:"id"`
-	ThreadID  uint      `json:"thread_id"`
-	Content   string    `json:"content"`
-	UserID    uint      `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint             `json:"id"`
+	ThreadID      uint             `json:"thread_id"`
+	Content       string           `json:"content"`
+	ContentFormat string           `json:"content_format"`
+	ContentHTML   string           `json:"content_html"`
+	UserID        uint             `json:"user_id"`
+	Version       uint             `json:"version"`
+	ParentID      uint             `json:"parent_id"`
+	Depth         uint             `json:"depth"`
+	ReplyCount    int64            `json:"reply_count"`
+	LikeCount     int64            `json:"like_count"`
+	DownvoteCount int64            `json:"downvote_count"`
+	Score         int64            `json:"score"`
+	Reactions     map[string]int64 `json:"reactions"`
+	Accepted      bool             `json:"accepted"`
+	Deleted       bool             `json:"deleted"`
+	Attachments   []UploadResp     `json:"attachments"`
+	Mentions      []MentionResp    `json:"mentions"`
+	Quotes        []QuoteResp      `json:"quotes"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+type ReplyTreeResp struct {
+	Reply      ReplyResp   `json:"reply"`
+	Children   []ReplyResp `json:"children"`
+	Size       int         `json:"size"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+type ReplyContextResp struct {
+	Ancestors []ReplyResp `json:"ancestors"`
+	Reply     ReplyResp   `json:"reply"`
+}
+
+type ReplyLocateResp struct {
+	ReplyID  uint   `json:"reply_id"`
+	ThreadID uint   `json:"thread_id"`
+	Page     int    `json:"page"`
+	Size     int    `json:"size"`
+	Cursor   string `json:"cursor"`
 }
 
 type ReplyListResp struct {
@@ -23,5 +61,6 @@ type ReplyListResp struct {
 }
 
 type UpdateReplyReq struct {
-	Content string `json:"content" binding:"required,min=1,max=4000"`
+	Content       string `json:"content" binding:"required,min=1,max=4000"`
+	ContentFormat string `json:"content_format" binding:"omitempty,oneof=plain markdown"`
 }
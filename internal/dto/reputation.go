@@ -0,0 +1,29 @@
+package dto
+
+type ProfileResp struct {
+	ID         uint   `json:"id"`
+	Username   string `json:"username"`
+	Reputation int64  `json:"reputation"`
+}
+
+type PenaltyReq struct {
+	Points int64  `json:"points" binding:"required,min=1,max=10000"`
+	Note   string `json:"note" binding:"max=200"`
+}
+
+type ReputationResp struct {
+	UserID     uint  `json:"user_id"`
+	Reputation int64 `json:"reputation"`
+}
+
+type LeaderboardItem struct {
+	Rank       int    `json:"rank"`
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	Reputation int64  `json:"reputation"`
+}
+
+type LeaderboardResp struct {
+	Window string            `json:"window"`
+	Items  []LeaderboardItem `json:"items"`
+}
@@ -3,24 +3,48 @@ package dto
 import "time"
 
 type CreateThreadReq struct {
-	Title   string `json:"title" binding:"required,min=1,max=200"`
-	Content string `json:"content" binding:"required,min=1,max=10000"`
+	Title         string `json:"title" binding:"required,min=1,max=200"`
+	Content       string `json:"content" binding:"required,min=1,max=10000"`
+	ContentFormat string `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	Type          string `json:"type" binding:"omitempty,oneof=discussion question"`
+	AttachmentIDs []uint `json:"attachment_ids" binding:"omitempty,max=20"`
 }
 
 type ThreadSummaryResp struct {
-	ID        uint      `json:"id"`
-	Title     string    `json:"title"`
-	UserID    uint      `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uint       `json:"id"`
+	Title           string     `json:"title"`
+	UserID          uint       `json:"user_id"`
+	Type            string     `json:"type"`
+	AcceptedReplyID uint       `json:"accepted_reply_id"`
+	ReplyCount      int64      `json:"reply_count"`
+	LikeCount       int64      `json:"like_count"`
+	DownvoteCount   int64      `json:"downvote_count"`
+	Score           int64      `json:"score"`
+	LastReplyAt     *time.Time `json:"last_reply_at"`
+	LastReplyUserID uint       `json:"last_reply_user_id"`
+	LastActivityAt  time.Time  `json:"last_activity_at"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 type ThreadDetailResp struct {
-	ID        uint      `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	UserID    uint      `json:"user_id"`
-	LikeCount int64     `json:"like_count"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uint             `json:"id"`
+	Title           string           `json:"title"`
+	Content         string           `json:"content"`
+	ContentFormat   string           `json:"content_format"`
+	ContentHTML     string           `json:"content_html"`
+	UserID          uint             `json:"user_id"`
+	Type            string           `json:"type"`
+	AcceptedReplyID uint             `json:"accepted_reply_id"`
+	LikeCount       int64            `json:"like_count"`
+	DownvoteCount   int64            `json:"downvote_count"`
+	Score           int64            `json:"score"`
+	Reactions       map[string]int64 `json:"reactions"`
+	Version         uint             `json:"version"`
+	Attachments     []UploadResp     `json:"attachments"`
+	Mentions        []MentionResp    `json:"mentions"`
+	Quotes          []QuoteResp      `json:"quotes"`
+	Bookmarked      bool             `json:"bookmarked"`
+	CreatedAt       time.Time        `json:"created_at"`
 }
 
 type ThreadListResp struct {
@@ -31,7 +55,17 @@ type ThreadListResp struct {
 	NextCursor string              `json:"next_cursor"`
 }
 
+type AcceptReplyReq struct {
+	ReplyID uint `json:"reply_id" binding:"required"`
+}
+
+type AcceptedReplyResp struct {
+	ThreadID        uint `json:"thread_id"`
+	AcceptedReplyID uint `json:"accepted_reply_id"`
+}
+
 type UpdateThreadReq struct {
-	Title   string `json:"title" binding:"required,min=1,max=200"`
-	Content string `json:"content" binding:"required,min=1,max=10000"`
+	Title         string `json:"title" binding:"required,min=1,max=200"`
+	Content       string `json:"content" binding:"required,min=1,max=10000"`
+	ContentFormat string `json:"content_format" binding:"omitempty,oneof=plain markdown"`
 }
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type TrashItemResp struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	ThreadID  uint      `json:"thread_id"`
+	Title     string    `json:"title,omitempty"`
+	Content   string    `json:"content"`
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashListResp struct {
+	Items      []TrashItemResp `json:"items"`
+	Size       int             `json:"size"`
+	NextCursor string          `json:"next_cursor"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type UploadResp struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Filename  string    `json:"filename"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -20,3 +20,11 @@ type LoginResp struct {
 	ID       uint   `json:"id"`
 	Token    string `json:"token"`
 }
+
+type PrivacyReq struct {
+	HideLikes *bool `json:"hide_likes" binding:"required"`
+}
+
+type PrivacyResp struct {
+	HideLikes bool `json:"hide_likes"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type VoteReq struct {
+	Vote string `json:"vote" binding:"required,oneof=up down"`
+}
+
+// vote 为 up、down 或空（未投票）；score = like_count - downvote_count
+type VoteResp struct {
+	Vote          string `json:"vote"`
+	LikeCount     int64  `json:"like_count"`
+	DownvoteCount int64  `json:"downvote_count"`
+	Score         int64  `json:"score"`
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkHandler struct {
+	svc *service.BookmarkService
+}
+
+func NewBookmarkHandler(svc *service.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{svc: svc}
+}
+
+func (h *BookmarkHandler) Add(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Add(userID, threadID); err != nil {
+		if errors.Is(err, service.ErrThreadNotFound) {
+			jsonError(ctx, http.StatusNotFound, "帖子不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "收藏失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"bookmarked": true})
+}
+
+func (h *BookmarkHandler) Remove(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Remove(userID, threadID); err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "取消收藏失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"bookmarked": false})
+}
+
+func (h *BookmarkHandler) ListMine(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	_, size := parsePageSize("", ctx.Query("size"))
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		cursorTime, cursorID, ok := parseCursor(cursor)
+		if !ok {
+			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
+			return
+		}
+		resp, err := h.svc.ListAfter(userID, cursorTime, cursorID, size)
+		if err != nil {
+			jsonError(ctx, http.StatusInternalServerError, "获取收藏失败")
+			return
+		}
+		ctx.JSON(http.StatusOK, resp)
+		return
+	}
+
+	resp, err := h.svc.List(userID, size)
+	if err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "获取收藏失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeBookmarkRepo struct {
+	created int
+	deleted int
+}
+
+func (f *fakeBookmarkRepo) Create(uint, uint) error {
+	f.created++
+	return nil
+}
+
+func (f *fakeBookmarkRepo) Delete(uint, uint) error {
+	f.deleted++
+	return nil
+}
+
+func (f *fakeBookmarkRepo) Exists(uint, uint) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeBookmarkRepo) ListByUserID(uint, int) ([]repository.BookmarkedThread, error) {
+	return nil, nil
+}
+
+func (f *fakeBookmarkRepo) ListByUserIDAfter(uint, time.Time, uint, int) ([]repository.BookmarkedThread, error) {
+	return nil, nil
+}
+
+func newBookmarkRouter(threadRepo *fakeThreadRepo, bookmarks *fakeBookmarkRepo, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewBookmarkService(threadRepo, bookmarks, threadRepo)
+	h := NewBookmarkHandler(svc)
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(userID))
+	auth.PUT("/threads/:id/bookmark", h.Add)
+	auth.DELETE("/threads/:id/bookmark", h.Remove)
+	auth.GET("/me/bookmarks", h.ListMine)
+	return r
+}
+
+func TestBookmarkEndpointsStatus(t *testing.T) {
+	cases := []struct {
+		method string
+		url    string
+		thread *models.Thread
+		userID uint
+		code   int
+	}{
+		{http.MethodPut, "/api/threads/1/bookmark", &models.Thread{ID: 1}, 0, http.StatusUnauthorized},
+		{http.MethodPut, "/api/threads/1/bookmark", nil, 1, http.StatusNotFound},
+		{http.MethodPut, "/api/threads/1/bookmark", &models.Thread{ID: 1}, 1, http.StatusOK},
+		{http.MethodDelete, "/api/threads/1/bookmark", nil, 1, http.StatusOK},
+		{http.MethodGet, "/api/me/bookmarks?cursor=bad", nil, 1, http.StatusBadRequest},
+		{http.MethodGet, "/api/me/bookmarks", nil, 1, http.StatusOK},
+	}
+	for i, c := range cases {
+		r := newBookmarkRouter(&fakeThreadRepo{findResult: c.thread}, &fakeBookmarkRepo{}, c.userID)
+		req := httptest.NewRequest(c.method, c.url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("case %d: expected %d, got %d, body=%s", i, c.code, w.Code, w.Body.String())
+		}
+	}
+}
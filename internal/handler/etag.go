@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/service"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setETag(ctx *gin.Context, version uint) {
+	ctx.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// 编辑接口必须携带 If-Match，取值为详情接口返回的 ETag，弱校验前缀 W/ 也接受
+func parseIfMatch(ctx *gin.Context) (uint, bool) {
+	raw := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if raw == "" {
+		jsonError(ctx, http.StatusPreconditionRequired, "缺少 If-Match")
+		return 0, false
+	}
+	raw = strings.TrimPrefix(raw, "W/")
+	raw = strings.Trim(raw, `"`)
+	version, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		jsonError(ctx, http.StatusBadRequest, "If-Match 无效")
+		return 0, false
+	}
+	return uint(version), true
+}
+
+func writeVersionConflict(ctx *gin.Context, err error) bool {
+	var conflict *service.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	setETag(ctx, conflict.Current)
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "内容已被修改",
+		"version": conflict.Current,
+	})
+	return true
+}
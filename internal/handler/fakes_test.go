@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
 )
 
 type fakeThreadRepo struct {
@@ -24,6 +27,11 @@ type fakeThreadRepo struct {
 	created   *models.Thread
 	updated   *models.Thread
 	deletedID uint
+
+	accepted       []uint
+	acceptMissed   bool
+	unansweredArgs []bool
+	scoreCursors   []int64
 }
 
 func (f *fakeThreadRepo) Create(t *models.Thread) error {
@@ -48,6 +56,29 @@ func (f *fakeThreadRepo) ListAfter(cursorTime time.Time, cursorID uint, limit in
 	return f.listResult, f.listErr
 }
 
+func (f *fakeThreadRepo) ListByActivity(limit, offset int) ([]models.Thread, error) {
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByActivityAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	if f.listAfterResult != nil || f.listAfterErr != nil {
+		return f.listAfterResult, f.listAfterErr
+	}
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByScore(limit, offset int) ([]models.Thread, error) {
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByScoreAfter(cursorScore int64, cursorID uint, limit int) ([]models.Thread, error) {
+	f.scoreCursors = append(f.scoreCursors, cursorScore)
+	if f.listAfterResult != nil || f.listAfterErr != nil {
+		return f.listAfterResult, f.listAfterErr
+	}
+	return f.listResult, f.listErr
+}
+
 func (f *fakeThreadRepo) Count() (int64, error) {
 	return f.countResult, f.countErr
 }
@@ -71,6 +102,21 @@ func (f *fakeThreadRepo) FindByID(id uint) (*models.Thread, error) {
 	return f.findResult, f.findErr
 }
 
+func (f *fakeThreadRepo) FindByIDs(ids []uint) ([]models.Thread, error) {
+	if f.findErr != nil {
+		return nil, f.findErr
+	}
+	var res []models.Thread
+	for _, id := range ids {
+		for _, t := range f.listResult {
+			if t.ID == id {
+				res = append(res, t)
+			}
+		}
+	}
+	return res, nil
+}
+
 func (f *fakeThreadRepo) Update(t *models.Thread) error {
 	f.updated = t
 	return f.updateErr
@@ -89,6 +135,36 @@ func (f *fakeThreadRepo) GetLikeCount(threadID uint) (int64, error) {
 	return 0, nil
 }
 
+func (f *fakeThreadRepo) ListUnanswered(byActivity bool, limit, offset int) ([]models.Thread, error) {
+	f.unansweredArgs = append(f.unansweredArgs, byActivity)
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	f.unansweredArgs = append(f.unansweredArgs, byActivity)
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) CountUnanswered() (int64, error) {
+	return f.countResult, f.countErr
+}
+
+func (f *fakeThreadRepo) SetAcceptedReply(threadID, fromID, toID uint) (bool, error) {
+	if f.acceptMissed {
+		return false, nil
+	}
+	f.accepted = append(f.accepted, toID)
+	return true, nil
+}
+
+func (f *fakeThreadRepo) ClearAcceptedReply(threadID, replyID uint) error {
+	return nil
+}
+
+func (f *fakeThreadRepo) UpdateReplyStats(threadID uint, delta int, last *models.Reply) error {
+	return nil
+}
+
 type fakeReplyRepo struct {
 	createErr    error
 	listErr      error
@@ -102,11 +178,16 @@ type fakeReplyRepo struct {
 	listAfterResult    []models.Reply
 	listByUserAfterRes []models.Reply
 	countResult        int64
+	beforeCount        int64
 	findResult         *models.Reply
 
 	created   *models.Reply
 	updated   *models.Reply
 	deletedID uint
+
+	withDeleted map[uint]*models.Reply
+	children    []models.Reply
+	childCounts map[uint]int64
 }
 
 func (f *fakeReplyRepo) Create(r *models.Reply) error {
@@ -120,17 +201,21 @@ func (f *fakeReplyRepo) Create(r *models.Reply) error {
 	return nil
 }
 
-func (f *fakeReplyRepo) ListByThreadID(threadID uint, limit, offset int) ([]models.Reply, error) {
+func (f *fakeReplyRepo) ListByThreadID(threadID uint, desc bool, limit, offset int) ([]models.Reply, error) {
 	return f.listResult, f.listErr
 }
 
-func (f *fakeReplyRepo) ListByThreadIDAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+func (f *fakeReplyRepo) ListByThreadIDAfter(threadID uint, desc bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
 	if f.listAfterResult != nil || f.listAfterErr != nil {
 		return f.listAfterResult, f.listAfterErr
 	}
 	return f.listResult, f.listErr
 }
 
+func (f *fakeReplyRepo) CountByThreadIDBefore(uint, bool, time.Time, uint) (int64, error) {
+	return f.beforeCount, f.countErr
+}
+
 func (f *fakeReplyRepo) CountByThreadID(threadID uint) (int64, error) {
 	return f.countResult, f.countErr
 }
@@ -154,6 +239,25 @@ func (f *fakeReplyRepo) FindByID(id uint) (*models.Reply, error) {
 	return f.findResult, f.findErr
 }
 
+func (f *fakeReplyRepo) FindWithDeleted(id uint) (*models.Reply, error) {
+	if r, ok := f.withDeleted[id]; ok {
+		return r, nil
+	}
+	return f.findResult, f.findErr
+}
+
+func (f *fakeReplyRepo) ListChildren(uint, time.Time, uint, int) ([]models.Reply, error) {
+	return f.children, nil
+}
+
+func (f *fakeReplyRepo) CountChildren([]uint) (map[uint]int64, error) {
+	return f.childCounts, nil
+}
+
+func (f *fakeReplyRepo) FindLatestByThreadID(threadID uint) (*models.Reply, error) {
+	return nil, nil
+}
+
 func (f *fakeReplyRepo) Update(r *models.Reply) error {
 	f.updated = r
 	return f.updateErr
@@ -170,6 +274,15 @@ type fakeThreadLikeRepo struct {
 	existsErr error
 	exists    bool
 
+	reactions []string
+	likers    []repository.ThreadLiker
+
+	likersAfter []uint
+
+	likedIDs     []uint
+	likedQueries [][]uint
+	recentCalls  int
+
 	created *models.ThreadLike
 	deleted bool
 }
@@ -179,15 +292,107 @@ func (f *fakeThreadLikeRepo) Create(t *models.ThreadLike) error {
 	return f.createErr
 }
 
-func (f *fakeThreadLikeRepo) Delete(userID, threadID uint) error {
+func (f *fakeThreadLikeRepo) Delete(userID, threadID uint, reaction string) error {
 	f.deleted = true
 	return f.deleteErr
 }
 
-func (f *fakeThreadLikeRepo) Exists(userID, threadID uint) (bool, error) {
+func (f *fakeThreadLikeRepo) Exists(userID, threadID uint, reaction string) (bool, error) {
 	return f.exists, f.existsErr
 }
 
+func (f *fakeThreadLikeRepo) ListReactions(userID, threadID uint) ([]string, error) {
+	return f.reactions, nil
+}
+
 func (f *fakeThreadLikeRepo) CountByThreadID(threadID uint) (int64, error) {
 	return 0, nil
 }
+
+func (f *fakeThreadLikeRepo) ListLikers(threadID uint, limit int) ([]repository.ThreadLiker, error) {
+	return f.likers, nil
+}
+
+func (f *fakeThreadLikeRepo) ListLikedThreadIDs(userID uint, threadIDs []uint) ([]uint, error) {
+	f.likedQueries = append(f.likedQueries, threadIDs)
+	var res []uint
+	for _, id := range threadIDs {
+		for _, liked := range f.likedIDs {
+			if id == liked {
+				res = append(res, id)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeThreadLikeRepo) ListRecentLikedThreadIDs(userID uint, limit int) ([]uint, error) {
+	f.recentCalls++
+	if len(f.likedIDs) > limit {
+		return f.likedIDs[:limit], nil
+	}
+	return f.likedIDs, nil
+}
+
+func (f *fakeThreadLikeRepo) ListLikersAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]repository.ThreadLiker, error) {
+	f.likersAfter = append(f.likersAfter, cursorID)
+	return f.likers, nil
+}
+
+type fakeUploadRepo struct {
+	createErr error
+	created   *models.Upload
+}
+
+func (f *fakeUploadRepo) Create(u *models.Upload) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	u.ID = 1
+	f.created = u
+	return nil
+}
+
+func (f *fakeUploadRepo) AttachToThread(userID, threadID uint, ids []uint) error {
+	return nil
+}
+
+func (f *fakeUploadRepo) AttachToReply(userID, replyID uint, ids []uint) error {
+	return nil
+}
+
+func (f *fakeUploadRepo) ListByThreadID(threadID uint) ([]models.Upload, error) {
+	return nil, nil
+}
+
+func (f *fakeUploadRepo) ListByReplyIDs(replyIDs []uint) ([]models.Upload, error) {
+	return nil, nil
+}
+
+func (f *fakeUploadRepo) ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error) {
+	return nil, nil
+}
+
+func (f *fakeUploadRepo) DeleteUnattached(ids []uint) ([]uint, error) {
+	return ids, nil
+}
+
+type fakeBlobStore struct {
+	keys []string
+}
+
+func (f *fakeBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+func (f *fakeBlobStore) Delete(_ context.Context, key string) error {
+	return nil
+}
+
+func (f *fakeBlobStore) URL(key string) string {
+	return "/uploads/" + key
+}
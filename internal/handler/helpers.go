@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"exchangeapp/internal/service"
 	"net/http"
 	"strconv"
 
@@ -33,6 +34,26 @@ func getUserID(ctx *gin.Context) (uint, bool) {
 	return userID, true
 }
 
+// 公开接口上可选登录，未登录返回 0
+func optionalUserID(ctx *gin.Context) uint {
+	userIDVal, ok := ctx.Get("userID")
+	if !ok {
+		return 0
+	}
+	userID, _ := userIDVal.(uint)
+	return userID
+}
+
+func getActor(ctx *gin.Context) (service.Actor, bool) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return service.Actor{}, false
+	}
+	role, _ := ctx.Get("role")
+	roleStr, _ := role.(string)
+	return service.Actor{UserID: userID, Role: roleStr}, true
+}
+
 func parseUintParam(ctx *gin.Context, name, errMsg string) (uint, bool) {
 	raw := ctx.Param(name)
 	val, err := strconv.ParseUint(raw, 10, 64)
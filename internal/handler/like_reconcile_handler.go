@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LikeReconcileHandler struct {
+	svc *service.LikeReconcileService
+	// 后台对账使用的 context，服务关闭时取消
+	bg context.Context
+}
+
+func NewLikeReconcileHandler(svc *service.LikeReconcileService, bg context.Context) *LikeReconcileHandler {
+	return &LikeReconcileHandler{svc: svc, bg: bg}
+}
+
+// 对账耗时与帖子数成正比，在后台执行，结果通过 Status 查询
+func (h *LikeReconcileHandler) Reconcile(ctx *gin.Context) {
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Trigger(h.bg, actor)
+	if err != nil {
+		writeReconcileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, resp)
+}
+
+func (h *LikeReconcileHandler) Status(ctx *gin.Context) {
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Status(actor)
+	if err != nil {
+		writeReconcileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func writeReconcileError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		jsonError(ctx, http.StatusForbidden, "需要版主权限")
+	case errors.Is(err, service.ErrReconcileRunning):
+		jsonError(ctx, http.StatusConflict, "对账正在进行")
+	default:
+		jsonError(ctx, http.StatusInternalServerError, "点赞数对账失败")
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeReconcileRepo struct {
+	rows []repository.ThreadLikeCount
+}
+
+func (f *fakeReconcileRepo) ListThreadLikeCounts(afterID uint, limit int) ([]repository.ThreadLikeCount, error) {
+	if afterID > 0 {
+		return nil, nil
+	}
+	return f.rows, nil
+}
+
+func (f *fakeReconcileRepo) CountThreadLikes([]uint) (map[uint]int64, error) {
+	return map[uint]int64{}, nil
+}
+
+func (f *fakeReconcileRepo) SetLikeCount(uint, int64) error {
+	return nil
+}
+
+type fakeLikeResetter struct{}
+
+func (f *fakeLikeResetter) GetLikeCounts([]uint) (map[uint]int64, error) {
+	return map[uint]int64{}, nil
+}
+
+func (f *fakeLikeResetter) ResetLikeCount(uint, int64) error {
+	return nil
+}
+
+func TestLikeReconcile(t *testing.T) {
+	cases := []struct {
+		name string
+		role string
+		want int
+		body string
+	}{
+		{"forbidden", models.RoleUser, http.StatusForbidden, ""},
+		{"accepted", models.RoleModerator, http.StatusAccepted, `"running":true`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newReconcileRouter(c.role)
+
+			w := doReconcile(r, http.MethodPost)
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.body != "" && !strings.Contains(w.Body.String(), c.body) {
+				t.Fatalf("expected %s in body, got %s", c.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLikeReconcileStatus(t *testing.T) {
+	if w := doReconcile(newReconcileRouter(models.RoleUser), http.MethodGet); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+
+	r := newReconcileRouter(models.RoleModerator)
+	if w := doReconcile(r, http.MethodPost); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d, body=%s", w.Code, w.Body.String())
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		w := doReconcile(r, http.MethodGet)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), `"drifted":1`) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected finished report, got %s", w.Body.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newReconcileRouter(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	repo := &fakeReconcileRepo{rows: []repository.ThreadLikeCount{{ID: 1, LikeCount: 3}}}
+	svc := service.NewLikeReconcileService(repo, &fakeLikeResetter{}, 10)
+	h := NewLikeReconcileHandler(svc, context.Background())
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(1), func(ctx *gin.Context) {
+		ctx.Set("role", role)
+	})
+	auth.POST("/admin/likes/reconcile", h.Reconcile)
+	auth.GET("/admin/likes/reconcile", h.Status)
+	return r
+}
+
+func doReconcile(r *gin.Engine, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/admin/likes/reconcile", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
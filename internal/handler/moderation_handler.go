@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	svc *service.ModerationService
+}
+
+func NewModerationHandler(svc *service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{svc: svc}
+}
+
+func (h *ModerationHandler) Merge(ctx *gin.Context) {
+	var req dto.MergeThreadReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Merge(actor, threadID, req.TargetID)
+	if err != nil {
+		writeModerationError(ctx, err, "合并失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ModerationHandler) Split(ctx *gin.Context) {
+	var req dto.SplitThreadReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Split(actor, threadID, req)
+	if err != nil {
+		writeModerationError(ctx, err, "拆分失败")
+		return
+	}
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+func writeModerationError(ctx *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		jsonError(ctx, http.StatusForbidden, "需要版主权限")
+	case errors.Is(err, service.ErrThreadNotFound):
+		jsonError(ctx, http.StatusNotFound, "帖子不存在")
+	case errors.Is(err, service.ErrMergeSameThread):
+		jsonError(ctx, http.StatusBadRequest, "不能合并到同一个帖子")
+	case errors.Is(err, service.ErrInvalidReplies):
+		jsonError(ctx, http.StatusBadRequest, "回复选择无效")
+	default:
+		jsonError(ctx, http.StatusInternalServerError, fallback)
+	}
+}
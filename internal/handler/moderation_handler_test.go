@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeModerationRepo struct {
+	replies []models.Reply
+}
+
+func (f *fakeModerationRepo) ListRepliesByIDs(uint, []uint) ([]models.Reply, error) {
+	return f.replies, nil
+}
+
+func (f *fakeModerationRepo) MoveReplies(uint, uint, []uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) MergeLikes(uint, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveThreadUploadsToReply(uint, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveReplyUploadsToThread(uint, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) HardDeleteReply(uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) RecalcThreadStats(uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveMentions(string, uint, string, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) DetachOrphanReplies(uint) error {
+	return nil
+}
+
+func newModerationRouter(threadRepo *fakeThreadRepo, modRepo *fakeModerationRepo, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewModerationService(threadRepo, &fakeReplyRepo{}, modRepo, nil, nil, nil)
+	h := NewModerationHandler(svc)
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(1), func(ctx *gin.Context) {
+		ctx.Set("role", role)
+	})
+	auth.POST("/threads/:id/merge", h.Merge)
+	auth.POST("/threads/:id/split", h.Split)
+	return r
+}
+
+func TestModerationEndpointsStatus(t *testing.T) {
+	cases := []struct {
+		role string
+		url  string
+		body string
+		code int
+	}{
+		{"", "/api/threads/1/merge", `{"target_id":2}`, http.StatusForbidden},
+		{models.RoleModerator, "/api/threads/1/merge", `{}`, http.StatusBadRequest},
+		{models.RoleModerator, "/api/threads/1/merge", `{"target_id":1}`, http.StatusBadRequest},
+		{models.RoleModerator, "/api/threads/1/merge", `{"target_id":2}`, http.StatusOK},
+		{models.RoleModerator, "/api/threads/1/split", `{"title":"t","reply_ids":[]}`, http.StatusBadRequest},
+		{models.RoleModerator, "/api/threads/1/split", `{"title":"t","reply_ids":[3]}`, http.StatusCreated},
+	}
+	for i, c := range cases {
+		threadRepo := &fakeThreadRepo{findResult: &models.Thread{ID: 1, UserID: 1}}
+		modRepo := &fakeModerationRepo{replies: []models.Reply{{ID: 3, ThreadID: 1}}}
+		r := newModerationRouter(threadRepo, modRepo, c.role)
+
+		req := httptest.NewRequest(http.MethodPost, c.url, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("case %d: expected %d, got %d, body=%s", i, c.code, w.Code, w.Body.String())
+		}
+	}
+}
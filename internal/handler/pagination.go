@@ -12,6 +12,20 @@ const (
 	maxSize     = 50
 )
 
+const (
+	sortLatest = "latest"
+	sortActive = "active"
+	sortScore  = "score"
+)
+
+// 帖子列表筛选，目前只有未采纳答案的问答帖
+const filterUnanswered = "unanswered"
+
+const (
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
 func parsePageSize(pageStr, sizeStr string) (int, int) {
 	page := defaultPage
 	size := defaultSize
@@ -28,24 +42,61 @@ func parsePageSize(pageStr, sizeStr string) (int, int) {
 	return page, size
 }
 
+// 回复列表排序，默认从早到晚
+func parseOrder(raw string) (bool, bool) {
+	switch raw {
+	case "", orderAsc:
+		return false, true
+	case orderDesc:
+		return true, true
+	}
+	return false, false
+}
+
 func parseCursor(raw string) (time.Time, uint, bool) {
-	if raw == "" {
+	ts, id, ok := parseScoreCursor(raw)
+	if !ok {
 		return time.Time{}, 0, false
 	}
+	return time.Unix(0, ts), id, true
+}
+
+// 按净分排序的游标 <score>_<id>，score 可以为负
+func parseScoreCursor(raw string) (int64, uint, bool) {
+	if raw == "" {
+		return 0, 0, false
+	}
 	parts := strings.Split(raw, "_")
 	if len(parts) != 2 {
-		return time.Time{}, 0, false
+		return 0, 0, false
 	}
 
-	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	score, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		return time.Time{}, 0, false
+		return 0, 0, false
 	}
 
 	id, err := strconv.ParseUint(parts[1], 10, 64)
 	if err != nil {
-		return time.Time{}, 0, false
+		return 0, 0, false
 	}
 
-	return time.Unix(0, ts), uint(id), true
+	return score, uint(id), true
+}
+
+// 解析逗号分隔的 ID 列表，最多 maxSize 个
+func parseIDList(raw string) ([]uint, bool) {
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxSize {
+		return nil, false
+	}
+	ids := make([]uint, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64)
+		if err != nil || id == 0 {
+			return nil, false
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, true
 }
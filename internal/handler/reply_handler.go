@@ -3,8 +3,10 @@ package handler
 import (
 	"errors"
 	"exchangeapp/internal/dto"
+	"exchangeapp/internal/repository"
 	"exchangeapp/internal/service"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,6 +41,22 @@ func (h *ReplyHandler) Create(ctx *gin.Context) {
 			jsonError(ctx, http.StatusNotFound, "帖子不存在")
 			return
 		}
+		if errors.Is(err, repository.ErrUploadNotAttachable) {
+			jsonError(ctx, http.StatusBadRequest, "附件无效")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidParent) {
+			jsonError(ctx, http.StatusBadRequest, "父回复无效")
+			return
+		}
+		if errors.Is(err, service.ErrReplyTooDeep) {
+			jsonError(ctx, http.StatusBadRequest, "回复层级过深")
+			return
+		}
+		if errors.Is(err, service.ErrReputationTooLow) {
+			jsonError(ctx, http.StatusForbidden, "声望不足，暂不能发布链接")
+			return
+		}
 		jsonError(ctx, http.StatusInternalServerError, "回复失败")
 		return
 	}
@@ -52,6 +70,11 @@ func (h *ReplyHandler) ListByThreadID(ctx *gin.Context) {
 		return
 	}
 
+	desc, ok := parseOrder(ctx.Query("order"))
+	if !ok {
+		jsonError(ctx, http.StatusBadRequest, "order 无效")
+		return
+	}
 	cursor := ctx.Query("cursor")
 	page, size := parsePageSize(ctx.Query("page"), ctx.Query("size"))
 
@@ -61,7 +84,7 @@ func (h *ReplyHandler) ListByThreadID(ctx *gin.Context) {
 			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
 			return
 		}
-		resp, err := h.svc.ListByThreadIDAfter(threadID, cursorTime, cursorID, size)
+		resp, err := h.svc.ListByThreadIDAfter(threadID, desc, cursorTime, cursorID, size)
 		if err != nil {
 			if errors.Is(err, service.ErrThreadNotFound) {
 				jsonError(ctx, http.StatusNotFound, "帖子不存在")
@@ -71,7 +94,7 @@ func (h *ReplyHandler) ListByThreadID(ctx *gin.Context) {
 		}
 		ctx.JSON(http.StatusOK, resp)
 	} else {
-		resp, err := h.svc.ListByThreadID(threadID, page, size)
+		resp, err := h.svc.ListByThreadID(threadID, desc, page, size)
 		if err != nil {
 			if errors.Is(err, service.ErrThreadNotFound) {
 				jsonError(ctx, http.StatusNotFound, "帖子不存在")
@@ -84,6 +107,77 @@ func (h *ReplyHandler) ListByThreadID(ctx *gin.Context) {
 	}
 }
 
+func (h *ReplyHandler) ListChildren(ctx *gin.Context) {
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	var cursorTime time.Time
+	var cursorID uint
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		cursorTime, cursorID, ok = parseCursor(cursor)
+		if !ok {
+			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
+			return
+		}
+	}
+	_, size := parsePageSize("", ctx.Query("size"))
+
+	resp, err := h.svc.ListChildren(replyID, cursorTime, cursorID, size)
+	if err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取回复失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReplyHandler) Locate(ctx *gin.Context) {
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+	desc, ok := parseOrder(ctx.Query("order"))
+	if !ok {
+		jsonError(ctx, http.StatusBadRequest, "order 无效")
+		return
+	}
+	_, size := parsePageSize("", ctx.Query("size"))
+
+	resp, err := h.svc.Locate(replyID, desc, size)
+	if err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "定位回复失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReplyHandler) Context(ctx *gin.Context) {
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Context(replyID)
+	if err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取回复失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
 func (h *ReplyHandler) ListMine(ctx *gin.Context) {
 	userID, ok := getUserID(ctx)
 	if !ok {
@@ -131,8 +225,16 @@ func (h *ReplyHandler) Update(ctx *gin.Context) {
 		return
 	}
 
-	resp, err := h.svc.Update(userID, replyID, req)
+	version, ok := parseIfMatch(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Update(userID, replyID, version, req)
 	if err != nil {
+		if writeVersionConflict(ctx, err) {
+			return
+		}
 		if errors.Is(err, service.ErrReplyNotFound) {
 			jsonError(ctx, http.StatusNotFound, "评论不存在")
 			return
@@ -141,9 +243,14 @@ func (h *ReplyHandler) Update(ctx *gin.Context) {
 			jsonError(ctx, http.StatusForbidden, "没有修改权限")
 			return
 		}
+		if errors.Is(err, service.ErrReputationTooLow) {
+			jsonError(ctx, http.StatusForbidden, "声望不足，暂不能发布链接")
+			return
+		}
 		jsonError(ctx, http.StatusInternalServerError, "修改失败")
 		return
 	}
+	setETag(ctx, resp.Version)
 	ctx.JSON(http.StatusOK, resp)
 }
 
@@ -172,3 +279,47 @@ func (h *ReplyHandler) Delete(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
+
+func (h *ReplyHandler) Accept(ctx *gin.Context) {
+	var req dto.AcceptReplyReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	h.accept(ctx, req.ReplyID)
+}
+
+func (h *ReplyHandler) Unaccept(ctx *gin.Context) {
+	h.accept(ctx, 0)
+}
+
+func (h *ReplyHandler) accept(ctx *gin.Context, replyID uint) {
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Accept(actor, threadID, replyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrThreadNotFound):
+			jsonError(ctx, http.StatusNotFound, "帖子不存在")
+		case errors.Is(err, service.ErrReplyNotFound):
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+		case errors.Is(err, service.ErrForbidden):
+			jsonError(ctx, http.StatusForbidden, "无权限")
+		case errors.Is(err, service.ErrNotQuestion):
+			jsonError(ctx, http.StatusBadRequest, "不是问答帖")
+		case errors.Is(err, service.ErrAcceptedReplyChanged):
+			jsonError(ctx, http.StatusConflict, "采纳答案已被修改")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "采纳失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -19,17 +19,22 @@ import (
 
 func newReplyRouter(replyRepo repository.ReplyRepository, threadRepo repository.ThreadRepository, userID uint) *gin.Engine {
 	gin.SetMode(gin.TestMode)
-	svc := service.NewReplyService(replyRepo, threadRepo)
+	svc := service.NewReplyService(replyRepo, threadRepo, nil, nil, nil, nil, nil, nil)
 	h := NewReplyHandler(svc)
 
 	r := gin.New()
 	r.GET("/threads/:id/replies", h.ListByThreadID)
+	r.GET("/replies/:id/children", h.ListChildren)
+	r.GET("/replies/:id/context", h.Context)
+	r.GET("/replies/:id/locate", h.Locate)
 
 	auth := r.Group("/api")
 	auth.Use(testAuthMiddleware(userID))
 	auth.GET("/me/replies", h.ListMine)
 	auth.POST("/threads/:id/replies", h.Create)
 	auth.PUT("/replies/:id", h.Update)
+	auth.PUT("/threads/:id/accepted-reply", h.Accept)
+	auth.DELETE("/threads/:id/accepted-reply", h.Unaccept)
 	auth.DELETE("/replies/:id", h.Delete)
 
 	return r
@@ -312,6 +317,7 @@ func TestReplyUpdateNotFound(t *testing.T) {
 	body := `{"content":"hello"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/replies/1", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -331,6 +337,7 @@ func TestReplyUpdateForbidden(t *testing.T) {
 	body := `{"content":"hello"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/replies/1", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -353,3 +360,128 @@ func TestReplyDeleteNotFound(t *testing.T) {
 		t.Fatalf("expected %d, got %d, body=%s", http.StatusNotFound, w.Code, w.Body.String())
 	}
 }
+
+func TestReplyCreateParentInvalid(t *testing.T) {
+	replyRepo := &fakeReplyRepo{findResult: &models.Reply{ID: 5, ThreadID: 2}}
+	r := newReplyRouter(replyRepo, &fakeThreadRepo{findResult: &models.Thread{ID: 1}}, 1)
+
+	body := `{"content":"hello","parent_id":5}`
+	req := httptest.NewRequest(http.MethodPost, "/api/threads/1/replies", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestReplyChildrenOK(t *testing.T) {
+	replyRepo := &fakeReplyRepo{
+		findResult:  &models.Reply{ID: 5, ThreadID: 1},
+		children:    []models.Reply{{ID: 6, ThreadID: 1, ParentID: 5, Depth: 1, CreatedAt: time.Unix(0, 500)}},
+		childCounts: map[uint]int64{5: 1},
+	}
+	r := newReplyRouter(replyRepo, &fakeThreadRepo{findResult: &models.Thread{ID: 1}}, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/replies/5/children?size=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp dto.ReplyTreeResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if resp.Reply.ID != 5 || resp.Reply.ReplyCount != 1 || len(resp.Children) != 1 || resp.NextCursor != "500_6" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestReplyContextNotFound(t *testing.T) {
+	r := newReplyRouter(&fakeReplyRepo{}, &fakeThreadRepo{findResult: &models.Thread{ID: 1}}, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/replies/5/context", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+}
+
+func TestReplyLocate(t *testing.T) {
+	replyRepo := &fakeReplyRepo{
+		findResult:  &models.Reply{ID: 5, ThreadID: 1, CreatedAt: time.Unix(0, 500)},
+		beforeCount: 3,
+		listResult:  []models.Reply{{ID: 3, ThreadID: 1, CreatedAt: time.Unix(0, 300)}},
+	}
+	r := newReplyRouter(replyRepo, &fakeThreadRepo{findResult: &models.Thread{ID: 1}}, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/replies/5/locate?size=2&order=desc", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp dto.ReplyLocateResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if resp.Page != 2 || resp.Size != 2 || resp.Cursor != "300_3" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestReplyListInvalidOrder(t *testing.T) {
+	r := newReplyRouter(&fakeReplyRepo{}, &fakeThreadRepo{findResult: &models.Thread{ID: 1}}, 0)
+
+	for _, path := range []string{"/threads/1/replies?order=up", "/replies/5/locate?order=up"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected %d, got %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestReplyAccept(t *testing.T) {
+	question := &models.Thread{ID: 1, UserID: 1, Type: models.ThreadTypeQuestion}
+	cases := []struct {
+		name   string
+		thread *models.Thread
+		userID uint
+		method string
+		body   string
+		want   int
+	}{
+		{"ok", question, 1, http.MethodPut, `{"reply_id":5}`, http.StatusOK},
+		{"clear", question, 1, http.MethodDelete, "", http.StatusOK},
+		{"missing_reply_id", question, 1, http.MethodPut, `{}`, http.StatusBadRequest},
+		{"forbidden", question, 2, http.MethodPut, `{"reply_id":5}`, http.StatusForbidden},
+		{"not_question", &models.Thread{ID: 1, UserID: 1}, 1, http.MethodPut, `{"reply_id":5}`, http.StatusBadRequest},
+		{"thread_not_found", nil, 1, http.MethodPut, `{"reply_id":5}`, http.StatusNotFound},
+		{"changed", question, 1, http.MethodPut, `{"reply_id":5}`, http.StatusConflict},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			replyRepo := &fakeReplyRepo{findResult: &models.Reply{ID: 5, ThreadID: 1}}
+			r := newReplyRouter(replyRepo, &fakeThreadRepo{findResult: c.thread, acceptMissed: c.name == "changed"}, c.userID)
+
+			req := httptest.NewRequest(c.method, "/api/threads/1/accepted-reply", strings.NewReader(c.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReplyLikeHandler struct {
+	svc *service.ReplyLikeService
+}
+
+func NewReplyLikeHandler(svc *service.ReplyLikeService) *ReplyLikeHandler {
+	return &ReplyLikeHandler{svc: svc}
+}
+
+func (h *ReplyLikeHandler) Like(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Like(userID, replyID); err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		if errors.Is(err, repository.ErrAlreadyLiked) {
+			jsonError(ctx, http.StatusConflict, "已点赞")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "点赞回复失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "点赞回复成功"})
+}
+
+func (h *ReplyLikeHandler) Unlike(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unlike(userID, replyID); err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		if errors.Is(err, repository.ErrLikeNotFound) {
+			jsonError(ctx, http.StatusConflict, "未点赞")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "取消点赞回复失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "取消点赞回复成功"})
+}
+
+func (h *ReplyLikeHandler) Status(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	liked, err := h.svc.IsLiked(userID, replyID)
+	if err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取点赞状态失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"liked": liked})
+}
+
+func (h *ReplyLikeHandler) React(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.React(userID, replyID, ctx.Param("reaction")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReaction):
+			jsonError(ctx, http.StatusBadRequest, "不支持的回应")
+		case errors.Is(err, service.ErrReplyNotFound):
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+		case errors.Is(err, repository.ErrAlreadyLiked):
+			jsonError(ctx, http.StatusConflict, "已回应")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "回应失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "回应成功"})
+}
+
+func (h *ReplyLikeHandler) Unreact(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unreact(userID, replyID, ctx.Param("reaction")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReaction):
+			jsonError(ctx, http.StatusBadRequest, "不支持的回应")
+		case errors.Is(err, service.ErrReplyNotFound):
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+		case errors.Is(err, repository.ErrLikeNotFound):
+			jsonError(ctx, http.StatusConflict, "未回应")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "取消回应失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "取消回应成功"})
+}
+
+func (h *ReplyLikeHandler) Reactions(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Reactions(userID, replyID)
+	if err != nil {
+		if errors.Is(err, service.ErrReplyNotFound) {
+			jsonError(ctx, http.StatusNotFound, "评论不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取回应失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// 赞成票与反对票互斥，改投时原子地撤掉另一票；重复投同一票不报错
+func (h *ReplyLikeHandler) Vote(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	var req dto.VoteReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	resp, err := h.svc.Vote(userID, replyID, req.Vote)
+	if err != nil {
+		h.voteError(ctx, err, "投票失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReplyLikeHandler) Unvote(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Unvote(userID, replyID)
+	if err != nil {
+		h.voteError(ctx, err, "撤销投票失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReplyLikeHandler) VoteStatus(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.VoteStatus(userID, replyID)
+	if err != nil {
+		h.voteError(ctx, err, "获取投票状态失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReplyLikeHandler) voteError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrDownvoteDisabled):
+		jsonError(ctx, http.StatusBadRequest, "未开启踩")
+	case errors.Is(err, service.ErrReplyNotFound):
+		jsonError(ctx, http.StatusNotFound, "评论不存在")
+	default:
+		jsonError(ctx, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeReplyLikeRepo struct {
+	createErr error
+	exists    bool
+}
+
+func (f *fakeReplyLikeRepo) Create(*models.ReplyLike) error {
+	return f.createErr
+}
+
+func (f *fakeReplyLikeRepo) Delete(uint, uint, string) error {
+	return nil
+}
+
+func (f *fakeReplyLikeRepo) Exists(uint, uint, string) (bool, error) {
+	return f.exists, nil
+}
+
+func (f *fakeReplyLikeRepo) ListReactions(uint, uint) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeReplyLikeRepo) GetLikeCount(uint) (int64, error) {
+	return 0, nil
+}
+
+func newReplyLikeRouter(replyRepo *fakeReplyRepo, likeRepo *fakeReplyLikeRepo, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewReplyLikeService(replyRepo, likeRepo, &fakeThreadRepo{}, nil, nil, nil)
+	h := NewReplyLikeHandler(svc)
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(userID))
+	auth.POST("/replies/:id/like", h.Like)
+	auth.DELETE("/replies/:id/like", h.Unlike)
+	auth.GET("/replies/:id/like", h.Status)
+	auth.PUT("/replies/:id/reactions/:reaction", h.React)
+	auth.DELETE("/replies/:id/reactions/:reaction", h.Unreact)
+	auth.GET("/replies/:id/reactions", h.Reactions)
+	return r
+}
+
+func TestReplyLikeHandler(t *testing.T) {
+	reply := &models.Reply{ID: 2, ThreadID: 1}
+	cases := []struct {
+		name     string
+		method   string
+		reply    *models.Reply
+		likeRepo *fakeReplyLikeRepo
+		want     int
+		body     string
+	}{
+		{"not_found", http.MethodPost, nil, &fakeReplyLikeRepo{}, http.StatusNotFound, ""},
+		{"conflict", http.MethodPost, reply, &fakeReplyLikeRepo{createErr: repository.ErrAlreadyLiked}, http.StatusConflict, ""},
+		{"like", http.MethodPost, reply, &fakeReplyLikeRepo{}, http.StatusOK, ""},
+		{"unlike", http.MethodDelete, reply, &fakeReplyLikeRepo{}, http.StatusOK, ""},
+		{"status", http.MethodGet, reply, &fakeReplyLikeRepo{exists: true}, http.StatusOK, `"liked":true`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newReplyLikeRouter(&fakeReplyRepo{findResult: c.reply}, c.likeRepo, 1)
+
+			req := httptest.NewRequest(c.method, "/api/replies/2/like", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.body != "" && !strings.Contains(w.Body.String(), c.body) {
+				t.Fatalf("expected %s in body, got %s", c.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestReplyReactionHandler(t *testing.T) {
+	reply := &models.Reply{ID: 2, ThreadID: 1}
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		likeRepo *fakeReplyLikeRepo
+		want     int
+		body     string
+	}{
+		{"unknown_reaction", http.MethodPut, "/api/replies/2/reactions/heart", &fakeReplyLikeRepo{}, http.StatusBadRequest, ""},
+		{"conflict", http.MethodPut, "/api/replies/2/reactions/like", &fakeReplyLikeRepo{createErr: repository.ErrAlreadyLiked}, http.StatusConflict, ""},
+		{"react", http.MethodPut, "/api/replies/2/reactions/like", &fakeReplyLikeRepo{}, http.StatusOK, ""},
+		{"unreact", http.MethodDelete, "/api/replies/2/reactions/like", &fakeReplyLikeRepo{}, http.StatusOK, ""},
+		{"list", http.MethodGet, "/api/replies/2/reactions", &fakeReplyLikeRepo{}, http.StatusOK, `"mine":[]`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newReplyLikeRouter(&fakeReplyRepo{findResult: reply}, c.likeRepo, 1)
+
+			req := httptest.NewRequest(c.method, c.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.body != "" && !strings.Contains(w.Body.String(), c.body) {
+				t.Fatalf("expected %s in body, got %s", c.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReputationHandler struct {
+	svc *service.ReputationService
+}
+
+func NewReputationHandler(svc *service.ReputationService) *ReputationHandler {
+	return &ReputationHandler{svc: svc}
+}
+
+func (h *ReputationHandler) Leaderboard(ctx *gin.Context) {
+	_, size := parsePageSize("", ctx.Query("size"))
+	resp, err := h.svc.Leaderboard(ctx.DefaultQuery("window", service.LeaderboardAll), size)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWindow) {
+			jsonError(ctx, http.StatusBadRequest, "window 无效")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取声望排行失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ReputationHandler) Penalize(ctx *gin.Context) {
+	var req dto.PenaltyReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	userID, ok := parseUintParam(ctx, "id", "用户 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Penalize(actor, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			jsonError(ctx, http.StatusForbidden, "无权限")
+		case errors.Is(err, service.ErrUserNotFound):
+			jsonError(ctx, http.StatusNotFound, "用户不存在")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "扣除声望失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeReputationRepo struct {
+	totals map[uint]int64
+	events []models.ReputationEvent
+}
+
+func (f *fakeReputationRepo) Append(e *models.ReputationEvent) error {
+	f.events = append(f.events, *e)
+	return nil
+}
+
+func (f *fakeReputationRepo) Total(userID uint) (int64, error) {
+	return f.totals[userID], nil
+}
+
+func (f *fakeReputationRepo) Leaderboard(time.Time, int) ([]repository.ReputationRank, error) {
+	return []repository.ReputationRank{{UserID: 2, Username: "bob", Reputation: 12}}, nil
+}
+
+func newReputationRouter(repRepo *fakeReputationRepo, users repository.UserRepository, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewReputationService(repRepo, users, config.ReputationConfig{
+		Privileges: map[string]int64{service.PrivilegePostLinks: 10},
+	})
+	h := NewReputationHandler(svc)
+	threadRepo := &fakeThreadRepo{}
+	threadHandler := NewThreadHandler(service.NewThreadService(threadRepo, &fakeThreadLikeRepo{}, threadRepo, nil, nil, nil, nil, nil, svc))
+
+	r := gin.New()
+	r.GET("/reputation/leaderboard", h.Leaderboard)
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(1), func(ctx *gin.Context) {
+		ctx.Set("role", role)
+	})
+	auth.POST("/users/:id/reputation/penalties", h.Penalize)
+	auth.POST("/threads", threadHandler.Create)
+	return r
+}
+
+func TestReputationEndpointsStatus(t *testing.T) {
+	cases := []struct {
+		role   string
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{"", http.MethodGet, "/reputation/leaderboard", "", http.StatusOK},
+		{"", http.MethodGet, "/reputation/leaderboard?window=day", "", http.StatusOK},
+		{"", http.MethodGet, "/reputation/leaderboard?window=month", "", http.StatusBadRequest},
+		{"", http.MethodPost, "/api/users/2/reputation/penalties", `{"points":5}`, http.StatusForbidden},
+		{models.RoleModerator, http.MethodPost, "/api/users/2/reputation/penalties", `{"points":0}`, http.StatusBadRequest},
+		{models.RoleModerator, http.MethodPost, "/api/users/3/reputation/penalties", `{"points":5}`, http.StatusNotFound},
+		{models.RoleModerator, http.MethodPost, "/api/users/2/reputation/penalties", `{"points":5,"note":"spam"}`, http.StatusOK},
+		{"", http.MethodPost, "/api/threads", `{"title":"t","content":"see https://example.com"}`, http.StatusForbidden},
+		{"", http.MethodPost, "/api/threads", `{"title":"t","content":"hello"}`, http.StatusCreated},
+	}
+	for i, c := range cases {
+		users := &fakeUserRepo{}
+		if strings.Contains(c.url, "/users/2/") {
+			users.findByIDResult = &models.User{Username: "bob"}
+		}
+		r := newReputationRouter(&fakeReputationRepo{totals: map[uint]int64{1: 3}}, users, c.role)
+
+		req := httptest.NewRequest(c.method, c.url, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("case %d: expected %d, got %d, body=%s", i, c.code, w.Code, w.Body.String())
+		}
+	}
+}
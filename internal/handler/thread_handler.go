@@ -3,8 +3,10 @@ package handler
 import (
 	"errors"
 	"exchangeapp/internal/dto"
+	"exchangeapp/internal/repository"
 	"exchangeapp/internal/service"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,6 +32,14 @@ func (h *ThreadHandler) Create(ctx *gin.Context) {
 
 	resp, err := h.svc.Create(userID, req)
 	if err != nil {
+		if errors.Is(err, service.ErrReputationTooLow) {
+			jsonError(ctx, http.StatusForbidden, "声望不足，暂不能发布链接")
+			return
+		}
+		if errors.Is(err, repository.ErrUploadNotAttachable) {
+			jsonError(ctx, http.StatusBadRequest, "附件无效")
+			return
+		}
 		jsonError(ctx, http.StatusInternalServerError, "发帖失败")
 		return
 	}
@@ -38,6 +48,31 @@ func (h *ThreadHandler) Create(ctx *gin.Context) {
 }
 
 func (h *ThreadHandler) List(ctx *gin.Context) {
+	if raw, ok := ctx.GetQuery("ids"); ok {
+		h.listByIDs(ctx, raw)
+		return
+	}
+
+	sort := ctx.DefaultQuery("sort", sortLatest)
+	if sort != sortLatest && sort != sortActive && sort != sortScore {
+		jsonError(ctx, http.StatusBadRequest, "sort 无效")
+		return
+	}
+	filter := ctx.Query("filter")
+	if filter != "" && filter != filterUnanswered {
+		jsonError(ctx, http.StatusBadRequest, "filter 无效")
+		return
+	}
+	if sort == sortScore {
+		if filter != "" {
+			jsonError(ctx, http.StatusBadRequest, "按净分排序不支持 filter")
+			return
+		}
+		h.listByScore(ctx)
+		return
+	}
+	byActivity := sort == sortActive
+
 	cursor := ctx.Query("cursor")
 	page, size := parsePageSize(ctx.Query("page"), ctx.Query("size"))
 	if cursor != "" {
@@ -46,14 +81,32 @@ func (h *ThreadHandler) List(ctx *gin.Context) {
 			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
 			return
 		}
-		resp, err := h.svc.ListAfter(cursorTime, cursorID, size)
+		listAfter := h.svc.ListAfter
+		if byActivity {
+			listAfter = h.svc.ListActiveAfter
+		}
+		if filter == filterUnanswered {
+			listAfter = func(cursorTime time.Time, cursorID uint, size int) (*dto.ThreadListResp, error) {
+				return h.svc.ListUnansweredAfter(byActivity, cursorTime, cursorID, size)
+			}
+		}
+		resp, err := listAfter(cursorTime, cursorID, size)
 		if err != nil {
 			jsonError(ctx, http.StatusInternalServerError, "获取帖子失败")
 			return
 		}
 		ctx.JSON(http.StatusOK, resp)
 	} else {
-		resp, err := h.svc.List(page, size)
+		list := h.svc.List
+		if byActivity {
+			list = h.svc.ListActive
+		}
+		if filter == filterUnanswered {
+			list = func(page, size int) (*dto.ThreadListResp, error) {
+				return h.svc.ListUnanswered(byActivity, page, size)
+			}
+		}
+		resp, err := list(page, size)
 		if err != nil {
 			jsonError(ctx, http.StatusInternalServerError, "获取帖子失败")
 			return
@@ -62,6 +115,43 @@ func (h *ThreadHandler) List(ctx *gin.Context) {
 	}
 }
 
+func (h *ThreadHandler) listByScore(ctx *gin.Context) {
+	cursor := ctx.Query("cursor")
+	page, size := parsePageSize(ctx.Query("page"), ctx.Query("size"))
+
+	var resp *dto.ThreadListResp
+	var err error
+	if cursor != "" {
+		score, cursorID, ok := parseScoreCursor(cursor)
+		if !ok {
+			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
+			return
+		}
+		resp, err = h.svc.ListByScoreAfter(score, cursorID, size)
+	} else {
+		resp, err = h.svc.ListByScore(page, size)
+	}
+	if err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "获取帖子失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ThreadHandler) listByIDs(ctx *gin.Context, raw string) {
+	ids, ok := parseIDList(raw)
+	if !ok {
+		jsonError(ctx, http.StatusBadRequest, "ids 无效")
+		return
+	}
+	resp, err := h.svc.ListByIDs(ids)
+	if err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "获取帖子失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
 func (h *ThreadHandler) ListMine(ctx *gin.Context) {
 	userID, ok := getUserID(ctx)
 	if !ok {
@@ -98,7 +188,7 @@ func (h *ThreadHandler) Detail(ctx *gin.Context) {
 		return
 	}
 
-	resp, err := h.svc.GetByID(threadID)
+	resp, err := h.svc.GetByID(optionalUserID(ctx), threadID)
 	if err != nil {
 		if errors.Is(err, service.ErrThreadNotFound) {
 			jsonError(ctx, http.StatusNotFound, "帖子不存在")
@@ -108,6 +198,7 @@ func (h *ThreadHandler) Detail(ctx *gin.Context) {
 		return
 	}
 
+	setETag(ctx, resp.Version)
 	ctx.JSON(http.StatusOK, resp)
 }
 
@@ -127,8 +218,16 @@ func (h *ThreadHandler) Update(ctx *gin.Context) {
 		return
 	}
 
-	resp, err := h.svc.Update(userID, threadID, req)
+	version, ok := parseIfMatch(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Update(userID, threadID, version, req)
 	if err != nil {
+		if writeVersionConflict(ctx, err) {
+			return
+		}
 		if errors.Is(err, service.ErrThreadNotFound) {
 			jsonError(ctx, http.StatusNotFound, "帖子不存在")
 			return
@@ -137,9 +236,14 @@ func (h *ThreadHandler) Update(ctx *gin.Context) {
 			jsonError(ctx, http.StatusForbidden, "没有修改权限")
 			return
 		}
+		if errors.Is(err, service.ErrReputationTooLow) {
+			jsonError(ctx, http.StatusForbidden, "声望不足，暂不能发布链接")
+			return
+		}
 		jsonError(ctx, http.StatusInternalServerError, "修改失败")
 		return
 	}
+	setETag(ctx, resp.Version)
 	ctx.JSON(http.StatusOK, resp)
 }
 
@@ -19,7 +19,7 @@ import (
 
 func newThreadRouter(repo repository.ThreadRepository, userID uint) *gin.Engine {
 	gin.SetMode(gin.TestMode)
-	svc := service.NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+	svc := service.NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 	h := NewThreadHandler(svc)
 
 	r := gin.New()
@@ -105,6 +105,120 @@ func TestThreadListCursorOK(t *testing.T) {
 	}
 }
 
+func TestThreadListSortInvalid(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads?sort=hot", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestThreadListSortActiveCursor(t *testing.T) {
+	created := time.Unix(0, 100)
+	active := time.Unix(0, 900)
+	repo := &fakeThreadRepo{
+		listAfterResult: []models.Thread{
+			{ID: 7, CreatedAt: created, LastActivityAt: active, Title: "t1", UserID: 1},
+		},
+	}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads?sort=active&cursor=1000_9&size=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp dto.ThreadListResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if resp.NextCursor != "900_7" {
+		t.Fatalf("expected next_cursor 900_7, got %s", resp.NextCursor)
+	}
+}
+
+func TestThreadListSortScoreCursor(t *testing.T) {
+	repo := &fakeThreadRepo{
+		listAfterResult: []models.Thread{
+			{ID: 7, Title: "t1", UserID: 1, LikeCount: 1, DownvoteCount: 6, Score: -5},
+		},
+	}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads?sort=score&cursor=-3_9&size=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(repo.scoreCursors) != 1 || repo.scoreCursors[0] != -3 {
+		t.Fatalf("expected score cursor -3, got %v", repo.scoreCursors)
+	}
+
+	var resp dto.ThreadListResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if resp.NextCursor != "-5_7" || len(resp.Items) != 1 || resp.Items[0].Score != -5 || resp.Items[0].DownvoteCount != 6 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/threads?sort=score&filter=unanswered", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestThreadListByIDs(t *testing.T) {
+	repo := &fakeThreadRepo{
+		listResult: []models.Thread{
+			{ID: 1, Title: "t1", UserID: 1},
+			{ID: 2, Title: "t2", UserID: 1},
+		},
+	}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads?ids=2,9,1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp dto.ThreadListResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].ID != 2 || resp.Items[1].ID != 1 {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+}
+
+func TestThreadListByIDsInvalid(t *testing.T) {
+	r := newThreadRouter(&fakeThreadRepo{}, 0)
+
+	for _, q := range []string{"ids=", "ids=1,x", "ids=0", "ids=" + strings.Repeat("1,", maxSize) + "1"} {
+		req := httptest.NewRequest(http.MethodGet, "/threads?"+q, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected %d, got %d", q, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
 func TestThreadDetailNotFound(t *testing.T) {
 	repo := &fakeThreadRepo{findResult: nil}
 	r := newThreadRouter(repo, 0)
@@ -190,6 +304,49 @@ func TestThreadCreateOK(t *testing.T) {
 	}
 }
 
+func TestThreadCreateBadContentFormat(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	r := newThreadRouter(repo, 1)
+
+	body := `{"title":"t","content":"c","content_format":"html"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/threads", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestThreadCreateMarkdown(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	r := newThreadRouter(repo, 1)
+
+	body := `{"title":"t","content":"# h\n<img src=x onerror=alert(1)>","content_format":"markdown"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/threads", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var resp dto.ThreadDetailResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if resp.ContentFormat != "markdown" || !strings.Contains(resp.ContentHTML, "<h1>h</h1>") {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if strings.Contains(resp.ContentHTML, "onerror") {
+		t.Fatalf("expected event handler stripped, got %q", resp.ContentHTML)
+	}
+}
+
 func TestThreadListMineUnauthorized(t *testing.T) {
 	repo := &fakeThreadRepo{}
 	r := newThreadRouter(repo, 0)
@@ -249,6 +406,7 @@ func TestThreadUpdateNotFound(t *testing.T) {
 	body := `{"title":"t","content":"c"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/threads/1", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -267,6 +425,7 @@ func TestThreadUpdateForbidden(t *testing.T) {
 	body := `{"title":"t","content":"c"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/threads/1", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -276,6 +435,62 @@ func TestThreadUpdateForbidden(t *testing.T) {
 	}
 }
 
+func TestThreadUpdatePreconditions(t *testing.T) {
+	cases := []struct {
+		ifMatch   string
+		updateErr error
+		code      int
+		etag      string
+	}{
+		{"", nil, http.StatusPreconditionRequired, ""},
+		{"abc", nil, http.StatusBadRequest, ""},
+		{`"2"`, nil, http.StatusPreconditionFailed, `"3"`},
+		{`W/"3"`, repository.ErrVersionConflict, http.StatusPreconditionFailed, `"3"`},
+		{`"3"`, nil, http.StatusOK, `"3"`},
+	}
+	for i, c := range cases {
+		repo := &fakeThreadRepo{
+			findResult: &models.Thread{ID: 1, UserID: 1, Version: 3},
+			updateErr:  c.updateErr,
+		}
+		r := newThreadRouter(repo, 1)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/threads/1", strings.NewReader(`{"title":"t","content":"c"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if c.ifMatch != "" {
+			req.Header.Set("If-Match", c.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Fatalf("case %d: expected %d, got %d, body=%s", i, c.code, w.Code, w.Body.String())
+		}
+		if c.etag != "" && w.Header().Get("ETag") != c.etag {
+			t.Fatalf("case %d: expected ETag %s, got %s", i, c.etag, w.Header().Get("ETag"))
+		}
+		if c.code == http.StatusPreconditionFailed && !strings.Contains(w.Body.String(), `"version":3`) {
+			t.Fatalf("case %d: expected current version in body, got %s", i, w.Body.String())
+		}
+	}
+}
+
+func TestThreadDetailETag(t *testing.T) {
+	repo := &fakeThreadRepo{findResult: &models.Thread{ID: 1, UserID: 1, Version: 5}}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != `"5"` {
+		t.Fatalf("expected ETag \"5\", got %s", w.Header().Get("ETag"))
+	}
+}
+
 func TestThreadDeleteNotFound(t *testing.T) {
 	repo := &fakeThreadRepo{findResult: nil}
 	r := newThreadRouter(repo, 1)
@@ -303,3 +518,32 @@ func TestThreadDeleteForbidden(t *testing.T) {
 		t.Fatalf("expected %d, got %d, body=%s", http.StatusForbidden, w.Code, w.Body.String())
 	}
 }
+
+func TestThreadListUnanswered(t *testing.T) {
+	repo := &fakeThreadRepo{
+		listResult:  []models.Thread{{ID: 3, Title: "q", UserID: 1, Type: models.ThreadTypeQuestion}},
+		countResult: 1,
+	}
+	r := newThreadRouter(repo, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/threads?filter=unanswered&sort=active", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(repo.unansweredArgs) != 1 || !repo.unansweredArgs[0] {
+		t.Fatalf("expected unanswered listing by activity, got %v", repo.unansweredArgs)
+	}
+	if !strings.Contains(w.Body.String(), `"type":"question"`) {
+		t.Fatalf("expected type in body, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/threads?filter=hot", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"exchangeapp/internal/dto"
 	"exchangeapp/internal/repository"
 	"exchangeapp/internal/service"
 	"net/http"
@@ -91,3 +92,212 @@ func (h *ThreadLikeHandler) Status(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"liked": liked})
 }
+
+func (h *ThreadLikeHandler) React(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.React(userID, threadID, ctx.Param("reaction")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReaction):
+			jsonError(ctx, http.StatusBadRequest, "不支持的回应")
+		case errors.Is(err, service.ErrThreadNotFound):
+			jsonError(ctx, http.StatusNotFound, "帖子不存在")
+		case errors.Is(err, repository.ErrAlreadyLiked):
+			jsonError(ctx, http.StatusConflict, "已回应")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "回应失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "回应成功"})
+}
+
+func (h *ThreadLikeHandler) Unreact(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unreact(userID, threadID, ctx.Param("reaction")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReaction):
+			jsonError(ctx, http.StatusBadRequest, "不支持的回应")
+		case errors.Is(err, service.ErrThreadNotFound):
+			jsonError(ctx, http.StatusNotFound, "帖子不存在")
+		case errors.Is(err, repository.ErrLikeNotFound):
+			jsonError(ctx, http.StatusConflict, "未回应")
+		default:
+			jsonError(ctx, http.StatusInternalServerError, "取消回应失败")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "取消回应成功"})
+}
+
+func (h *ThreadLikeHandler) Reactions(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Reactions(userID, threadID)
+	if err != nil {
+		if errors.Is(err, service.ErrThreadNotFound) {
+			jsonError(ctx, http.StatusNotFound, "帖子不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取回应失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// 不存在的帖子 ID 按未点赞返回
+func (h *ThreadLikeHandler) BatchStatus(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.LikeStatusBatchReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	liked, err := h.svc.BatchStatus(userID, req.ThreadIDs)
+	if err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "获取点赞状态失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"liked": liked})
+}
+
+func (h *ThreadLikeHandler) ListLikers(ctx *gin.Context) {
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	_, size := parsePageSize("", ctx.Query("size"))
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		cursorTime, cursorID, ok := parseCursor(cursor)
+		if !ok {
+			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
+			return
+		}
+		resp, err := h.svc.ListLikersAfter(threadID, cursorTime, cursorID, size)
+		if err != nil {
+			h.likersError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, resp)
+		return
+	}
+
+	resp, err := h.svc.ListLikers(threadID, size)
+	if err != nil {
+		h.likersError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ThreadLikeHandler) likersError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrThreadNotFound) {
+		jsonError(ctx, http.StatusNotFound, "帖子不存在")
+		return
+	}
+	jsonError(ctx, http.StatusInternalServerError, "获取点赞用户失败")
+}
+
+// 赞成票与反对票互斥，改投时原子地撤掉另一票；重复投同一票不报错
+func (h *ThreadLikeHandler) Vote(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	var req dto.VoteReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	resp, err := h.svc.Vote(userID, threadID, req.Vote)
+	if err != nil {
+		h.voteError(ctx, err, "投票失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ThreadLikeHandler) Unvote(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Unvote(userID, threadID)
+	if err != nil {
+		h.voteError(ctx, err, "撤销投票失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ThreadLikeHandler) VoteStatus(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.VoteStatus(userID, threadID)
+	if err != nil {
+		h.voteError(ctx, err, "获取投票状态失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ThreadLikeHandler) voteError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrDownvoteDisabled):
+		jsonError(ctx, http.StatusBadRequest, "未开启踩")
+	case errors.Is(err, service.ErrThreadNotFound):
+		jsonError(ctx, http.StatusNotFound, "帖子不存在")
+	default:
+		jsonError(ctx, http.StatusInternalServerError, msg)
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"exchangeapp/internal/service"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func newThreadLikeRouter(threadRepo repository.ThreadRepository, likeRepo repository.ThreadLikeRepository, userID uint) *gin.Engine {
 	gin.SetMode(gin.TestMode)
-	svc := service.NewThreadLikeService(threadRepo, likeRepo, threadRepo)
+	svc := service.NewThreadLikeService(threadRepo, likeRepo, threadRepo, nil, nil, nil, nil, nil)
 	h := NewThreadLikeHandler(svc)
 
 	r := gin.New()
@@ -23,6 +26,8 @@ func newThreadLikeRouter(threadRepo repository.ThreadRepository, likeRepo reposi
 	auth.POST("/threads/:id/like", h.Like)
 	auth.DELETE("/threads/:id/like", h.Unlike)
 	auth.GET("/threads/:id/like", h.Status)
+	auth.POST("/likes/status", h.BatchStatus)
+	r.GET("/threads/:id/likes", h.ListLikers)
 
 	return r
 }
@@ -157,3 +162,89 @@ func TestThreadLikeStatusOK(t *testing.T) {
 		t.Fatalf("expected liked true, got false")
 	}
 }
+
+func TestThreadLikersList(t *testing.T) {
+	likeRepo := &fakeThreadLikeRepo{likers: []repository.ThreadLiker{
+		{ID: 4, UserID: 2, Username: "bob", CreatedAt: time.Unix(0, 100)},
+	}}
+	cases := []struct {
+		name   string
+		thread *models.Thread
+		query  string
+		want   int
+	}{
+		{"not_found", nil, "", http.StatusNotFound},
+		{"invalid_cursor", &models.Thread{ID: 1}, "?cursor=bad", http.StatusBadRequest},
+		{"first_page", &models.Thread{ID: 1}, "", http.StatusOK},
+		{"cursor", &models.Thread{ID: 1}, "?cursor=200_7", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newThreadLikeRouter(&fakeThreadRepo{findResult: c.thread}, likeRepo, 0)
+
+			req := httptest.NewRequest(http.MethodGet, "/threads/1/likes"+c.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.want != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Items []struct {
+					UserID   uint   `json:"user_id"`
+					Username string `json:"username"`
+				} `json:"items"`
+				NextCursor string `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Items) != 1 || resp.Items[0].Username != "bob" || resp.NextCursor != "100_4" {
+				t.Fatalf("unexpected body: %s", w.Body.String())
+			}
+		})
+	}
+	if len(likeRepo.likersAfter) != 1 || likeRepo.likersAfter[0] != 7 {
+		t.Fatalf("expected cursor id 7 passed, got %v", likeRepo.likersAfter)
+	}
+}
+
+func TestThreadLikeBatchStatus(t *testing.T) {
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = strconv.Itoa(i + 1)
+	}
+	cases := []struct {
+		name string
+		body string
+		want int
+		resp string
+	}{
+		{"empty", `{"thread_ids":[]}`, http.StatusBadRequest, ""},
+		{"zero_id", `{"thread_ids":[0]}`, http.StatusBadRequest, ""},
+		{"too_many", `{"thread_ids":[` + strings.Join(tooMany, ",") + `]}`, http.StatusBadRequest, ""},
+		{"ok", `{"thread_ids":[1,2]}`, http.StatusOK, `{"liked":{"1":false,"2":true}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newThreadLikeRouter(&fakeThreadRepo{}, &fakeThreadLikeRepo{likedIDs: []uint{2}}, 1)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/likes/status", strings.NewReader(c.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.resp != "" && w.Body.String() != c.resp {
+				t.Fatalf("expected %s, got %s", c.resp, w.Body.String())
+			}
+		})
+	}
+}
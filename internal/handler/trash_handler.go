@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrashHandler struct {
+	svc *service.TrashService
+}
+
+func NewTrashHandler(svc *service.TrashService) *TrashHandler {
+	return &TrashHandler{svc: svc}
+}
+
+func (h *TrashHandler) List(ctx *gin.Context) {
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	kind := ctx.DefaultQuery("type", service.TrashTypeThreads)
+	if kind != service.TrashTypeThreads && kind != service.TrashTypeReplies {
+		jsonError(ctx, http.StatusBadRequest, "type 无效")
+		return
+	}
+	all := ctx.Query("all") == "true"
+	_, size := parsePageSize("", ctx.Query("size"))
+
+	var cursorTime time.Time
+	var cursorID uint
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		if cursorTime, cursorID, ok = parseCursor(cursor); !ok {
+			jsonError(ctx, http.StatusBadRequest, "cursor 无效")
+			return
+		}
+	}
+
+	resp, err := h.svc.List(actor, kind, all, cursorTime, cursorID, size)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			jsonError(ctx, http.StatusForbidden, "没有查看权限")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取回收站失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *TrashHandler) RestoreThread(ctx *gin.Context) {
+	threadID, ok := parseUintParam(ctx, "id", "帖子 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RestoreThread(actor, threadID); err != nil {
+		writeRestoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "恢复成功"})
+}
+
+func (h *TrashHandler) RestoreReply(ctx *gin.Context) {
+	replyID, ok := parseUintParam(ctx, "id", "评论 ID 无效")
+	if !ok {
+		return
+	}
+
+	actor, ok := getActor(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RestoreReply(actor, replyID); err != nil {
+		writeRestoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "恢复成功"})
+}
+
+func writeRestoreError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrThreadNotFound):
+		jsonError(ctx, http.StatusNotFound, "帖子不存在")
+	case errors.Is(err, service.ErrReplyNotFound):
+		jsonError(ctx, http.StatusNotFound, "评论不存在")
+	case errors.Is(err, service.ErrForbidden):
+		jsonError(ctx, http.StatusForbidden, "没有恢复权限")
+	case errors.Is(err, service.ErrNotInTrash):
+		jsonError(ctx, http.StatusConflict, "内容不在回收站")
+	case errors.Is(err, service.ErrParentThreadDeleted):
+		jsonError(ctx, http.StatusConflict, "所属帖子已删除")
+	default:
+		jsonError(ctx, http.StatusInternalServerError, "恢复失败")
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type fakeTrashRepo struct {
+	threads []models.Thread
+	thread  *models.Thread
+	reply   *models.Reply
+}
+
+func (f *fakeTrashRepo) ListDeletedThreads(uint, time.Time, uint, int) ([]models.Thread, error) {
+	return f.threads, nil
+}
+
+func (f *fakeTrashRepo) ListDeletedReplies(uint, time.Time, uint, int) ([]models.Reply, error) {
+	return nil, nil
+}
+
+func (f *fakeTrashRepo) FindThread(uint) (*models.Thread, error) {
+	return f.thread, nil
+}
+
+func (f *fakeTrashRepo) FindReply(uint) (*models.Reply, error) {
+	return f.reply, nil
+}
+
+func (f *fakeTrashRepo) RestoreThread(uint) error {
+	return nil
+}
+
+func (f *fakeTrashRepo) RestoreReply(uint) error {
+	return nil
+}
+
+func (f *fakeTrashRepo) PurgeThreadsBefore(time.Time, int) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeTrashRepo) PurgeRepliesBefore(time.Time, int) (int, error) {
+	return 0, nil
+}
+
+func newTrashRouter(trash *fakeTrashRepo, threadRepo *fakeThreadRepo, userID uint, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewTrashService(trash, threadRepo, &fakeReplyRepo{}, nil, nil, time.Hour)
+	h := NewTrashHandler(svc)
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(userID), func(ctx *gin.Context) {
+		ctx.Set("role", role)
+	})
+	auth.GET("/me/trash", h.List)
+	auth.POST("/threads/:id/restore", h.RestoreThread)
+	auth.POST("/replies/:id/restore", h.RestoreReply)
+	return r
+}
+
+func TestTrashListOK(t *testing.T) {
+	trash := &fakeTrashRepo{threads: []models.Thread{
+		{ID: 1, UserID: 2, Title: "t", DeletedAt: gorm.DeletedAt{Time: time.Unix(10, 0), Valid: true}},
+	}}
+	r := newTrashRouter(trash, &fakeThreadRepo{}, 2, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/trash?type=threads", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp dto.TrashListResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Type != "thread" {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+}
+
+func TestTrashListBadParams(t *testing.T) {
+	r := newTrashRouter(&fakeTrashRepo{}, &fakeThreadRepo{}, 2, "")
+
+	cases := map[string]int{
+		"/api/me/trash?type=users":   http.StatusBadRequest,
+		"/api/me/trash?cursor=abc":   http.StatusBadRequest,
+		"/api/me/trash?all=true":     http.StatusForbidden,
+		"/api/me/trash?type=replies": http.StatusOK,
+	}
+	for url, code := range cases {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Fatalf("%s: expected %d, got %d, body=%s", url, code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTrashRestoreThreadStatus(t *testing.T) {
+	deleted := &models.Thread{ID: 1, UserID: 2, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	live := &models.Thread{ID: 1, UserID: 2}
+
+	cases := []struct {
+		thread *models.Thread
+		userID uint
+		role   string
+		code   int
+	}{
+		{nil, 2, "", http.StatusNotFound},
+		{deleted, 3, "", http.StatusForbidden},
+		{live, 2, "", http.StatusConflict},
+		{deleted, 2, "", http.StatusOK},
+		{deleted, 3, models.RoleModerator, http.StatusOK},
+	}
+	for i, c := range cases {
+		r := newTrashRouter(&fakeTrashRepo{thread: c.thread}, &fakeThreadRepo{}, c.userID, c.role)
+		req := httptest.NewRequest(http.MethodPost, "/api/threads/1/restore", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("case %d: expected %d, got %d, body=%s", i, c.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTrashRestoreReplyParentDeleted(t *testing.T) {
+	trash := &fakeTrashRepo{reply: &models.Reply{ID: 4, ThreadID: 1, UserID: 2, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}}
+	r := newTrashRouter(trash, &fakeThreadRepo{}, 2, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/replies/4/restore", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusConflict, w.Code, w.Body.String())
+	}
+}
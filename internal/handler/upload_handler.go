@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"exchangeapp/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	svc *service.UploadService
+}
+
+func NewUploadHandler(svc *service.UploadService) *UploadHandler {
+	return &UploadHandler{svc: svc}
+}
+
+func (h *UploadHandler) Create(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.svc.MaxSize()+1<<20)
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			jsonError(ctx, http.StatusRequestEntityTooLarge, "文件过大")
+			return
+		}
+		jsonError(ctx, http.StatusBadRequest, "缺少文件")
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		jsonError(ctx, http.StatusBadRequest, "读取文件失败")
+		return
+	}
+	defer f.Close()
+
+	resp, err := h.svc.Create(userID, fh.Filename, fh.Size, f)
+	if err != nil {
+		if errors.Is(err, service.ErrUploadTooLarge) {
+			jsonError(ctx, http.StatusRequestEntityTooLarge, "文件过大")
+			return
+		}
+		if errors.Is(err, service.ErrUploadTypeNotAllowed) {
+			jsonError(ctx, http.StatusUnsupportedMediaType, "不支持的文件类型")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "上传失败")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
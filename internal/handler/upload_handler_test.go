@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/service"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newUploadRouter(repo *fakeUploadRepo, store *fakeBlobStore, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewUploadService(repo, store, config.UploadConfig{MaxSizeMB: 1})
+	h := NewUploadHandler(svc)
+
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(userID))
+	auth.POST("/uploads", h.Create)
+	return r
+}
+
+func multipartBody(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if field != "" {
+		fw, err := mw.CreateFormFile(field, filename)
+		if err != nil {
+			t.Fatalf("create form file failed: %v", err)
+		}
+		fw.Write(content)
+	}
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestUploadCreateUnauthorized(t *testing.T) {
+	r := newUploadRouter(&fakeUploadRepo{}, &fakeBlobStore{}, 0)
+
+	body, ct := multipartBody(t, "file", "a.png", []byte("\x89PNG\r\n\x1a\n"))
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", body)
+	req.Header.Set("Content-Type", ct)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestUploadCreateMissingFile(t *testing.T) {
+	r := newUploadRouter(&fakeUploadRepo{}, &fakeBlobStore{}, 1)
+
+	body, ct := multipartBody(t, "", "", nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", body)
+	req.Header.Set("Content-Type", ct)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestUploadCreateTooLarge(t *testing.T) {
+	r := newUploadRouter(&fakeUploadRepo{}, &fakeBlobStore{}, 1)
+
+	body, ct := multipartBody(t, "file", "a.png", bytes.Repeat([]byte{0}, 3<<20))
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", body)
+	req.Header.Set("Content-Type", ct)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+}
+
+func TestUploadCreateUnsupportedType(t *testing.T) {
+	store := &fakeBlobStore{}
+	r := newUploadRouter(&fakeUploadRepo{}, store, 1)
+
+	body, ct := multipartBody(t, "file", "a.png", []byte("<html><body>x</body></html>"))
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", body)
+	req.Header.Set("Content-Type", ct)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+	}
+	if len(store.keys) != 0 {
+		t.Fatalf("expected nothing stored, got %v", store.keys)
+	}
+}
+
+func TestUploadCreateOK(t *testing.T) {
+	repo := &fakeUploadRepo{}
+	store := &fakeBlobStore{}
+	r := newUploadRouter(repo, store, 1)
+
+	body, ct := multipartBody(t, "file", "a.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", body)
+	req.Header.Set("Content-Type", ct)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var resp dto.UploadResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if resp.ID != 1 || resp.MimeType != "image/png" || resp.URL != "/uploads/"+repo.created.Key {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
@@ -65,3 +65,58 @@ func (h *UserHandler) Me(ctx *gin.Context) {
 		"username": username,
 	})
 }
+
+func (h *UserHandler) Privacy(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Privacy(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			jsonError(ctx, http.StatusNotFound, "用户不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取隐私设置失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) UpdatePrivacy(ctx *gin.Context) {
+	userID, ok := getUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.PrivacyReq
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	resp, err := h.svc.UpdatePrivacy(userID, req)
+	if err != nil {
+		jsonError(ctx, http.StatusInternalServerError, "更新隐私设置失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) Profile(ctx *gin.Context) {
+	userID, ok := parseUintParam(ctx, "id", "用户 ID 无效")
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.Profile(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			jsonError(ctx, http.StatusNotFound, "用户不存在")
+			return
+		}
+		jsonError(ctx, http.StatusInternalServerError, "获取用户资料失败")
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
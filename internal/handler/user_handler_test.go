@@ -22,7 +22,10 @@ type fakeUserRepo struct {
 	createErr            error
 	findByUsernameResult *models.User
 	findByUsernameErr    error
+	findByIDResult       *models.User
 	nextID               uint
+
+	hideLikes []bool
 }
 
 func (f *fakeUserRepo) Create(u *models.User) error {
@@ -40,6 +43,19 @@ func (f *fakeUserRepo) FindByUsername(username string) (*models.User, error) {
 	return f.findByUsernameResult, f.findByUsernameErr
 }
 
+func (f *fakeUserRepo) FindByID(uint) (*models.User, error) {
+	return f.findByIDResult, nil
+}
+
+func (f *fakeUserRepo) UpdateHideLikes(_ uint, hide bool) error {
+	f.hideLikes = append(f.hideLikes, hide)
+	return nil
+}
+
+func (f *fakeUserRepo) FindByUsernames([]string) ([]models.User, error) {
+	return nil, nil
+}
+
 func newUserRouter(repo repository.UserRepository) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	svc := service.NewUserService(repo, config.JWTConfig{
@@ -51,6 +67,7 @@ func newUserRouter(repo repository.UserRepository) *gin.Engine {
 	r := gin.New()
 	r.POST("/register", h.Register)
 	r.POST("/login", h.Login)
+	r.GET("/users/:id", h.Profile)
 	return r
 }
 
@@ -186,3 +203,72 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestPrivacy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &fakeUserRepo{}
+	h := NewUserHandler(service.NewUserService(repo, config.JWTConfig{Secret: "test"}))
+	r := gin.New()
+	auth := r.Group("/api")
+	auth.Use(testAuthMiddleware(1))
+	auth.GET("/me/privacy", h.Privacy)
+	auth.PUT("/me/privacy", h.UpdatePrivacy)
+
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+		resp   string
+	}{
+		{"get_missing_user", http.MethodGet, "", http.StatusNotFound, ""},
+		{"put_missing_field", http.MethodPut, `{}`, http.StatusBadRequest, ""},
+		{"put_hide", http.MethodPut, `{"hide_likes":true}`, http.StatusOK, `"hide_likes":true`},
+		{"put_show", http.MethodPut, `{"hide_likes":false}`, http.StatusOK, `"hide_likes":false`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, "/api/me/privacy", strings.NewReader(c.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d, body=%s", c.want, w.Code, w.Body.String())
+			}
+			if c.resp != "" && !strings.Contains(w.Body.String(), c.resp) {
+				t.Fatalf("expected %s in body, got %s", c.resp, w.Body.String())
+			}
+		})
+	}
+	if len(repo.hideLikes) != 2 || !repo.hideLikes[0] || repo.hideLikes[1] {
+		t.Fatalf("unexpected updates: %v", repo.hideLikes)
+	}
+
+	repo.findByIDResult = &models.User{HideLikes: true}
+	req := httptest.NewRequest(http.MethodGet, "/api/me/privacy", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"hide_likes":true`) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProfile(t *testing.T) {
+	r := newUserRouter(&fakeUserRepo{})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	r = newUserRouter(&fakeUserRepo{findByIDResult: &models.User{Model: gorm.Model{ID: 2}, Username: "bob", Reputation: 42}})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"reputation":42`) || !strings.Contains(body, `"username":"bob"`) {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
@@ -8,6 +8,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// 带合法 token 时写入用户信息，否则按匿名用户继续处理
+func OptionalAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") {
+			tokenStr := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+			if claims, err := jwt.ParseToken(tokenStr, secret); err == nil {
+				c.Set("userID", claims.UserID)
+				c.Set("username", claims.Username)
+				c.Set("role", claims.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
 func Auth(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
@@ -25,6 +41,7 @@ func Auth(secret string) gin.HandlerFunc {
 		}
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
@@ -76,3 +76,39 @@ func TestAuthValidToken(t *testing.T) {
 		t.Fatalf("unexpected response: %+v", resp)
 	}
 }
+
+func TestOptionalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/threads/1", OptionalAuth("secret"), func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+		c.JSON(http.StatusOK, gin.H{"id": userID})
+	})
+	tokenStr, err := jwt.GenerateToken(7, "alice", "secret", 60)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+
+	cases := map[string]uint{"": 0, "Bearer bad": 0, "Bearer " + tokenStr: 7}
+	for header, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/threads/1", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d, body=%s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+		if resp.ID != want {
+			t.Fatalf("header %q: expected user %d, got %d", header, want, resp.ID)
+		}
+	}
+}
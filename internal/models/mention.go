@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	MentionSourceThread = "thread"
+	MentionSourceReply  = "reply"
+
+	MentionKindUser  = "user"
+	MentionKindQuote = "quote"
+)
+
+// 内容中解析出的 @用户 与 >>回复 引用；kind=user 时 target_id 为用户 ID，kind=quote 时为回复 ID
+type Mention struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"index:idx_mentions_kind_target_created,priority:3,sort:desc"`
+	SourceType string    `gorm:"size:16;uniqueIndex:uidx_mention_source_target,priority:1"`
+	SourceID   uint      `gorm:"uniqueIndex:uidx_mention_source_target,priority:2"`
+	Kind       string    `gorm:"size:16;uniqueIndex:uidx_mention_source_target,priority:3;index:idx_mentions_kind_target_created,priority:1"`
+	TargetID   uint      `gorm:"uniqueIndex:uidx_mention_source_target,priority:4;index:idx_mentions_kind_target_created,priority:2"`
+	AuthorID   uint
+	Username   string `gorm:"size:32"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// 表情回应沿用 thread_likes / reply_likes 表，reaction 列区分类型，like 即原来的点赞
+const ReactionLike = "like"
+
+// 踩与 like 同存于点赞表，同一用户对同一目标最多持有其中一个；不属于可配置的表情回应
+const ReactionDownvote = "downvote"
+
+// like 以外的回应计数，由后台任务从 Redis 回写
+type ReactionCount struct {
+	ID         uint   `gorm:"primaryKey"`
+	TargetType string `gorm:"size:16;uniqueIndex:uidx_reaction_count_target"`
+	TargetID   uint   `gorm:"uniqueIndex:uidx_reaction_count_target"`
+	Reaction   string `gorm:"size:32;uniqueIndex:uidx_reaction_count_target"`
+	Count      int64
+}
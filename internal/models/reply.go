@@ -7,12 +7,20 @@ import (
 )
 
 type Reply struct {
-	ID        uint      `gorm:"primaryKey;index:idx_replies_thread_created_id,priority:3,sort:desc;index:idx_replies_user_created_id,priority:3,sort:desc"`
-	CreatedAt time.Time `gorm:"index:idx_replies_thread_created_id,priority:2,sort:desc;index:idx_replies_user_created_id,priority:2,sort:desc"`
+	ID        uint      `gorm:"primaryKey;index:idx_replies_thread_created_id,priority:3,sort:desc;index:idx_replies_user_created_id,priority:3,sort:desc;index:idx_replies_parent_created_id,priority:3"`
+	CreatedAt time.Time `gorm:"index:idx_replies_thread_created_id,priority:2,sort:desc;index:idx_replies_user_created_id,priority:2,sort:desc;index:idx_replies_parent_created_id,priority:2"`
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
-	ThreadID uint `gorm:"index:idx_replies_thread_created_id,priority:1"`
-	Content  string
-	UserID   uint `gorm:"index:idx_replies_user_created_id,priority:1"`
+	ThreadID      uint `gorm:"index:idx_replies_thread_created_id,priority:1"`
+	ParentID      uint `gorm:"not null;default:0;index:idx_replies_parent_created_id,priority:1"`
+	Depth         uint `gorm:"not null;default:0"`
+	Content       string
+	ContentFormat string `gorm:"size:16;default:plain"`
+	ContentHTML   string
+	Version       uint  `gorm:"not null;default:1"`
+	LikeCount     int64 `gorm:"default:0"`
+	DownvoteCount int64 `gorm:"not null;default:0"`
+	Score         int64 `gorm:"not null;default:0"`
+	UserID        uint  `gorm:"index:idx_replies_user_created_id,priority:1"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type ReplyLike struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"uniqueIndex:uidx_user_reply_reaction"`
+	ReplyID   uint   `gorm:"uniqueIndex:uidx_user_reply_reaction;index:idx_reply_likes_reply"`
+	Reaction  string `gorm:"size:32;not null;default:like;uniqueIndex:uidx_user_reply_reaction"`
+}
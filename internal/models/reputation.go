@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ReputationThreadLiked      = "thread_liked"
+	ReputationThreadUnliked    = "thread_unliked"
+	ReputationAnswerAccepted   = "answer_accepted"
+	ReputationAnswerUnaccepted = "answer_unaccepted"
+	ReputationPenalty          = "penalty"
+
+	ReputationSourceThread = "thread"
+	ReputationSourceReply  = "reply"
+)
+
+// 声望流水，只追加不修改：取消点赞、取消采纳时追加一条反向记录；users.reputation 为累计值
+type ReputationEvent struct {
+	ID         uint      `gorm:"primaryKey;index:idx_reputation_events_user,priority:2"`
+	CreatedAt  time.Time `gorm:"index:idx_reputation_events_created,priority:1"`
+	UserID     uint      `gorm:"not null;index:idx_reputation_events_user,priority:1;index:idx_reputation_events_created,priority:2"`
+	Delta      int64     `gorm:"not null;index:idx_reputation_events_created,priority:3"`
+	Reason     string    `gorm:"size:32;not null"`
+	SourceType string    `gorm:"size:16"`
+	SourceID   uint
+	// 触发变动的用户：点赞者、采纳者或处罚的版主
+	ActorID uint
+	Note    string `gorm:"size:200"`
+}
@@ -6,14 +6,33 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	ThreadTypeDiscussion = "discussion"
+	ThreadTypeQuestion   = "question"
+)
+
 type Thread struct {
-	ID        uint      `gorm:"primaryKey;index:idx_threads_created_id,priority:2,sort:desc;index:idx_threads_user_created_id,priority:3,sort:desc"`
+	ID        uint      `gorm:"primaryKey;index:idx_threads_created_id,priority:2,sort:desc;index:idx_threads_user_created_id,priority:3,sort:desc;index:idx_threads_activity_id,priority:2,sort:desc;index:idx_threads_score_id,priority:2,sort:desc"`
 	CreatedAt time.Time `gorm:"index:idx_threads_created_id,priority:1,sort:desc;index:idx_threads_user_created_id,priority:2,sort:desc"`
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
-	Title     string
-	Content   string
-	UserID    uint  `gorm:"index:idx_threads_user_created_id,priority:1"`
-	LikeCount int64 `gorm:"default:0"`
+	Title         string
+	Content       string
+	ContentFormat string `gorm:"size:16;default:plain"`
+	ContentHTML   string
+	Version       uint  `gorm:"not null;default:1"`
+	UserID        uint  `gorm:"index:idx_threads_user_created_id,priority:1"`
+	LikeCount     int64 `gorm:"default:0"`
+	// 净分 = like_count - downvote_count，随两个计数的回写一起更新
+	DownvoteCount int64 `gorm:"not null;default:0"`
+	Score         int64 `gorm:"not null;default:0;index:idx_threads_score_id,priority:1,sort:desc"`
+
+	Type            string `gorm:"size:16;default:discussion;index:idx_threads_type_accepted,priority:1"`
+	AcceptedReplyID uint   `gorm:"default:0;index:idx_threads_type_accepted,priority:2"`
+
+	ReplyCount      int64 `gorm:"default:0"`
+	LastReplyAt     *time.Time
+	LastReplyUserID uint      `gorm:"default:0"`
+	LastActivityAt  time.Time `gorm:"index:idx_threads_activity_id,priority:1,sort:desc"`
 }
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type ThreadBookmark struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index:idx_bookmarks_user_created_thread,priority:2,sort:desc"`
+	UserID    uint      `gorm:"uniqueIndex:uidx_bookmark_user_thread;index:idx_bookmarks_user_created_thread,priority:1"`
+	ThreadID  uint      `gorm:"uniqueIndex:uidx_bookmark_user_thread;index:idx_bookmarks_user_created_thread,priority:3,sort:desc;index:idx_bookmarks_thread"`
+}
@@ -1,9 +1,18 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// 展开 gorm.Model 以便 created_at、id 进入点赞列表的联合索引
 type ThreadLike struct {
-	gorm.Model
-	UserID   uint `gorm:"uniqueIndex:uidx_user_thread"`
-	ThreadID uint `gorm:"uniqueIndex:uidx_user_thread;index:idx_thread"`
+	ID        uint      `gorm:"primarykey;index:idx_thread_like_created,priority:3"`
+	CreatedAt time.Time `gorm:"index:idx_thread_like_created,priority:2"`
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	UserID    uint           `gorm:"uniqueIndex:uidx_user_thread_reaction"`
+	ThreadID  uint           `gorm:"uniqueIndex:uidx_user_thread_reaction;index:idx_thread_like_created,priority:1"`
+	Reaction  string         `gorm:"size:32;not null;default:like;uniqueIndex:uidx_user_thread_reaction"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+type Upload struct {
+	gorm.Model
+	UserID   uint   `gorm:"index"`
+	Key      string `gorm:"size:255;uniqueIndex"`
+	URL      string `gorm:"size:512"`
+	Filename string `gorm:"size:255"`
+	MimeType string `gorm:"size:127"`
+	Size     int64
+	ThreadID uint `gorm:"index"`
+	ReplyID  uint `gorm:"index"`
+}
@@ -2,8 +2,18 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+)
+
 type User struct {
 	gorm.Model
 	Username string `gorm:"unique"`
 	Password string
+	Role     string `gorm:"size:16;default:user"`
+	// 不出现在帖子的点赞用户列表中
+	HideLikes bool `gorm:"not null;default:false"`
+	// 声望累计值，随 reputation_events 同一事务更新
+	Reputation int64 `gorm:"not null;default:0;index"`
 }
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkedThread struct {
+	models.Thread
+	BookmarkedAt time.Time
+}
+
+type BookmarkRepository interface {
+	Create(userID, threadID uint) error
+	Delete(userID, threadID uint) error
+	Exists(userID, threadID uint) (bool, error)
+	ListByUserID(userID uint, limit int) ([]BookmarkedThread, error)
+	ListByUserIDAfter(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]BookmarkedThread, error)
+}
+
+type BookmarkRepo struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &BookmarkRepo{db: db}
+}
+
+// 重复收藏直接忽略，保持 PUT 幂等
+func (r *BookmarkRepo) Create(userID, threadID uint) error {
+	b := &models.ThreadBookmark{UserID: userID, ThreadID: threadID}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(b).Error; err != nil {
+		return fmt.Errorf("收藏帖子失败：%w", err)
+	}
+	return nil
+}
+
+func (r *BookmarkRepo) Delete(userID, threadID uint) error {
+	if err := r.db.Where("user_id = ? and thread_id = ?", userID, threadID).
+		Delete(&models.ThreadBookmark{}).Error; err != nil {
+		return fmt.Errorf("取消收藏失败：%w", err)
+	}
+	return nil
+}
+
+func (r *BookmarkRepo) Exists(userID, threadID uint) (bool, error) {
+	var cnt int64
+	if err := r.db.Model(&models.ThreadBookmark{}).
+		Where("user_id = ? and thread_id = ?", userID, threadID).
+		Count(&cnt).Error; err != nil {
+		return false, fmt.Errorf("查询收藏失败：%w", err)
+	}
+	return cnt > 0, nil
+}
+
+// 已删除的帖子不出现在收藏列表中，恢复后自动重新出现
+func (r *BookmarkRepo) listScope(userID uint, limit int) *gorm.DB {
+	return r.db.Table("thread_bookmarks b").
+		Select("t.*, b.created_at AS bookmarked_at").
+		Joins("JOIN threads t ON t.id = b.thread_id AND t.deleted_at IS NULL").
+		Where("b.user_id = ?", userID).
+		Order("b.created_at desc, b.thread_id desc").
+		Limit(limit)
+}
+
+func (r *BookmarkRepo) ListByUserID(userID uint, limit int) ([]BookmarkedThread, error) {
+	var rows []BookmarkedThread
+	if err := r.listScope(userID, limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询收藏失败：%w", err)
+	}
+	return rows, nil
+}
+
+func (r *BookmarkRepo) ListByUserIDAfter(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]BookmarkedThread, error) {
+	var rows []BookmarkedThread
+	if err := r.listScope(userID, limit).
+		Where("(b.created_at, b.thread_id) < (?, ?)", cursorTime, cursorID).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询收藏失败：%w", err)
+	}
+	return rows, nil
+}
@@ -8,65 +8,96 @@ import (
 	"gorm.io/gorm"
 )
 
-type threadLikeCache interface {
-	ThreadLikeCounter
-	setLikeCount(threadID uint, value int64) error
-	TryLockLikeCount(threadID uint, token string, ttl time.Duration) (bool, error)
-	UnlockLikeCount(threadID uint, token string) error
-	MarkDirty(threadID uint) error
+type likeCache interface {
+	LikeCounter
+	LikeBatchCounter
+	incrementIfExists(id uint, delta int) (bool, error)
+	setLikeCount(id uint, value int64) error
+	TryLockLikeCount(id uint, token string, ttl time.Duration) (bool, error)
+	UnlockLikeCount(id uint, token string) error
+	MarkDirty(id uint) error
+	PurgeLikeCount(id uint) error
 }
 
-type CachedThreadLikeCounter struct {
-	db    ThreadRepository
-	cache threadLikeCache
+// 通用的点赞计数：Redis 计数 + dirty 标记，未命中时从 db 回源
+type CachedLikeCounter struct {
+	db    LikeCountSource
+	cache likeCache
 	sf    *singleflight.Group
 }
 
-func NewCachedThreadLikeCounter(db ThreadRepository, cache *RedisLikeCounter) *CachedThreadLikeCounter {
-	return &CachedThreadLikeCounter{
+func NewCachedLikeCounter(db LikeCountSource, cache *RedisLikeCounter) *CachedLikeCounter {
+	return &CachedLikeCounter{
 		db:    db,
 		cache: cache,
 		sf:    &singleflight.Group{},
 	}
 }
 
-func (c *CachedThreadLikeCounter) IncrementLikeCount(threadID uint, delta int) error {
-	if err := c.cache.IncrementLikeCount(threadID, delta); err != nil {
+// 踩数计数，未命中时从 downvote_count 回源
+func NewCachedDownvoteCounter(db DownvoteCountStore, cache *RedisLikeCounter) *CachedLikeCounter {
+	return &CachedLikeCounter{
+		db:    downvoteSource{db},
+		cache: cache,
+		sf:    &singleflight.Group{},
+	}
+}
+
+type downvoteSource struct {
+	store DownvoteCountStore
+}
+
+func (s downvoteSource) GetLikeCount(id uint) (int64, error) {
+	return s.store.GetDownvoteCount(id)
+}
+
+// key 不存在时先从库回源再累加，与 CachedReactionCounter.IncrementReaction 一致
+func (c *CachedLikeCounter) IncrementLikeCount(id uint, delta int) error {
+	ok, err := c.cache.incrementIfExists(id, delta)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		if _, err := c.GetLikeCount(id); err != nil {
+			return err
+		}
+		if _, err := c.cache.incrementIfExists(id, delta); err != nil {
+			return err
+		}
+	}
 
-	return c.cache.MarkDirty(threadID)
+	return c.cache.MarkDirty(id)
 }
 
-func (c *CachedThreadLikeCounter) GetLikeCount(threadID uint) (int64, error) {
-	if val, err := c.cache.GetLikeCount(threadID); err == nil {
+func (c *CachedLikeCounter) GetLikeCount(id uint) (int64, error) {
+	if val, err := c.cache.GetLikeCount(id); err == nil {
 		return val, nil
 	}
 
-	key := "thread_like_count:" + strconv.FormatUint(uint64(threadID), 10)
+	key := "like_count:" + strconv.FormatUint(uint64(id), 10)
 	v, err, _ := c.sf.Do(key, func() (interface{}, error) {
 		token := strconv.FormatInt(time.Now().UnixNano(), 10)
-		locked, _ := c.cache.TryLockLikeCount(threadID, token, likeCountLockTTL)
+		locked, _ := c.cache.TryLockLikeCount(id, token, likeCountLockTTL)
 		if locked {
-			defer c.cache.UnlockLikeCount(threadID, token)
-			val, err := c.db.GetLikeCount(threadID)
+			defer c.cache.UnlockLikeCount(id, token)
+			val, err := c.db.GetLikeCount(id)
 			if err != nil {
 				return nil, err
 			}
-			_ = c.cache.setLikeCount(threadID, val)
+			_ = c.cache.setLikeCount(id, val)
 			return val, err
 		}
 
 		time.Sleep(20 * time.Millisecond)
-		if val, err := c.cache.GetLikeCount(threadID); err == nil {
+		if val, err := c.cache.GetLikeCount(id); err == nil {
 			return val, nil
 		}
 
-		val, err := c.db.GetLikeCount(threadID)
+		val, err := c.db.GetLikeCount(id)
 		if err != nil {
 			return nil, err
 		}
-		_ = c.cache.setLikeCount(threadID, val)
+		_ = c.cache.setLikeCount(id, val)
 		return val, nil
 	})
 	if err != nil {
@@ -75,13 +106,27 @@ func (c *CachedThreadLikeCounter) GetLikeCount(threadID uint) (int64, error) {
 	return v.(int64), nil
 }
 
-func (c *CachedThreadLikeCounter) WithTx(tx *gorm.DB) ThreadLikeCounter {
-	tr, ok := c.db.(ThreadRepoWithTx)
-	if !ok {
+// 只返回缓存命中的部分，未命中的由调用方用库里的 like_count 兜底
+func (c *CachedLikeCounter) GetLikeCounts(ids []uint) (map[uint]int64, error) {
+	return c.cache.GetLikeCounts(ids)
+}
+
+func (c *CachedLikeCounter) PurgeLikeCount(id uint) error {
+	return c.cache.PurgeLikeCount(id)
+}
+
+func (c *CachedLikeCounter) WithTx(tx *gorm.DB) LikeCounter {
+	var db LikeCountSource
+	switch src := c.db.(type) {
+	case ThreadRepoWithTx:
+		db = src.WithTx(tx)
+	case ReplyLikeRepoWithTx:
+		db = src.WithTx(tx)
+	default:
 		return c
 	}
-	return &CachedThreadLikeCounter{
-		db:    tr.WithTx(tx),
+	return &CachedLikeCounter{
+		db:    db,
 		cache: c.cache,
 		sf:    c.sf,
 	}
@@ -34,6 +34,26 @@ func (f *fakeThreadRepo) FindByID(uint) (*models.Thread, error) {
 	return nil, nil
 }
 
+func (f *fakeThreadRepo) FindByIDs([]uint) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) ListByActivity(int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) ListByActivityAfter(time.Time, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) ListByScore(int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) ListByScoreAfter(int64, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
 func (f *fakeThreadRepo) Count() (int64, error) {
 	return 0, nil
 }
@@ -64,6 +84,30 @@ func (f *fakeThreadRepo) IncrementLikeCount(threadID uint, delta int) error {
 	return f.incErr
 }
 
+func (f *fakeThreadRepo) ListUnanswered(bool, int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) ListUnansweredAfter(bool, time.Time, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepo) CountUnanswered() (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeThreadRepo) SetAcceptedReply(uint, uint, uint) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeThreadRepo) ClearAcceptedReply(uint, uint) error {
+	return nil
+}
+
+func (f *fakeThreadRepo) UpdateReplyStats(uint, int, *models.Reply) error {
+	return nil
+}
+
 func (f *fakeThreadRepo) GetLikeCount(threadID uint) (int64, error) {
 	f.getCalls++
 	return f.getVal, f.getErr
@@ -82,6 +126,9 @@ type fakeLikeCache struct {
 	lockErr   error
 	lockCalls int
 	unlockErr error
+	purged    []uint
+	// 模拟 key 不存在，setLikeCount 后恢复
+	missing bool
 }
 
 func (f *fakeLikeCache) IncrementLikeCount(threadID uint, delta int) error {
@@ -90,13 +137,37 @@ func (f *fakeLikeCache) IncrementLikeCount(threadID uint, delta int) error {
 	return f.incErr
 }
 
+func (f *fakeLikeCache) incrementIfExists(threadID uint, delta int) (bool, error) {
+	f.incCalls++
+	f.lastDelta = delta
+	if f.incErr != nil {
+		return false, f.incErr
+	}
+	if f.missing {
+		return false, nil
+	}
+	f.getVal += int64(delta)
+	return true, nil
+}
+
 func (f *fakeLikeCache) GetLikeCount(threadID uint) (int64, error) {
+	if f.missing {
+		return 0, ErrLikeCountNotFound
+	}
 	return f.getVal, f.getErr
 }
 
+func (f *fakeLikeCache) GetLikeCounts(threadIDs []uint) (map[uint]int64, error) {
+	return nil, f.getErr
+}
+
 func (f *fakeLikeCache) setLikeCount(threadID uint, value int64) error {
 	f.setCalls++
 	f.setVal = value
+	if f.setErr == nil && f.missing {
+		f.missing = false
+		f.getVal = value
+	}
 	return f.setErr
 }
 
@@ -117,10 +188,27 @@ func (f *fakeLikeCache) MarkDirty(threadID uint) error {
 	return nil
 }
 
-func TestCachedThreadLikeCounterGetLikeCountCacheHit(t *testing.T) {
+func (f *fakeLikeCache) PurgeLikeCount(threadID uint) error {
+	f.purged = append(f.purged, threadID)
+	return nil
+}
+
+func TestCachedLikeCounterPurgeLikeCount(t *testing.T) {
+	cache := &fakeLikeCache{}
+	counter := &CachedLikeCounter{db: &fakeThreadRepo{}, cache: cache, sf: &singleflight.Group{}}
+
+	if err := counter.PurgeLikeCount(4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cache.purged) != 1 || cache.purged[0] != 4 {
+		t.Fatalf("expected cache purged, got %+v", cache.purged)
+	}
+}
+
+func TestCachedLikeCounterGetLikeCountCacheHit(t *testing.T) {
 	db := &fakeThreadRepo{getVal: 7}
 	cache := &fakeLikeCache{getVal: 9}
-	counter := &CachedThreadLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
 
 	val, err := counter.GetLikeCount(1)
 	if err != nil {
@@ -134,10 +222,10 @@ func TestCachedThreadLikeCounterGetLikeCountCacheHit(t *testing.T) {
 	}
 }
 
-func TestCachedThreadLikeCounterGetLikeCountCacheMiss(t *testing.T) {
+func TestCachedLikeCounterGetLikeCountCacheMiss(t *testing.T) {
 	db := &fakeThreadRepo{getVal: 5}
 	cache := &fakeLikeCache{getErr: ErrLikeCountNotFound}
-	counter := &CachedThreadLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
 
 	val, err := counter.GetLikeCount(1)
 	if err != nil {
@@ -154,10 +242,10 @@ func TestCachedThreadLikeCounterGetLikeCountCacheMiss(t *testing.T) {
 	}
 }
 
-func TestCachedThreadLikeCounterGetLikeCountCacheErrorFallback(t *testing.T) {
+func TestCachedLikeCounterGetLikeCountCacheErrorFallback(t *testing.T) {
 	db := &fakeThreadRepo{getVal: 3}
 	cache := &fakeLikeCache{getErr: errors.New("boom")}
-	counter := &CachedThreadLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
 
 	val, err := counter.GetLikeCount(1)
 	if err != nil {
@@ -171,10 +259,10 @@ func TestCachedThreadLikeCounterGetLikeCountCacheErrorFallback(t *testing.T) {
 	}
 }
 
-func TestCachedThreadLikeCounterIncrementLikeCountCacheError(t *testing.T) {
+func TestCachedLikeCounterIncrementLikeCountCacheError(t *testing.T) {
 	db := &fakeThreadRepo{}
 	cache := &fakeLikeCache{incErr: errors.New("cache")}
-	counter := &CachedThreadLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
 
 	if err := counter.IncrementLikeCount(1, -1); err == nil {
 		t.Fatalf("expected error, got nil")
@@ -187,10 +275,10 @@ func TestCachedThreadLikeCounterIncrementLikeCountCacheError(t *testing.T) {
 	}
 }
 
-func TestCachedThreadLikeCounterIncrementLikeCountOK(t *testing.T) {
+func TestCachedLikeCounterIncrementLikeCountOK(t *testing.T) {
 	db := &fakeThreadRepo{}
 	cache := &fakeLikeCache{}
-	counter := &CachedThreadLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
 
 	if err := counter.IncrementLikeCount(1, 1); err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -202,3 +290,77 @@ func TestCachedThreadLikeCounterIncrementLikeCountOK(t *testing.T) {
 		t.Fatalf("expected MarkDirty called once, got %d", cache.markCalls)
 	}
 }
+
+func TestCachedLikeCounterIncrementLikeCountLoadsOnMiss(t *testing.T) {
+	// 合并后计数 key 已清掉，库中是重算后的 8，再点一次赞应得到 9 而不是 1
+	db := &fakeThreadRepo{getVal: 8}
+	cache := &fakeLikeCache{missing: true}
+	counter := &CachedLikeCounter{db: db, cache: cache, sf: &singleflight.Group{}}
+
+	if err := counter.IncrementLikeCount(1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.getCalls != 1 || cache.setVal != 8 {
+		t.Fatalf("expected count loaded from db, got calls=%d value=%d", db.getCalls, cache.setVal)
+	}
+	if cache.getVal != 9 || cache.markCalls != 1 {
+		t.Fatalf("expected merged count incremented to 9, got %d (mark=%d)", cache.getVal, cache.markCalls)
+	}
+}
+
+func TestRedisLikeCounterKeysByTarget(t *testing.T) {
+	thread := NewRedisLikeCounter(nil, LikeTargetThread)
+	reply := NewRedisLikeCounter(nil, LikeTargetReply)
+
+	if thread.key(3) != "thread:like:3" || thread.dirtyKey() != "thread:like:dirty" || thread.lockKey(3) != "thread:like:lock3" {
+		t.Fatalf("thread keys changed: %s %s %s", thread.key(3), thread.dirtyKey(), thread.lockKey(3))
+	}
+	if reply.key(3) != "reply:like:3" || reply.dirtyKey() != "reply:like:dirty" || reply.lockKey(3) != "reply:like:lock3" {
+		t.Fatalf("unexpected reply keys: %s %s %s", reply.key(3), reply.dirtyKey(), reply.lockKey(3))
+	}
+}
+
+func TestRedisDownvoteCounterKeys(t *testing.T) {
+	c := NewRedisDownvoteCounter(nil, LikeTargetThread)
+	if c.key(3) != "thread:downvote:3" || c.dirtyKey() != "thread:downvote:dirty" || c.lockKey(3) != "thread:downvote:lock3" {
+		t.Fatalf("unexpected downvote keys: %s %s %s", c.key(3), c.dirtyKey(), c.lockKey(3))
+	}
+	if c.QueueName() != "thread:downvote" {
+		t.Fatalf("unexpected queue name: %s", c.QueueName())
+	}
+}
+
+func TestDirtyQueueKeysByTarget(t *testing.T) {
+	like := NewRedisLikeCounter(nil, LikeTargetReply).queue()
+	if like.dirtyKey != "reply:like:dirty" || like.processingKey != "reply:like:processing" {
+		t.Fatalf("unexpected like queue keys: %s %s", like.dirtyKey, like.processingKey)
+	}
+	reactions := NewRedisReactionCounter(nil, LikeTargetThread).queue()
+	if reactions.dirtyKey != "thread:reactions:dirty" || reactions.processingKey != "thread:reactions:processing" {
+		t.Fatalf("unexpected reaction queue keys: %s %s", reactions.dirtyKey, reactions.processingKey)
+	}
+}
+
+func TestRedisReactionCounterKeys(t *testing.T) {
+	c := NewRedisReactionCounter(nil, LikeTargetReply)
+	if c.key(3) != "reply:reactions:3" || c.dirtyKey() != "reply:reactions:dirty" || c.lockKey(3) != "reply:reactions:lock3" {
+		t.Fatalf("unexpected reaction keys: %s %s %s", c.key(3), c.dirtyKey(), c.lockKey(3))
+	}
+}
+
+func TestParseReactionCountsSkipsLoadedMarker(t *testing.T) {
+	got := parseReactionCounts(map[string]string{reactionLoadedField: "0", "heart": "2", "laugh": "0", "bad": "x"})
+	if len(got) != 2 || got["heart"] != 2 || got["laugh"] != 0 {
+		t.Fatalf("unexpected counts: %v", got)
+	}
+}
+
+func TestRedisLeaderLeaseKeys(t *testing.T) {
+	l := NewRedisLeaderLease(nil, "workers", time.Second)
+	if l.key != "leader:workers" || l.fenceKey() != "leader:workers:fence" {
+		t.Fatalf("unexpected leader keys: %s %s", l.key, l.fenceKey())
+	}
+	if got := leaseValue("host-1", 7); got != "host-1:7" {
+		t.Fatalf("unexpected lease value: %s", got)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type ReactionCounter interface {
+	IncrementReaction(id uint, reaction string, delta int) error
+	GetReactionCounts(id uint) (map[string]int64, error)
+	GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error)
+	PurgeReactionCounts(id uint) error
+}
+
+type reactionCache interface {
+	incrementReaction(id uint, reaction string, delta int) (bool, error)
+	GetReactionCounts(id uint) (map[string]int64, error)
+	GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error)
+	setReactionCounts(id uint, counts map[string]int64) error
+	tryLock(id uint, token string, ttl time.Duration) (bool, error)
+	unlock(id uint, token string) error
+	MarkDirty(id uint) error
+	PurgeReactionCounts(id uint) error
+}
+
+// 与 CachedLikeCounter 相同的策略：写 Redis 哈希并标记 dirty，未命中时从 reaction_counts 回源
+type CachedReactionCounter struct {
+	db     ReactionCountRepository
+	cache  reactionCache
+	target string
+	sf     *singleflight.Group
+}
+
+func NewCachedReactionCounter(db ReactionCountRepository, cache *RedisReactionCounter) *CachedReactionCounter {
+	return &CachedReactionCounter{
+		db:     db,
+		cache:  cache,
+		target: cache.target,
+		sf:     &singleflight.Group{},
+	}
+}
+
+func (c *CachedReactionCounter) IncrementReaction(id uint, reaction string, delta int) error {
+	ok, err := c.cache.incrementReaction(id, reaction, delta)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := c.load(id); err != nil {
+			return err
+		}
+		if _, err := c.cache.incrementReaction(id, reaction, delta); err != nil {
+			return err
+		}
+	}
+	return c.cache.MarkDirty(id)
+}
+
+func (c *CachedReactionCounter) GetReactionCounts(id uint) (map[string]int64, error) {
+	if counts, err := c.cache.GetReactionCounts(id); err == nil {
+		return counts, nil
+	}
+	return c.load(id)
+}
+
+func (c *CachedReactionCounter) load(id uint) (map[string]int64, error) {
+	key := "reaction_counts:" + strconv.FormatUint(uint64(id), 10)
+	v, err, _ := c.sf.Do(key, func() (interface{}, error) {
+		token := strconv.FormatInt(time.Now().UnixNano(), 10)
+		locked, _ := c.cache.tryLock(id, token, likeCountLockTTL)
+		if !locked {
+			time.Sleep(20 * time.Millisecond)
+			if counts, err := c.cache.GetReactionCounts(id); err == nil {
+				return counts, nil
+			}
+		} else {
+			defer c.cache.unlock(id, token)
+		}
+
+		res, err := c.db.GetReactionCounts(c.target, []uint{id})
+		if err != nil {
+			return nil, err
+		}
+		counts := res[id]
+		if counts == nil {
+			counts = map[string]int64{}
+		}
+		_ = c.cache.setReactionCounts(id, counts)
+		return counts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]int64), nil
+}
+
+// 缓存未命中的目标合并为一次查询回源，不回填缓存
+func (c *CachedReactionCounter) GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error) {
+	res, err := c.cache.GetReactionCountsBatch(ids)
+	if err != nil {
+		res = make(map[uint]map[string]int64, len(ids))
+	}
+	var misses []uint
+	for _, id := range ids {
+		if _, ok := res[id]; !ok {
+			misses = append(misses, id)
+		}
+	}
+	if len(misses) == 0 {
+		return res, nil
+	}
+	fromDB, err := c.db.GetReactionCounts(c.target, misses)
+	if err != nil {
+		return nil, err
+	}
+	for id, counts := range fromDB {
+		res[id] = counts
+	}
+	return res, nil
+}
+
+func (c *CachedReactionCounter) PurgeReactionCounts(id uint) error {
+	return c.cache.PurgeReactionCounts(id)
+}
@@ -22,10 +22,15 @@ const (
 
 type threadCacheStore interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
+	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
 }
 
+type ThreadCacheRefresher interface {
+	RefreshCache(id uint) error
+}
+
 type CachedThreadRepo struct {
 	db  ThreadRepository
 	rdb threadCacheStore
@@ -106,6 +111,95 @@ func (c *CachedThreadRepo) FindByID(id uint) (*models.Thread, error) {
 	return t, nil
 }
 
+// 一次 MGET 取缓存，未命中的用一条 IN 查询回源；不存在的 ID 同样写入负缓存。返回顺序与 ids 一致，不存在的跳过
+func (c *CachedThreadRepo) FindByIDs(ids []uint) ([]models.Thread, error) {
+	ids = dedupeIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.cacheKey(id)
+	}
+
+	found := make(map[uint]models.Thread, len(ids))
+	notFound := make(map[uint]bool)
+	var misses []uint
+
+	vals, err := c.rdb.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		vals = make([]interface{}, len(ids))
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			misses = append(misses, ids[i])
+			continue
+		}
+		if s == threadCacheNotFound {
+			notFound[ids[i]] = true
+			continue
+		}
+		var t models.Thread
+		if err := json.Unmarshal([]byte(s), &t); err != nil {
+			misses = append(misses, ids[i])
+			continue
+		}
+		found[ids[i]] = t
+	}
+
+	if len(misses) > 0 {
+		ts, err := c.db.FindByIDs(misses)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ts {
+			found[ts[i].ID] = ts[i]
+			_ = c.setCache(&ts[i])
+		}
+		for _, id := range misses {
+			if _, ok := found[id]; !ok {
+				_ = c.rdb.Set(context.Background(), c.cacheKey(id), threadCacheNotFound, threadCacheNotFoundTTL).Err()
+			}
+		}
+	}
+
+	res := make([]models.Thread, 0, len(found))
+	for _, id := range ids {
+		if t, ok := found[id]; ok {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func dedupeIDs(ids []uint) []uint {
+	seen := make(map[uint]struct{}, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// 从数据库重新加载并覆盖缓存，包括覆盖不存在标记
+func (c *CachedThreadRepo) RefreshCache(id uint) error {
+	t, err := c.db.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		c.deleteCache(id)
+		return nil
+	}
+	return c.setCache(t)
+}
+
 func (c *CachedThreadRepo) Create(t *models.Thread) error {
 	if err := c.db.Create(t); err != nil {
 		return err
@@ -138,6 +232,34 @@ func (c *CachedThreadRepo) ListAfter(cursorTime time.Time, cursorID uint, limit
 	return c.db.ListAfter(cursorTime, cursorID, limit)
 }
 
+func (c *CachedThreadRepo) ListByActivity(limit, offset int) ([]models.Thread, error) {
+	return c.db.ListByActivity(limit, offset)
+}
+
+func (c *CachedThreadRepo) ListByActivityAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	return c.db.ListByActivityAfter(cursorTime, cursorID, limit)
+}
+
+func (c *CachedThreadRepo) ListByScore(limit, offset int) ([]models.Thread, error) {
+	return c.db.ListByScore(limit, offset)
+}
+
+func (c *CachedThreadRepo) ListByScoreAfter(cursorScore int64, cursorID uint, limit int) ([]models.Thread, error) {
+	return c.db.ListByScoreAfter(cursorScore, cursorID, limit)
+}
+
+func (c *CachedThreadRepo) ListUnanswered(byActivity bool, limit, offset int) ([]models.Thread, error) {
+	return c.db.ListUnanswered(byActivity, limit, offset)
+}
+
+func (c *CachedThreadRepo) ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	return c.db.ListUnansweredAfter(byActivity, cursorTime, cursorID, limit)
+}
+
+func (c *CachedThreadRepo) CountUnanswered() (int64, error) {
+	return c.db.CountUnanswered()
+}
+
 func (c *CachedThreadRepo) Count() (int64, error) {
 	return c.db.Count()
 }
@@ -162,6 +284,31 @@ func (c *CachedThreadRepo) GetLikeCount(threadID uint) (int64, error) {
 	return c.db.GetLikeCount(threadID)
 }
 
+func (c *CachedThreadRepo) UpdateReplyStats(threadID uint, delta int, last *models.Reply) error {
+	if err := c.db.UpdateReplyStats(threadID, delta, last); err != nil {
+		return err
+	}
+	c.deleteCache(threadID)
+	return nil
+}
+
+func (c *CachedThreadRepo) SetAcceptedReply(threadID, fromID, toID uint) (bool, error) {
+	ok, err := c.db.SetAcceptedReply(threadID, fromID, toID)
+	if err != nil {
+		return false, err
+	}
+	c.deleteCache(threadID)
+	return ok, nil
+}
+
+func (c *CachedThreadRepo) ClearAcceptedReply(threadID, replyID uint) error {
+	if err := c.db.ClearAcceptedReply(threadID, replyID); err != nil {
+		return err
+	}
+	c.deleteCache(threadID)
+	return nil
+}
+
 func (c *CachedThreadRepo) Transaction(fn func(tx *gorm.DB) error) error {
 	txer, ok := c.db.(Transactioner)
 	if !ok {
@@ -16,6 +16,9 @@ type fakeThreadRepoCache struct {
 	findVal   *models.Thread
 	findErr   error
 	findCalls int
+
+	byIDsVal   []models.Thread
+	byIDsCalls [][]uint
 }
 
 func (f *fakeThreadRepoCache) Create(*models.Thread) error {
@@ -35,6 +38,35 @@ func (f *fakeThreadRepoCache) FindByID(id uint) (*models.Thread, error) {
 	return f.findVal, f.findErr
 }
 
+func (f *fakeThreadRepoCache) FindByIDs(ids []uint) ([]models.Thread, error) {
+	f.byIDsCalls = append(f.byIDsCalls, ids)
+	var res []models.Thread
+	for _, t := range f.byIDsVal {
+		for _, id := range ids {
+			if t.ID == id {
+				res = append(res, t)
+			}
+		}
+	}
+	return res, f.findErr
+}
+
+func (f *fakeThreadRepoCache) ListByActivity(int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepoCache) ListByActivityAfter(time.Time, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepoCache) ListByScore(int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepoCache) ListByScoreAfter(int64, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
 func (f *fakeThreadRepoCache) Count() (int64, error) {
 	return 0, nil
 }
@@ -63,6 +95,30 @@ func (f *fakeThreadRepoCache) IncrementLikeCount(uint, int) error {
 	return nil
 }
 
+func (f *fakeThreadRepoCache) ListUnanswered(bool, int, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepoCache) ListUnansweredAfter(bool, time.Time, uint, int) ([]models.Thread, error) {
+	return nil, nil
+}
+
+func (f *fakeThreadRepoCache) CountUnanswered() (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeThreadRepoCache) SetAcceptedReply(uint, uint, uint) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeThreadRepoCache) ClearAcceptedReply(uint, uint) error {
+	return nil
+}
+
+func (f *fakeThreadRepoCache) UpdateReplyStats(uint, int, *models.Reply) error {
+	return nil
+}
+
 func (f *fakeThreadRepoCache) GetLikeCount(uint) (int64, error) {
 	return 0, nil
 }
@@ -73,6 +129,7 @@ type fakeRedisClient struct {
 	setErr    error
 	setKeys   []string
 	setValues map[string]string
+	mgetVals  map[string]string
 }
 
 func (f *fakeRedisClient) Get(_ context.Context, _ string) *redis.StringCmd {
@@ -84,6 +141,18 @@ func (f *fakeRedisClient) Get(_ context.Context, _ string) *redis.StringCmd {
 	return cmd
 }
 
+func (f *fakeRedisClient) MGet(_ context.Context, keys ...string) *redis.SliceCmd {
+	cmd := redis.NewSliceCmd(context.Background())
+	vals := make([]interface{}, len(keys))
+	for i, k := range keys {
+		if v, ok := f.mgetVals[k]; ok {
+			vals[i] = v
+		}
+	}
+	cmd.SetVal(vals)
+	return cmd
+}
+
 func (f *fakeRedisClient) Set(_ context.Context, key string, value interface{}, _ time.Duration) *redis.StatusCmd {
 	cmd := redis.NewStatusCmd(context.Background())
 	if f.setErr != nil {
@@ -157,6 +226,29 @@ func TestCachedThreadRepoFindByIDCacheMiss(t *testing.T) {
 	}
 }
 
+func TestCachedThreadRepoCachesRenderedContent(t *testing.T) {
+	thread := &models.Thread{ID: 4, Content: "**x**", ContentFormat: "markdown", ContentHTML: "<p><strong>x</strong></p>"}
+	db := &fakeThreadRepoCache{findVal: thread}
+	rdb := &fakeRedisClient{getErr: redis.Nil}
+	repo := &CachedThreadRepo{
+		db:  db,
+		rdb: rdb,
+		sf:  &singleflight.Group{},
+	}
+
+	if _, err := repo.FindByID(4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cached models.Thread
+	if err := json.Unmarshal([]byte(rdb.setValues[repo.cacheKey(4)]), &cached); err != nil {
+		t.Fatalf("unmarshal cached value failed: %v", err)
+	}
+	if cached.ContentFormat != "markdown" || cached.ContentHTML != thread.ContentHTML {
+		t.Fatalf("expected rendered content cached, got %+v", cached)
+	}
+}
+
 func TestCachedThreadRepoFindByIDNotFound(t *testing.T) {
 	db := &fakeThreadRepoCache{findVal: nil}
 	rdb := &fakeRedisClient{getErr: redis.Nil}
@@ -183,3 +275,59 @@ func TestCachedThreadRepoFindByIDNotFound(t *testing.T) {
 		t.Fatalf("expected not found cache set")
 	}
 }
+
+func TestCachedThreadRepoRefreshCacheOverwritesNotFound(t *testing.T) {
+	thread := &models.Thread{ID: 5, Title: "restored"}
+	db := &fakeThreadRepoCache{findVal: thread}
+	rdb := &fakeRedisClient{setValues: map[string]string{}}
+	repo := &CachedThreadRepo{
+		db:  db,
+		rdb: rdb,
+		sf:  &singleflight.Group{},
+	}
+	rdb.setValues[repo.cacheKey(5)] = threadCacheNotFound
+
+	if err := repo.RefreshCache(5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cached models.Thread
+	if err := json.Unmarshal([]byte(rdb.setValues[repo.cacheKey(5)]), &cached); err != nil {
+		t.Fatalf("expected thread cached, got %q", rdb.setValues[repo.cacheKey(5)])
+	}
+	if cached.Title != "restored" {
+		t.Fatalf("unexpected cached thread: %+v", cached)
+	}
+}
+
+func TestCachedThreadRepoFindByIDsMixesCacheAndDB(t *testing.T) {
+	db := &fakeThreadRepoCache{byIDsVal: []models.Thread{{ID: 3, Title: "db"}}}
+	rdb := &fakeRedisClient{}
+	repo := &CachedThreadRepo{
+		db:  db,
+		rdb: rdb,
+		sf:  &singleflight.Group{},
+	}
+	raw, _ := json.Marshal(&models.Thread{ID: 1, Title: "cached"})
+	rdb.mgetVals = map[string]string{
+		repo.cacheKey(1): string(raw),
+		repo.cacheKey(2): threadCacheNotFound,
+	}
+
+	got, err := repo.FindByIDs([]uint{3, 2, 1, 4, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 3 || got[1].ID != 1 {
+		t.Fatalf("unexpected threads: %+v", got)
+	}
+	if len(db.byIDsCalls) != 1 || len(db.byIDsCalls[0]) != 2 {
+		t.Fatalf("expected one db query for misses, got %v", db.byIDsCalls)
+	}
+	if rdb.setValues[repo.cacheKey(4)] != threadCacheNotFound {
+		t.Fatalf("expected not found cache set for missing id")
+	}
+	if _, ok := rdb.setValues[repo.cacheKey(3)]; !ok {
+		t.Fatalf("expected db result cached")
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KEYS[1] dirty 集合，KEYS[2] processing 有序集合（score 为租约到期毫秒时间）
+// ARGV[1] 当前毫秒时间，ARGV[2] 新租约到期时间，ARGV[3] 数量上限
+// 先回收租约已过期的 ID，不足再从 dirty 集合弹出。弹出的 ID 若仍在有效租约中则放回 dirty，
+// 避免同一 ID 被两个租约持有；若租约已过期则丢弃，它会随 processing 的回收被处理
+const claimDirtyScript = `
+local limit = tonumber(ARGV[3])
+local ids = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, limit)
+local n = limit - #ids
+if n > 0 then
+	local popped = redis.call("SPOP", KEYS[1], n)
+	for _, id in ipairs(popped) do
+		local score = redis.call("ZSCORE", KEYS[2], id)
+		if not score then
+			table.insert(ids, id)
+		elseif tonumber(score) > tonumber(ARGV[1]) then
+			redis.call("SADD", KEYS[1], id)
+		end
+	end
+end
+for _, id in ipairs(ids) do
+	redis.call("ZADD", KEYS[2], ARGV[2], id)
+end
+return ids
+`
+
+// ARGV[1] 租约标识，其余为 ID；只确认仍持有同一租约的 ID，租约过期被别人回收后的确认不生效
+const ackDirtyScript = `
+local n = 0
+for i = 2, #ARGV do
+	local score = redis.call("ZSCORE", KEYS[1], ARGV[i])
+	if score and tonumber(score) == tonumber(ARGV[1]) then
+		n = n + redis.call("ZREM", KEYS[1], ARGV[i])
+	end
+end
+return n
+`
+
+// 至少一次的 dirty 队列：领取时移入 processing 并带租约，回写成功后确认；
+// 进程在确认前退出时，租约到期后由下一次领取重新处理
+type dirtyQueue struct {
+	rdb           *redis.Client
+	dirtyKey      string
+	processingKey string
+}
+
+func (q dirtyQueue) mark(id uint) error {
+	return q.rdb.SAdd(context.Background(), q.dirtyKey, id).Err()
+}
+
+// 返回领取的 ID 与本次租约标识，确认时需带上
+func (q dirtyQueue) claim(limit int, lease time.Duration) ([]uint, int64, error) {
+	now := time.Now().UnixMilli()
+	token := now + lease.Milliseconds()
+	vals, err := q.rdb.Eval(context.Background(), claimDirtyScript,
+		[]string{q.dirtyKey, q.processingKey}, now, token, limit).StringSlice()
+	if err != nil {
+		return nil, 0, fmt.Errorf("领取 dirty 队列失败：%w", err)
+	}
+	ids := make([]uint, 0, len(vals))
+	for _, v := range vals {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, token, nil
+}
+
+func (q dirtyQueue) ack(ids []uint, token int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, token)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if err := q.rdb.Eval(context.Background(), ackDirtyScript,
+		[]string{q.processingKey}, args...).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("确认 dirty 队列失败：%w", err)
+	}
+	return nil
+}
+
+// 尚未回写的 ID 数：dirty 集合加上已领取未确认的
+func (q dirtyQueue) pending() (int64, error) {
+	pipe := q.rdb.Pipeline()
+	dirty := pipe.SCard(context.Background(), q.dirtyKey)
+	processing := pipe.ZCard(context.Background(), q.processingKey)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return 0, fmt.Errorf("统计 dirty 队列失败：%w", err)
+	}
+	return dirty.Val() + processing.Val(), nil
+}
+
+func (q dirtyQueue) remove(pipe redis.Pipeliner, id uint) {
+	pipe.SRem(context.Background(), q.dirtyKey, id)
+	pipe.ZRem(context.Background(), q.processingKey, id)
+}
@@ -3,3 +3,5 @@ package repository
 import "errors"
 
 var ErrLikeCountNotFound = errors.New("点赞数不存在")
+var ErrVersionConflict = errors.New("版本冲突")
+var ErrReactionCountNotFound = errors.New("回应数不存在")
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KEYS[1] 租约 key，KEYS[2] fencing token 计数器；ARGV[1] 实例标识，ARGV[2] 租约毫秒
+// 租约空闲时递增 token 并写入 "<owner>:<token>"，返回 token；已被持有时返回 0
+const acquireLeaderScript = `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], ARGV[1] .. ":" .. token, "PX", ARGV[2])
+return token
+`
+
+// 续约与释放都比对完整的 "<owner>:<token>"，过期后被他人接管的旧 leader 无法续约或删除新租约
+const renewLeaderScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+const releaseLeaderScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+// 基于 Redis 租约的选主，同一 name 的租约同一时刻只有一个实例持有。
+// 每次当选得到单调递增的 fencing token，用于区分先后任期
+type RedisLeaderLease struct {
+	rdb *redis.Client
+	key string
+	ttl time.Duration
+}
+
+func NewRedisLeaderLease(rdb *redis.Client, name string, ttl time.Duration) *RedisLeaderLease {
+	return &RedisLeaderLease{rdb: rdb, key: "leader:" + name, ttl: ttl}
+}
+
+func (l *RedisLeaderLease) fenceKey() string {
+	return l.key + ":fence"
+}
+
+func leaseValue(owner string, token int64) string {
+	return owner + ":" + strconv.FormatInt(token, 10)
+}
+
+// 返回本次任期的 fencing token，租约已被他人持有时返回 0
+func (l *RedisLeaderLease) Acquire(owner string) (int64, error) {
+	token, err := l.rdb.Eval(context.Background(), acquireLeaderScript,
+		[]string{l.key, l.fenceKey()}, owner, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("获取 leader 租约失败：%w", err)
+	}
+	return token, nil
+}
+
+// 返回 false 表示租约已过期或已被他人接管
+func (l *RedisLeaderLease) Renew(owner string, token int64) (bool, error) {
+	n, err := l.rdb.Eval(context.Background(), renewLeaderScript,
+		[]string{l.key}, leaseValue(owner, token), l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("续约 leader 租约失败：%w", err)
+	}
+	return n == 1, nil
+}
+
+func (l *RedisLeaderLease) Release(owner string, token int64) error {
+	if err := l.rdb.Eval(context.Background(), releaseLeaderScript,
+		[]string{l.key}, leaseValue(owner, token)).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("释放 leader 租约失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 一条 UPDATE ... CASE 回写一批点赞数或踩数，同时更新净分；query 需已指定 Model 与删除范围
+func updateVoteCounts(query *gorm.DB, column string, counts map[uint]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var expr strings.Builder
+	args := make([]interface{}, 0, len(ids)*2)
+	expr.WriteString("CASE id")
+	for _, id := range ids {
+		expr.WriteString(" WHEN ? THEN ?")
+		args = append(args, id, counts[id])
+	}
+	expr.WriteString(" ELSE " + column + " END")
+
+	// 净分直接用新值计算，不依赖 SET 子句的求值顺序
+	score := "like_count - (" + expr.String() + ")"
+	if column == "like_count" {
+		score = "(" + expr.String() + ") - downvote_count"
+	}
+	return query.Where("id IN ?", ids).UpdateColumns(map[string]interface{}{
+		column:  gorm.Expr(expr.String(), args...),
+		"score": gorm.Expr(score, args...),
+	}).Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+// 点赞目标类型，同时作为 Redis key 前缀：<target>:like:<id>
+const (
+	LikeTargetThread = "thread"
+	LikeTargetReply  = "reply"
+)
+
+type LikeCounter interface {
+	IncrementLikeCount(id uint, delta int) error
+	GetLikeCount(id uint) (int64, error)
+}
+
+type LikeCountPurger interface {
+	PurgeLikeCount(id uint) error
+}
+
+type LikeBatchCounter interface {
+	GetLikeCounts(ids []uint) (map[uint]int64, error)
+}
+
+// 库中持久化的点赞数，缓存未命中时回源
+type LikeCountSource interface {
+	GetLikeCount(id uint) (int64, error)
+}
+
+// 后台任务把 Redis 中的点赞数按批回写到库，每批一条语句
+type LikeCountWriter interface {
+	SetLikeCounts(counts map[uint]int64) error
+}
+
+// 踩数与点赞数共用 CachedLikeCounter，库中持久化在 downvote_count
+type DownvoteCountStore interface {
+	GetDownvoteCount(id uint) (int64, error)
+	SetDownvoteCounts(counts map[uint]int64) error
+}
+
+// 对账任务读取并修正 Redis 中的点赞数
+type LikeCountResetter interface {
+	LikeBatchCounter
+	ResetLikeCount(id uint, value int64) error
+}
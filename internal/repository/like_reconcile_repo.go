@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type ThreadLikeCount struct {
+	ID        uint
+	LikeCount int64
+}
+
+// 对账任务按 ID 顺序遍历未删除帖子的 like_count 并修正
+type LikeReconcileRepository interface {
+	ListThreadLikeCounts(afterID uint, limit int) ([]ThreadLikeCount, error)
+	// 一批帖子按 thread_likes 实际点赞行数分组计数，没有点赞的帖子不在结果中
+	CountThreadLikes(ids []uint) (map[uint]int64, error)
+	SetLikeCount(id uint, value int64) error
+}
+
+type LikeReconcileRepo struct {
+	db *gorm.DB
+}
+
+func NewLikeReconcileRepository(db *gorm.DB) LikeReconcileRepository {
+	return &LikeReconcileRepo{db: db}
+}
+
+func (r *LikeReconcileRepo) ListThreadLikeCounts(afterID uint, limit int) ([]ThreadLikeCount, error) {
+	var rows []ThreadLikeCount
+	if err := r.db.Model(&models.Thread{}).
+		Select("id, like_count").
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子点赞数失败：%w", err)
+	}
+	return rows, nil
+}
+
+func (r *LikeReconcileRepo) CountThreadLikes(ids []uint) (map[uint]int64, error) {
+	res := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	var rows []struct {
+		ThreadID uint
+		Total    int64
+	}
+	if err := r.db.Model(&models.ThreadLike{}).
+		Select("thread_id, COUNT(*) AS total").
+		Where("thread_id IN ? and reaction = ?", ids, models.ReactionLike).
+		Group("thread_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子点赞失败：%w", err)
+	}
+	for _, row := range rows {
+		res[row.ThreadID] = row.Total
+	}
+	return res, nil
+}
+
+func (r *LikeReconcileRepo) SetLikeCount(id uint, value int64) error {
+	if err := r.db.Model(&models.Thread{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"like_count": value,
+			"score":      gorm.Expr("? - downvote_count", value),
+		}).Error; err != nil {
+		return fmt.Errorf("更新点赞数失败：%w", err)
+	}
+	return nil
+}
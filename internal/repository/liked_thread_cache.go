@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 每个用户最近点赞过的帖子 ID 缓存数量
+const LikedThreadCacheSize = 500
+
+const (
+	likedThreadCacheTTL = 5 * time.Minute
+	likedLoadedMember   = "_"
+	likedCompleteMember = "*"
+)
+
+var ErrLikedCacheMiss = errors.New("点赞缓存未加载")
+
+type LikedThreadCache interface {
+	// 返回每个 ID 是否在缓存集合中；complete 为 true 表示集合包含用户全部点赞，不在集合中即未点赞
+	Contains(userID uint, threadIDs []uint) (hits []bool, complete bool, err error)
+	Store(userID uint, threadIDs []uint, complete bool) error
+	Invalidate(userID uint) error
+}
+
+// 集合 user:liked_threads:<uid>，成员为帖子 ID，另有 "_" 表示已加载、"*" 表示完整
+type RedisLikedThreadCache struct {
+	rdb *redis.Client
+}
+
+func NewRedisLikedThreadCache(rdb *redis.Client) *RedisLikedThreadCache {
+	return &RedisLikedThreadCache{rdb: rdb}
+}
+
+func (c *RedisLikedThreadCache) key(userID uint) string {
+	return fmt.Sprintf("user:liked_threads:%d", userID)
+}
+
+func (c *RedisLikedThreadCache) Contains(userID uint, threadIDs []uint) ([]bool, bool, error) {
+	members := make([]interface{}, 0, len(threadIDs)+2)
+	members = append(members, likedLoadedMember, likedCompleteMember)
+	for _, id := range threadIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	res, err := c.rdb.SMIsMember(context.Background(), c.key(userID), members...).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if !res[0] {
+		return nil, false, ErrLikedCacheMiss
+	}
+	return res[2:], res[1], nil
+}
+
+// 加载与点赞失效之间存在竞争窗口，靠较短的过期时间兜底
+func (c *RedisLikedThreadCache) Store(userID uint, threadIDs []uint, complete bool) error {
+	members := make([]interface{}, 0, len(threadIDs)+2)
+	members = append(members, likedLoadedMember)
+	if complete {
+		members = append(members, likedCompleteMember)
+	}
+	for _, id := range threadIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	key := c.key(userID)
+	_, err := c.rdb.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), key)
+		pipe.SAdd(context.Background(), key, members...)
+		pipe.Expire(context.Background(), key, likedThreadCacheTTL)
+		return nil
+	})
+	return err
+}
+
+func (c *RedisLikedThreadCache) Invalidate(userID uint) error {
+	return c.rdb.Del(context.Background(), c.key(userID)).Err()
+}
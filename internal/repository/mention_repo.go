@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type MentionRepository interface {
+	Sync(sourceType string, sourceID uint, mentions []models.Mention) error
+	ListBySources(sourceType string, sourceIDs []uint) ([]models.Mention, error)
+	FilterReplyIDs(threadID uint, ids []uint) ([]uint, error)
+}
+
+type MentionRepo struct {
+	db *gorm.DB
+}
+
+func NewMentionRepository(db *gorm.DB) MentionRepository {
+	return &MentionRepo{db: db}
+}
+
+type mentionKey struct {
+	kind     string
+	targetID uint
+}
+
+// 只删除不再出现的引用、插入新增的引用，已有记录保留原 created_at，便于按新增提及发通知
+func (r *MentionRepo) Sync(sourceType string, sourceID uint, mentions []models.Mention) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Mention
+		if err := tx.Where("source_type = ? and source_id = ?", sourceType, sourceID).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+
+		want := make(map[mentionKey]struct{}, len(mentions))
+		for _, m := range mentions {
+			want[mentionKey{m.Kind, m.TargetID}] = struct{}{}
+		}
+		have := make(map[mentionKey]struct{}, len(existing))
+		var stale []uint
+		for _, m := range existing {
+			k := mentionKey{m.Kind, m.TargetID}
+			have[k] = struct{}{}
+			if _, ok := want[k]; !ok {
+				stale = append(stale, m.ID)
+			}
+		}
+		if len(stale) > 0 {
+			if err := tx.Delete(&models.Mention{}, stale).Error; err != nil {
+				return err
+			}
+		}
+
+		var added []models.Mention
+		for _, m := range mentions {
+			if _, ok := have[mentionKey{m.Kind, m.TargetID}]; ok {
+				continue
+			}
+			m.SourceType = sourceType
+			m.SourceID = sourceID
+			added = append(added, m)
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		return tx.Create(&added).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存提及失败：%w", err)
+	}
+	return nil
+}
+
+func (r *MentionRepo) ListBySources(sourceType string, sourceIDs []uint) ([]models.Mention, error) {
+	if len(sourceIDs) == 0 {
+		return nil, nil
+	}
+	var mentions []models.Mention
+	if err := r.db.Where("source_type = ? and source_id IN ?", sourceType, sourceIDs).
+		Order("id asc").
+		Find(&mentions).Error; err != nil {
+		return nil, fmt.Errorf("查询提及失败：%w", err)
+	}
+	return mentions, nil
+}
+
+// 只保留属于该帖子且未删除的回复
+func (r *MentionRepo) FilterReplyIDs(threadID uint, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []uint
+	if err := r.db.Model(&models.Reply{}).
+		Where("thread_id = ? and id IN ?", threadID, ids).
+		Pluck("id", &found).Error; err != nil {
+		return nil, fmt.Errorf("查询引用回复失败：%w", err)
+	}
+	return found, nil
+}
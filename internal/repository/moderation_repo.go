@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type ModerationRepository interface {
+	ListRepliesByIDs(threadID uint, ids []uint) ([]models.Reply, error)
+	MoveReplies(fromThreadID, toThreadID uint, ids []uint) error
+	MergeLikes(fromThreadID, toThreadID uint) error
+	MoveThreadUploadsToReply(threadID, replyID uint) error
+	MoveReplyUploadsToThread(replyID, threadID uint) error
+	HardDeleteReply(id uint) error
+	RecalcThreadStats(threadID uint) error
+	DetachOrphanReplies(threadID uint) error
+	MoveMentions(fromType string, fromID uint, toType string, toID uint) error
+}
+
+type ModerationRepo struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) ModerationRepository {
+	return &ModerationRepo{db: db}
+}
+
+func (r *ModerationRepo) ListRepliesByIDs(threadID uint, ids []uint) ([]models.Reply, error) {
+	var replies []models.Reply
+	if err := r.db.Where("thread_id = ? and id IN ?", threadID, ids).
+		Order("created_at asc, id asc").
+		Find(&replies).Error; err != nil {
+		return nil, fmt.Errorf("查询回复失败：%w", err)
+	}
+	return replies, nil
+}
+
+// ids 为空时移动全部回复（包括回收站中的），保证合并后回收站里的回复仍能恢复到新帖
+func (r *ModerationRepo) MoveReplies(fromThreadID, toThreadID uint, ids []uint) error {
+	q := r.db.Unscoped().Model(&models.Reply{}).Where("thread_id = ?", fromThreadID)
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	if err := q.UpdateColumn("thread_id", toThreadID).Error; err != nil {
+		return fmt.Errorf("移动回复失败：%w", err)
+	}
+	return nil
+}
+
+// 两边都有同一回应的用户只保留目标帖的记录；已在目标帖投过赞或踩的用户，源帖的赞与踩都不转移，
+// 保证每人一票。其余回应转移到目标帖
+func (r *ModerationRepo) MergeLikes(fromThreadID, toThreadID uint) error {
+	votes := []string{models.ReactionLike, models.ReactionDownvote}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+DELETE s FROM thread_likes s
+JOIN thread_likes t ON t.user_id = s.user_id AND t.reaction = s.reaction AND t.thread_id = ?
+WHERE s.thread_id = ?`, toThreadID, fromThreadID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+DELETE s FROM thread_likes s
+JOIN thread_likes t ON t.user_id = s.user_id AND t.thread_id = ? AND t.reaction IN ? AND t.deleted_at IS NULL
+WHERE s.thread_id = ? AND s.reaction IN ?`, toThreadID, votes, fromThreadID, votes).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.ThreadLike{}).
+			Where("thread_id = ?", fromThreadID).
+			UpdateColumn("thread_id", toThreadID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("合并点赞失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ModerationRepo) MoveThreadUploadsToReply(threadID, replyID uint) error {
+	if err := r.db.Model(&models.Upload{}).
+		Where("thread_id = ?", threadID).
+		UpdateColumns(map[string]interface{}{"thread_id": 0, "reply_id": replyID}).Error; err != nil {
+		return fmt.Errorf("移动附件失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ModerationRepo) MoveReplyUploadsToThread(replyID, threadID uint) error {
+	if err := r.db.Model(&models.Upload{}).
+		Where("reply_id = ?", replyID).
+		UpdateColumns(map[string]interface{}{"thread_id": threadID, "reply_id": 0}).Error; err != nil {
+		return fmt.Errorf("移动附件失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ModerationRepo) HardDeleteReply(id uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reply_id = ?", id).Delete(&models.ReplyLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? and target_id = ?", LikeTargetReply, id).
+			Delete(&models.ReactionCount{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Reply{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除回复失败：%w", err)
+	}
+	return nil
+}
+
+// 按现有数据重算回复数、最后回复、最后活跃时间、点赞、踩、净分与回应数，被采纳的回复已不在本帖时取消采纳
+// MySQL 单表 UPDATE 从左到右赋值，score 取的是重算后的点赞数与踩数
+func (r *ModerationRepo) RecalcThreadStats(threadID uint) error {
+	err := r.db.Exec(`
+UPDATE threads t SET
+	reply_count = (SELECT COUNT(*) FROM replies r WHERE r.thread_id = t.id AND r.deleted_at IS NULL),
+	last_reply_at = (SELECT MAX(r.created_at) FROM replies r WHERE r.thread_id = t.id AND r.deleted_at IS NULL),
+	last_reply_user_id = COALESCE((
+		SELECT r.user_id FROM replies r
+		WHERE r.thread_id = t.id AND r.deleted_at IS NULL
+		ORDER BY r.created_at DESC, r.id DESC LIMIT 1
+	), 0),
+	like_count = (SELECT COUNT(*) FROM thread_likes l WHERE l.thread_id = t.id AND l.deleted_at IS NULL AND l.reaction = 'like'),
+	downvote_count = (SELECT COUNT(*) FROM thread_likes l WHERE l.thread_id = t.id AND l.deleted_at IS NULL AND l.reaction = 'downvote'),
+	score = like_count - downvote_count,
+	accepted_reply_id = CASE WHEN EXISTS (
+		SELECT 1 FROM replies r WHERE r.id = t.accepted_reply_id AND r.thread_id = t.id AND r.deleted_at IS NULL
+	) THEN t.accepted_reply_id ELSE 0 END
+WHERE t.id = ?`, threadID).Error
+	if err == nil {
+		err = r.db.Exec(`
+UPDATE threads SET last_activity_at = GREATEST(created_at, COALESCE(last_reply_at, created_at))
+WHERE id = ?`, threadID).Error
+	}
+	if err == nil {
+		err = rebuildThreadReactionCounts(r.db, threadID)
+	}
+	if err != nil {
+		return fmt.Errorf("重算帖子统计失败：%w", err)
+	}
+	return nil
+}
+
+// 父回复不在同一帖子下（被拆走或已彻底删除）的回复提升为顶层，再逐层修正深度
+func (r *ModerationRepo) DetachOrphanReplies(threadID uint) error {
+	err := r.db.Exec(`
+UPDATE replies c
+LEFT JOIN replies p ON p.id = c.parent_id AND p.thread_id = c.thread_id
+SET c.parent_id = 0, c.depth = 0
+WHERE c.thread_id = ? AND c.parent_id <> 0 AND p.id IS NULL`, threadID).Error
+	for err == nil {
+		res := r.db.Exec(`
+UPDATE replies c
+JOIN replies p ON p.id = c.parent_id
+SET c.depth = p.depth + 1
+WHERE c.thread_id = ? AND c.depth <> p.depth + 1`, threadID)
+		err = res.Error
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("整理回复层级失败：%w", err)
+	}
+	return nil
+}
+
+// 正文在帖子与回复之间转换时，提及与引用记录跟随转移
+func (r *ModerationRepo) MoveMentions(fromType string, fromID uint, toType string, toID uint) error {
+	if err := r.db.Model(&models.Mention{}).
+		Where("source_type = ? and source_id = ?", fromType, fromID).
+		UpdateColumns(map[string]interface{}{"source_type": toType, "source_id": toID}).Error; err != nil {
+		return fmt.Errorf("移动提及失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ModerationRepo) WithTx(tx *gorm.DB) ModerationRepository {
+	return &ModerationRepo{db: tx}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// like 以外的回应计数，按目标类型区分，Redis 未命中时回源
+type ReactionCountRepository interface {
+	GetReactionCounts(target string, ids []uint) (map[uint]map[string]int64, error)
+	SetReactionCounts(target string, counts map[uint]map[string]int64) error
+}
+
+type ReactionCountRepo struct {
+	db *gorm.DB
+}
+
+func NewReactionCountRepository(db *gorm.DB) ReactionCountRepository {
+	return &ReactionCountRepo{db: db}
+}
+
+func (r *ReactionCountRepo) GetReactionCounts(target string, ids []uint) (map[uint]map[string]int64, error) {
+	res := make(map[uint]map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	var rows []models.ReactionCount
+	if err := r.db.Where("target_type = ? and target_id IN ? and count > 0", target, ids).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询回应数失败：%w", err)
+	}
+	for _, row := range rows {
+		if res[row.TargetID] == nil {
+			res[row.TargetID] = make(map[string]int64)
+		}
+		res[row.TargetID][row.Reaction] = row.Count
+	}
+	return res, nil
+}
+
+// 一条 INSERT ... ON DUPLICATE KEY UPDATE 回写一批目标的全部回应数
+func (r *ReactionCountRepo) SetReactionCounts(target string, counts map[uint]map[string]int64) error {
+	var rows []models.ReactionCount
+	for id, byReaction := range counts {
+		for reaction, count := range byReaction {
+			rows = append(rows, models.ReactionCount{
+				TargetType: target,
+				TargetID:   id,
+				Reaction:   reaction,
+				Count:      count,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}, {Name: "reaction"}},
+		DoUpdates: clause.AssignmentColumns([]string{"count"}),
+	}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("更新回应数失败：%w", err)
+	}
+	return nil
+}
+
+// 按帖子现有的回应记录重算计数，用于恢复、合并与拆分；like 与 downvote 有各自的计数列，不计入
+func rebuildThreadReactionCounts(db *gorm.DB, threadID uint) error {
+	if err := db.Where("target_type = ? and target_id = ?", LikeTargetThread, threadID).
+		Delete(&models.ReactionCount{}).Error; err != nil {
+		return err
+	}
+	return db.Exec(`
+INSERT INTO reaction_counts (target_type, target_id, reaction, count)
+SELECT ?, thread_id, reaction, COUNT(*) FROM thread_likes
+WHERE thread_id = ? AND deleted_at IS NULL AND reaction NOT IN ?
+GROUP BY thread_id, reaction`, LikeTargetThread, threadID, []string{models.ReactionLike, models.ReactionDownvote}).Error
+}
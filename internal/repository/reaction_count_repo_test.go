@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"exchangeapp/internal/models"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 不连接数据库，记录执行的语句及参数；不支持查询
+type recordingConn struct {
+	stmts []string
+}
+
+func (c *recordingConn) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.stmts = append(c.stmts, fmt.Sprintf("%s %v", query, args))
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func newRecordingDB(t *testing.T) (*gorm.DB, *recordingConn) {
+	t.Helper()
+	conn := &recordingConn{}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      conn,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	return db, conn
+}
+
+func TestRebuildThreadReactionCountsSkipsVotes(t *testing.T) {
+	db, conn := newRecordingDB(t)
+
+	if err := rebuildThreadReactionCounts(db, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conn.stmts) != 2 {
+		t.Fatalf("expected delete and insert, got %v", conn.stmts)
+	}
+	// 帖子同时有 like、downvote 与表情回应记录时，只有表情回应写入 reaction_counts
+	insert := conn.stmts[1]
+	want := fmt.Sprintf("reaction NOT IN (?,?)\nGROUP BY thread_id, reaction [%s 7 %s %s]",
+		LikeTargetThread, models.ReactionLike, models.ReactionDownvote)
+	if !strings.HasSuffix(insert, want) {
+		t.Fatalf("expected rebuild to exclude like and downvote, got %s", insert)
+	}
+}
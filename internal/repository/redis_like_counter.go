@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -27,28 +26,69 @@ end
 return 0
 `
 
-const likeDirtyKey = "thread:like:dirty"
-
+// 每种目标一个实例，key 按 target 区分：<target>:like:<id>、<target>:like:lock<id>、<target>:like:dirty、<target>:like:processing；
+// 踩数使用同样的结构，key 中的 like 换为 downvote
 type RedisLikeCounter struct {
-	rdb *redis.Client
+	rdb    *redis.Client
+	target string
+	kind   string
+}
+
+func NewRedisLikeCounter(rdb *redis.Client, target string) *RedisLikeCounter {
+	return &RedisLikeCounter{
+		rdb:    rdb,
+		target: target,
+		kind:   "like",
+	}
 }
 
-func NewRedisLikeCounter(rdb *redis.Client) *RedisLikeCounter {
+func NewRedisDownvoteCounter(rdb *redis.Client, target string) *RedisLikeCounter {
 	return &RedisLikeCounter{
-		rdb: rdb,
+		rdb:    rdb,
+		target: target,
+		kind:   "downvote",
 	}
 }
 
-func (c *RedisLikeCounter) key(threadID uint) string {
-	return fmt.Sprintf("thread:like:%d", threadID)
+func (c *RedisLikeCounter) key(id uint) string {
+	return fmt.Sprintf("%s:%s:%d", c.target, c.kind, id)
+}
+
+func (c *RedisLikeCounter) dirtyKey() string {
+	return c.target + ":" + c.kind + ":dirty"
+}
+
+func (c *RedisLikeCounter) processingKey() string {
+	return c.target + ":" + c.kind + ":processing"
+}
+
+// dirty 队列名，用于日志
+func (c *RedisLikeCounter) QueueName() string {
+	return c.target + ":" + c.kind
 }
 
-func (c *RedisLikeCounter) IncrementLikeCount(threadID uint, delta int) error {
-	return c.rdb.IncrBy(context.Background(), c.key(threadID), int64(delta)).Err()
+func (c *RedisLikeCounter) queue() dirtyQueue {
+	return dirtyQueue{rdb: c.rdb, dirtyKey: c.dirtyKey(), processingKey: c.processingKey()}
 }
 
-func (c *RedisLikeCounter) GetLikeCount(threadID uint) (int64, error) {
-	val, err := c.rdb.Get(context.Background(), c.key(threadID)).Int64()
+func (c *RedisLikeCounter) IncrementLikeCount(id uint, delta int) error {
+	return c.rdb.IncrBy(context.Background(), c.key(id), int64(delta)).Err()
+}
+
+// key 不存在时不写入并返回 false，由调用方回源后重试，避免从 0 开始计数覆盖库中的值
+func (c *RedisLikeCounter) incrementIfExists(id uint, delta int) (bool, error) {
+	_, err := c.rdb.Eval(context.Background(), incrIfExistsScript, []string{c.key(id)}, delta).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("更新点赞数失败：%w", err)
+	}
+	return true, nil
+}
+
+func (c *RedisLikeCounter) GetLikeCount(id uint) (int64, error) {
+	val, err := c.rdb.Get(context.Background(), c.key(id)).Int64()
 	if err == redis.Nil {
 		return 0, ErrLikeCountNotFound
 	}
@@ -58,37 +98,79 @@ func (c *RedisLikeCounter) GetLikeCount(threadID uint) (int64, error) {
 	return val, nil
 }
 
-func (c *RedisLikeCounter) setLikeCount(threadID uint, value int64) error {
-	return c.rdb.Set(context.Background(), c.key(threadID), value, 0).Err()
+func (c *RedisLikeCounter) GetLikeCounts(ids []uint) (map[uint]int64, error) {
+	res := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.Get(context.Background(), c.key(id))
+	}
+	if _, err := pipe.Exec(context.Background()); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("批量获取点赞数失败：%w", err)
+	}
+
+	for i, cmd := range cmds {
+		val, err := cmd.Int64()
+		if err != nil {
+			continue
+		}
+		res[ids[i]] = val
+	}
+	return res, nil
+}
+
+func (c *RedisLikeCounter) setLikeCount(id uint, value int64) error {
+	return c.rdb.Set(context.Background(), c.key(id), value, 0).Err()
 }
 
-func (c *RedisLikeCounter) lockKey(threadID uint) string {
-	return fmt.Sprintf("thread:like:lock%d", threadID)
+// 对账修正：只覆盖已存在的计数，不存在时下次读取会从库回源
+func (c *RedisLikeCounter) ResetLikeCount(id uint, value int64) error {
+	if err := c.rdb.SetXX(context.Background(), c.key(id), value, 0).Err(); err != nil {
+		return fmt.Errorf("修正点赞数失败：%w", err)
+	}
+	return nil
+}
+
+// 删除计数 key 并移出 dirty 队列，避免 worker 继续回写已删除内容的计数
+func (c *RedisLikeCounter) PurgeLikeCount(id uint) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.Del(context.Background(), c.key(id))
+	c.queue().remove(pipe, id)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return fmt.Errorf("清理点赞数失败：%w", err)
+	}
+	return nil
 }
 
-func (c *RedisLikeCounter) TryLockLikeCount(threadID uint, token string, ttl time.Duration) (bool, error) {
-	return c.rdb.SetNX(context.Background(), c.lockKey(threadID), token, ttl).Result()
+func (c *RedisLikeCounter) lockKey(id uint) string {
+	return fmt.Sprintf("%s:%s:lock%d", c.target, c.kind, id)
 }
 
-func (c *RedisLikeCounter) UnlockLikeCount(threadID uint, token string) error {
-	_, err := c.rdb.Eval(context.Background(), unlockIfMatchScript, []string{c.lockKey(threadID)}, token).Result()
+func (c *RedisLikeCounter) TryLockLikeCount(id uint, token string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(context.Background(), c.lockKey(id), token, ttl).Result()
+}
+
+func (c *RedisLikeCounter) UnlockLikeCount(id uint, token string) error {
+	_, err := c.rdb.Eval(context.Background(), unlockIfMatchScript, []string{c.lockKey(id)}, token).Result()
 	return err
 }
 
-func (c *RedisLikeCounter) MarkDirty(threadID uint) error {
-	return c.rdb.SAdd(context.Background(), likeDirtyKey, threadID).Err()
+func (c *RedisLikeCounter) MarkDirty(id uint) error {
+	return c.queue().mark(id)
 }
 
-func (c *RedisLikeCounter) PopDirty(limit int) ([]uint, error) {
-	vals, err := c.rdb.SPopN(context.Background(), likeDirtyKey, int64(limit)).Result()
-	if err != nil {
-		return nil, err
-	}
-	ids := make([]uint, 0, len(vals))
-	for _, v := range vals {
-		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
-			ids = append(ids, uint(id))
-		}
-	}
-	return ids, nil
+func (c *RedisLikeCounter) ClaimDirty(limit int, lease time.Duration) ([]uint, int64, error) {
+	return c.queue().claim(limit, lease)
+}
+
+func (c *RedisLikeCounter) AckDirty(ids []uint, token int64) error {
+	return c.queue().ack(ids, token)
+}
+
+func (c *RedisLikeCounter) PendingDirty() (int64, error) {
+	return c.queue().pending()
 }
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 哈希中的占位字段，标记已从库中加载过，避免没有任何回应的目标每次都回源
+const reactionLoadedField = "_"
+
+const hincrIfExistsScript = `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+end
+return nil
+`
+
+// like 以外的回应计数，每个目标一个哈希：<target>:reactions:<id>，dirty 队列为 <target>:reactions:dirty 与 <target>:reactions:processing
+type RedisReactionCounter struct {
+	rdb    *redis.Client
+	target string
+}
+
+func NewRedisReactionCounter(rdb *redis.Client, target string) *RedisReactionCounter {
+	return &RedisReactionCounter{
+		rdb:    rdb,
+		target: target,
+	}
+}
+
+func (c *RedisReactionCounter) key(id uint) string {
+	return fmt.Sprintf("%s:reactions:%d", c.target, id)
+}
+
+func (c *RedisReactionCounter) dirtyKey() string {
+	return c.target + ":reactions:dirty"
+}
+
+func (c *RedisReactionCounter) processingKey() string {
+	return c.target + ":reactions:processing"
+}
+
+func (c *RedisReactionCounter) queue() dirtyQueue {
+	return dirtyQueue{rdb: c.rdb, dirtyKey: c.dirtyKey(), processingKey: c.processingKey()}
+}
+
+func (c *RedisReactionCounter) lockKey(id uint) string {
+	return fmt.Sprintf("%s:reactions:lock%d", c.target, id)
+}
+
+// 哈希不存在时不写入并返回 false，由调用方先从库加载
+func (c *RedisReactionCounter) incrementReaction(id uint, reaction string, delta int) (bool, error) {
+	_, err := c.rdb.Eval(context.Background(), hincrIfExistsScript, []string{c.key(id)}, reaction, delta).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("更新回应数失败：%w", err)
+	}
+	return true, nil
+}
+
+func (c *RedisReactionCounter) GetReactionCounts(id uint) (map[string]int64, error) {
+	vals, err := c.rdb.HGetAll(context.Background(), c.key(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取回应数失败：%w", err)
+	}
+	if len(vals) == 0 {
+		return nil, ErrReactionCountNotFound
+	}
+	return parseReactionCounts(vals), nil
+}
+
+// 只返回缓存命中的目标
+func (c *RedisReactionCounter) GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error) {
+	res := make(map[uint]map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(context.Background(), c.key(id))
+	}
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return nil, fmt.Errorf("批量获取回应数失败：%w", err)
+	}
+
+	for i, cmd := range cmds {
+		vals, err := cmd.Result()
+		if err != nil || len(vals) == 0 {
+			continue
+		}
+		res[ids[i]] = parseReactionCounts(vals)
+	}
+	return res, nil
+}
+
+func parseReactionCounts(vals map[string]string) map[string]int64 {
+	counts := make(map[string]int64, len(vals))
+	for reaction, v := range vals {
+		if reaction == reactionLoadedField {
+			continue
+		}
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			counts[reaction] = n
+		}
+	}
+	return counts
+}
+
+func (c *RedisReactionCounter) setReactionCounts(id uint, counts map[string]int64) error {
+	fields := make([]interface{}, 0, len(counts)*2+2)
+	fields = append(fields, reactionLoadedField, 0)
+	for reaction, n := range counts {
+		fields = append(fields, reaction, n)
+	}
+	return c.rdb.HSet(context.Background(), c.key(id), fields...).Err()
+}
+
+func (c *RedisReactionCounter) PurgeReactionCounts(id uint) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.Del(context.Background(), c.key(id))
+	c.queue().remove(pipe, id)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return fmt.Errorf("清理回应数失败：%w", err)
+	}
+	return nil
+}
+
+func (c *RedisReactionCounter) tryLock(id uint, token string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(context.Background(), c.lockKey(id), token, ttl).Result()
+}
+
+func (c *RedisReactionCounter) unlock(id uint, token string) error {
+	_, err := c.rdb.Eval(context.Background(), unlockIfMatchScript, []string{c.lockKey(id)}, token).Result()
+	return err
+}
+
+func (c *RedisReactionCounter) MarkDirty(id uint) error {
+	return c.queue().mark(id)
+}
+
+func (c *RedisReactionCounter) ClaimDirty(limit int, lease time.Duration) ([]uint, int64, error) {
+	return c.queue().claim(limit, lease)
+}
+
+func (c *RedisReactionCounter) AckDirty(ids []uint, token int64) error {
+	return c.queue().ack(ids, token)
+}
+
+func (c *RedisReactionCounter) PendingDirty() (int64, error) {
+	return c.queue().pending()
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"exchangeapp/internal/models"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type ReplyLikeRepository interface {
+	Create(*models.ReplyLike) error
+	Delete(userID, replyID uint, reaction string) error
+	Exists(userID, replyID uint, reaction string) (bool, error)
+	ListReactions(userID, replyID uint) ([]string, error)
+	GetLikeCount(replyID uint) (int64, error)
+}
+
+type ReplyLikeRepo struct {
+	db *gorm.DB
+}
+
+func NewReplyLikeRepository(db *gorm.DB) ReplyLikeRepository {
+	return &ReplyLikeRepo{db: db}
+}
+
+func (r *ReplyLikeRepo) Create(l *models.ReplyLike) error {
+	if err := r.db.Create(l).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyLiked
+		}
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 {
+			return ErrAlreadyLiked
+		}
+		return fmt.Errorf("创建回复点赞失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ReplyLikeRepo) Delete(userID, replyID uint, reaction string) error {
+	res := r.db.Where("user_id = ? and reply_id = ? and reaction = ?", userID, replyID, reaction).
+		Delete(&models.ReplyLike{})
+	if res.Error != nil {
+		return fmt.Errorf("删除回复点赞失败：%w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrLikeNotFound
+	}
+	return nil
+}
+
+func (r *ReplyLikeRepo) Exists(userID, replyID uint, reaction string) (bool, error) {
+	var cnt int64
+	if err := r.db.Model(&models.ReplyLike{}).
+		Where("user_id = ? and reply_id = ? and reaction = ?", userID, replyID, reaction).
+		Count(&cnt).Error; err != nil {
+		return false, fmt.Errorf("查询回复点赞失败：%w", err)
+	}
+	return cnt > 0, nil
+}
+
+func (r *ReplyLikeRepo) ListReactions(userID, replyID uint) ([]string, error) {
+	var reactions []string
+	if err := r.db.Model(&models.ReplyLike{}).
+		Where("user_id = ? and reply_id = ?", userID, replyID).
+		Order("id asc").
+		Pluck("reaction", &reactions).Error; err != nil {
+		return nil, fmt.Errorf("查询回复回应失败：%w", err)
+	}
+	return reactions, nil
+}
+
+// 点赞数冗余在 replies.like_count，由后台任务从 Redis 回写
+func (r *ReplyLikeRepo) GetLikeCount(replyID uint) (int64, error) {
+	var res struct{ LikeCount int64 }
+	if err := r.db.Model(&models.Reply{}).
+		Select("like_count").
+		Where("id = ?", replyID).
+		Scan(&res).Error; err != nil {
+		return 0, fmt.Errorf("获取回复点赞数失败：%w", err)
+	}
+	return res.LikeCount, nil
+}
+
+func (r *ReplyLikeRepo) SetLikeCounts(counts map[uint]int64) error {
+	if err := updateVoteCounts(r.db.Unscoped().Model(&models.Reply{}), "like_count", counts); err != nil {
+		return fmt.Errorf("更新回复点赞数失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ReplyLikeRepo) GetDownvoteCount(replyID uint) (int64, error) {
+	var res struct{ DownvoteCount int64 }
+	if err := r.db.Model(&models.Reply{}).
+		Select("downvote_count").
+		Where("id = ?", replyID).
+		Scan(&res).Error; err != nil {
+		return 0, fmt.Errorf("获取回复踩数失败：%w", err)
+	}
+	return res.DownvoteCount, nil
+}
+
+func (r *ReplyLikeRepo) SetDownvoteCounts(counts map[uint]int64) error {
+	if err := updateVoteCounts(r.db.Unscoped().Model(&models.Reply{}), "downvote_count", counts); err != nil {
+		return fmt.Errorf("更新回复踩数失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ReplyLikeRepo) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *ReplyLikeRepo) WithTx(tx *gorm.DB) ReplyLikeRepository {
+	return &ReplyLikeRepo{db: tx}
+}
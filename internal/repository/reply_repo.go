@@ -11,13 +11,18 @@ import (
 
 type ReplyRepository interface {
 	Create(*models.Reply) error
-	ListByThreadID(threadID uint, limit, offset int) ([]models.Reply, error)
-	ListByThreadIDAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error)
+	ListByThreadID(threadID uint, desc bool, limit, offset int) ([]models.Reply, error)
+	ListByThreadIDAfter(threadID uint, desc bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error)
 	CountByThreadID(threadID uint) (int64, error)
+	CountByThreadIDBefore(threadID uint, desc bool, createdAt time.Time, id uint) (int64, error)
 	ListByUserID(userID uint, limit, offset int) ([]models.Reply, error)
 	ListByUserIDAfter(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error)
 	CountByUserID(userID uint) (int64, error)
 	FindByID(id uint) (*models.Reply, error)
+	FindWithDeleted(id uint) (*models.Reply, error)
+	ListChildren(parentID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error)
+	CountChildren(parentIDs []uint) (map[uint]int64, error)
+	FindLatestByThreadID(threadID uint) (*models.Reply, error)
 	Update(*models.Reply) error
 	DeleteByID(id uint) error
 }
@@ -37,10 +42,19 @@ func (r *ReplyRepo) Create(reply *models.Reply) error {
 	return nil
 }
 
-func (r *ReplyRepo) ListByThreadID(threadID uint, limit, offset int) ([]models.Reply, error) {
+// 按 (created_at, id) 排序，desc 为 true 时最新的在前
+func replyOrder(desc bool) (string, string) {
+	if desc {
+		return "created_at desc, id desc", "<"
+	}
+	return "created_at asc, id asc", ">"
+}
+
+func (r *ReplyRepo) ListByThreadID(threadID uint, desc bool, limit, offset int) ([]models.Reply, error) {
+	order, _ := replyOrder(desc)
 	var replies []models.Reply
 	if err := r.db.Where("thread_id = ?", threadID).
-		Order("created_at asc").
+		Order(order).
 		Limit(limit).Offset(offset).
 		Find(&replies).Error; err != nil {
 		return nil, fmt.Errorf("查询回复失败：%w", err)
@@ -48,11 +62,12 @@ func (r *ReplyRepo) ListByThreadID(threadID uint, limit, offset int) ([]models.R
 	return replies, nil
 }
 
-func (r *ReplyRepo) ListByThreadIDAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+func (r *ReplyRepo) ListByThreadIDAfter(threadID uint, desc bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+	order, cmp := replyOrder(desc)
 	var replies []models.Reply
 	if err := r.db.
-		Where("thread_id = ? and (created_at, id) < (?, ?)", threadID, cursorTime, cursorID).
-		Order("created_at desc, id desc").
+		Where("thread_id = ? and (created_at, id) "+cmp+" (?, ?)", threadID, cursorTime, cursorID).
+		Order(order).
 		Limit(limit).
 		Find(&replies).Error; err != nil {
 		return nil, fmt.Errorf("查询回复失败：%w", err)
@@ -70,6 +85,18 @@ func (r *ReplyRepo) CountByThreadID(threadID uint) (int64, error) {
 	return total, nil
 }
 
+// 统计在给定排序下排在 (createdAt, id) 之前的回复数
+func (r *ReplyRepo) CountByThreadIDBefore(threadID uint, desc bool, createdAt time.Time, id uint) (int64, error) {
+	_, cmp := replyOrder(!desc)
+	var total int64
+	if err := r.db.Model(&models.Reply{}).
+		Where("thread_id = ? and (created_at, id) "+cmp+" (?, ?)", threadID, createdAt, id).
+		Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计回复失败：%w", err)
+	}
+	return total, nil
+}
+
 func (r *ReplyRepo) ListByUserID(userID uint, limit, offset int) ([]models.Reply, error) {
 	var replies []models.Reply
 	if err := r.db.Where("user_id = ?", userID).
@@ -114,14 +141,87 @@ func (r *ReplyRepo) FindByID(id uint) (*models.Reply, error) {
 	return &rp, nil
 }
 
-func (r *ReplyRepo) Update(rp *models.Reply) error {
+// 包含已删除的回复，用于展示子回复仍在时的占位
+func (r *ReplyRepo) FindWithDeleted(id uint) (*models.Reply, error) {
+	var rp models.Reply
+	if err := r.db.Unscoped().First(&rp, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	return &rp, nil
+}
+
+// 按时间正序返回直接子回复；已删除但仍有未删除子回复的也会返回，由上层渲染为占位
+func (r *ReplyRepo) ListChildren(parentID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+	q := r.db.Unscoped().
+		Where("parent_id = ?", parentID).
+		Where("deleted_at IS NULL OR EXISTS (SELECT 1 FROM replies c WHERE c.parent_id = replies.id AND c.deleted_at IS NULL)")
+	if !cursorTime.IsZero() {
+		q = q.Where("(created_at, id) > (?, ?)", cursorTime, cursorID)
+	}
+	var replies []models.Reply
+	if err := q.Order("created_at asc, id asc").
+		Limit(limit).
+		Find(&replies).Error; err != nil {
+		return nil, fmt.Errorf("查询子回复失败：%w", err)
+	}
+	return replies, nil
+}
+
+// 统计未删除的直接子回复数，没有子回复的 ID 不出现在结果中
+func (r *ReplyRepo) CountChildren(parentIDs []uint) (map[uint]int64, error) {
+	res := make(map[uint]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return res, nil
+	}
+	var rows []struct {
+		ParentID uint
+		Total    int64
+	}
 	if err := r.db.Model(&models.Reply{}).
-		Where("id = ?", rp.ID).
+		Select("parent_id, COUNT(*) AS total").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计子回复失败：%w", err)
+	}
+	for _, row := range rows {
+		res[row.ParentID] = row.Total
+	}
+	return res, nil
+}
+
+func (r *ReplyRepo) FindLatestByThreadID(threadID uint) (*models.Reply, error) {
+	var rp models.Reply
+	if err := r.db.Where("thread_id = ?", threadID).
+		Order("created_at desc, id desc").
+		First(&rp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	return &rp, nil
+}
+
+func (r *ReplyRepo) Update(rp *models.Reply) error {
+	res := r.db.Model(&models.Reply{}).
+		Where("id = ? and version = ?", rp.ID, rp.Version).
 		Updates(map[string]interface{}{
-			"content": rp.Content,
-		}).Error; err != nil {
-		return fmt.Errorf("更新评论失败：%w", err)
+			"content":        rp.Content,
+			"content_format": rp.ContentFormat,
+			"content_html":   rp.ContentHTML,
+			"version":        gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return fmt.Errorf("更新评论失败：%w", res.Error)
 	}
+	if res.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	rp.Version++
 	return nil
 }
 
@@ -131,3 +231,7 @@ func (r *ReplyRepo) DeleteByID(id uint) error {
 	}
 	return nil
 }
+
+func (r *ReplyRepo) WithTx(tx *gorm.DB) ReplyRepository {
+	return &ReplyRepo{db: tx}
+}
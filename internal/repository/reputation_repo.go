@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"exchangeapp/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReputationRepository interface {
+	Append(e *models.ReputationEvent) error
+	Total(userID uint) (int64, error)
+	Leaderboard(since time.Time, limit int) ([]ReputationRank, error)
+}
+
+type ReputationRank struct {
+	UserID     uint
+	Username   string
+	Reputation int64
+}
+
+type ReputationRepo struct {
+	db *gorm.DB
+}
+
+func NewReputationRepository(db *gorm.DB) ReputationRepository {
+	return &ReputationRepo{db: db}
+}
+
+func (r *ReputationRepo) WithTx(tx *gorm.DB) ReputationRepository {
+	return &ReputationRepo{db: tx}
+}
+
+// 追加流水并在同一事务内更新 users.reputation
+func (r *ReputationRepo) Append(e *models.ReputationEvent) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(e).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ?", e.UserID).
+			UpdateColumn("reputation", gorm.Expr("reputation + ?", e.Delta)).Error
+	})
+	if err != nil {
+		return fmt.Errorf("记录声望失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ReputationRepo) Total(userID uint) (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Pluck("reputation", &total).Error; err != nil {
+		return 0, fmt.Errorf("查询声望失败：%w", err)
+	}
+	return total, nil
+}
+
+// since 为零值时按累计值排名，否则按 since 之后的流水合计排名；只返回大于 0 的用户
+func (r *ReputationRepo) Leaderboard(since time.Time, limit int) ([]ReputationRank, error) {
+	var rows []ReputationRank
+	var err error
+	if since.IsZero() {
+		err = r.db.Model(&models.User{}).
+			Select("id AS user_id, username, reputation").
+			Where("reputation > 0").
+			Order("reputation DESC, id ASC").
+			Limit(limit).
+			Scan(&rows).Error
+	} else {
+		err = r.db.Table("reputation_events AS e").
+			Select("e.user_id, u.username, SUM(e.delta) AS reputation").
+			Joins("JOIN users u ON u.id = e.user_id AND u.deleted_at IS NULL").
+			Where("e.created_at >= ?", since).
+			Group("e.user_id, u.username").
+			Having("SUM(e.delta) > 0").
+			Order("reputation DESC, e.user_id ASC").
+			Limit(limit).
+			Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询声望排行失败：%w", err)
+	}
+	return rows, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"exchangeapp/internal/models"
 	"fmt"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
@@ -12,11 +13,24 @@ import (
 var ErrAlreadyLiked = errors.New("已点赞")
 var ErrLikeNotFound = errors.New("未点赞")
 
+// 点赞列表中的一项，ID 为点赞记录 ID
+type ThreadLiker struct {
+	ID        uint
+	UserID    uint
+	Username  string
+	CreatedAt time.Time
+}
+
 type ThreadLikeRepository interface {
 	Create(*models.ThreadLike) error
-	Delete(userID, threadID uint) error
-	Exists(userID, threadID uint) (bool, error)
+	Delete(userID, threadID uint, reaction string) error
+	Exists(userID, threadID uint, reaction string) (bool, error)
+	ListReactions(userID, threadID uint) ([]string, error)
 	CountByThreadID(threadID uint) (int64, error)
+	ListLikers(threadID uint, limit int) ([]ThreadLiker, error)
+	ListLikersAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]ThreadLiker, error)
+	ListLikedThreadIDs(userID uint, threadIDs []uint) ([]uint, error)
+	ListRecentLikedThreadIDs(userID uint, limit int) ([]uint, error)
 }
 
 type ThreadLikeRepo struct {
@@ -41,9 +55,9 @@ func (r *ThreadLikeRepo) Create(t *models.ThreadLike) error {
 	return nil
 }
 
-func (r *ThreadLikeRepo) Delete(userID, threadID uint) error {
+func (r *ThreadLikeRepo) Delete(userID, threadID uint, reaction string) error {
 	res := r.db.Unscoped().
-		Where("user_id = ? and thread_id = ?", userID, threadID).
+		Where("user_id = ? and thread_id = ? and reaction = ?", userID, threadID, reaction).
 		Delete(&models.ThreadLike{})
 	if res.Error != nil {
 		return fmt.Errorf("删除帖子点赞失败：%w", res.Error)
@@ -54,26 +68,91 @@ func (r *ThreadLikeRepo) Delete(userID, threadID uint) error {
 	return nil
 }
 
-func (r *ThreadLikeRepo) Exists(userID, threadID uint) (bool, error) {
+func (r *ThreadLikeRepo) Exists(userID, threadID uint, reaction string) (bool, error) {
 	var cnt int64
 	if err := r.db.Model(&models.ThreadLike{}).
-		Where("user_id = ? and thread_id = ?", userID, threadID).
+		Where("user_id = ? and thread_id = ? and reaction = ?", userID, threadID, reaction).
 		Count(&cnt).Error; err != nil {
 		return false, fmt.Errorf("查询帖子点赞失败：%w", err)
 	}
 	return cnt > 0, nil
 }
 
+func (r *ThreadLikeRepo) ListReactions(userID, threadID uint) ([]string, error) {
+	var reactions []string
+	if err := r.db.Model(&models.ThreadLike{}).
+		Where("user_id = ? and thread_id = ?", userID, threadID).
+		Order("id asc").
+		Pluck("reaction", &reactions).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子回应失败：%w", err)
+	}
+	return reactions, nil
+}
+
 func (r *ThreadLikeRepo) CountByThreadID(threadID uint) (int64, error) {
 	var total int64
 	if err := r.db.Model(&models.ThreadLike{}).
-		Where("thread_id = ?", threadID).
+		Where("thread_id = ? and reaction = ?", threadID, models.ReactionLike).
 		Count(&total).Error; err != nil {
 		return 0, fmt.Errorf("查询帖子点赞失败：%w", err)
 	}
 	return total, nil
 }
 
+// 只列出 like，隐藏点赞的用户不出现
+func (r *ThreadLikeRepo) likersScope(threadID uint, limit int) *gorm.DB {
+	return r.db.Table("thread_likes l").
+		Select("l.id, l.user_id, u.username, l.created_at").
+		Joins("JOIN users u ON u.id = l.user_id AND u.deleted_at IS NULL AND u.hide_likes = ?", false).
+		Where("l.thread_id = ? AND l.reaction = ? AND l.deleted_at IS NULL", threadID, models.ReactionLike).
+		Order("l.created_at desc, l.id desc").
+		Limit(limit)
+}
+
+func (r *ThreadLikeRepo) ListLikers(threadID uint, limit int) ([]ThreadLiker, error) {
+	var rows []ThreadLiker
+	if err := r.likersScope(threadID, limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询点赞用户失败：%w", err)
+	}
+	return rows, nil
+}
+
+func (r *ThreadLikeRepo) ListLikersAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]ThreadLiker, error) {
+	var rows []ThreadLiker
+	if err := r.likersScope(threadID, limit).
+		Where("(l.created_at, l.id) < (?, ?)", cursorTime, cursorID).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询点赞用户失败：%w", err)
+	}
+	return rows, nil
+}
+
+// 返回 threadIDs 中该用户点过赞的帖子
+func (r *ThreadLikeRepo) ListLikedThreadIDs(userID uint, threadIDs []uint) ([]uint, error) {
+	if len(threadIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint
+	if err := r.db.Model(&models.ThreadLike{}).
+		Where("user_id = ? and thread_id IN ? and reaction = ?", userID, threadIDs, models.ReactionLike).
+		Pluck("thread_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子点赞失败：%w", err)
+	}
+	return ids, nil
+}
+
+func (r *ThreadLikeRepo) ListRecentLikedThreadIDs(userID uint, limit int) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.ThreadLike{}).
+		Where("user_id = ? and reaction = ?", userID, models.ReactionLike).
+		Order("created_at desc, id desc").
+		Limit(limit).
+		Pluck("thread_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子点赞失败：%w", err)
+	}
+	return ids, nil
+}
+
 func (r *ThreadLikeRepo) WithTx(tx *gorm.DB) ThreadLikeRepository {
 	return &ThreadLikeRepo{db: tx}
 }
@@ -13,7 +13,15 @@ type ThreadRepository interface {
 	Create(*models.Thread) error
 	List(limit, offset int) ([]models.Thread, error)
 	ListAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error)
+	ListByActivity(limit, offset int) ([]models.Thread, error)
+	ListByActivityAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error)
+	ListByScore(limit, offset int) ([]models.Thread, error)
+	ListByScoreAfter(cursorScore int64, cursorID uint, limit int) ([]models.Thread, error)
+	ListUnanswered(byActivity bool, limit, offset int) ([]models.Thread, error)
+	ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error)
+	CountUnanswered() (int64, error)
 	FindByID(id uint) (*models.Thread, error)
+	FindByIDs(ids []uint) ([]models.Thread, error)
 	Count() (int64, error)
 	ListByUserID(userID uint, limit, offset int) ([]models.Thread, error)
 	ListByUserIDAfter(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error)
@@ -22,10 +30,9 @@ type ThreadRepository interface {
 	DeleteByID(id uint) error
 	IncrementLikeCount(threadID uint, delta int) error
 	GetLikeCount(threadID uint) (int64, error)
-}
-
-type ThreadLikeCountWriter interface {
-	SetLikeCount(threadID uint, value int64) error
+	UpdateReplyStats(threadID uint, delta int, last *models.Reply) error
+	SetAcceptedReply(threadID, fromID, toID uint) (bool, error)
+	ClearAcceptedReply(threadID, replyID uint) error
 }
 
 type ThreadRepo struct {
@@ -37,6 +44,12 @@ func NewThreadRepository(db *gorm.DB) ThreadRepository {
 }
 
 func (r *ThreadRepo) Create(t *models.Thread) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	if t.LastActivityAt.IsZero() {
+		t.LastActivityAt = t.CreatedAt
+	}
 	if err := r.db.Create(t).Error; err != nil {
 		return fmt.Errorf("创建帖子失败：%w", err)
 	}
@@ -66,6 +79,98 @@ func (r *ThreadRepo) ListAfter(cursorTime time.Time, cursorID uint, limit int) (
 	return threads, nil
 }
 
+func (r *ThreadRepo) ListByActivity(limit, offset int) ([]models.Thread, error) {
+	var threads []models.Thread
+	if err := r.db.Order("last_activity_at desc, id desc").
+		Limit(limit).Offset(offset).
+		Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+func (r *ThreadRepo) ListByActivityAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	var threads []models.Thread
+	err := r.db.
+		Where("(last_activity_at, id) < (?, ?)", cursorTime, cursorID).
+		Order("last_activity_at desc, id desc").
+		Limit(limit).
+		Find(&threads).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+// 按净分排序，净分取自库中回写后的 score，比 Redis 中的实时计数略有延迟
+func (r *ThreadRepo) ListByScore(limit, offset int) ([]models.Thread, error) {
+	var threads []models.Thread
+	if err := r.db.Order("score desc, id desc").
+		Limit(limit).Offset(offset).
+		Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+func (r *ThreadRepo) ListByScoreAfter(cursorScore int64, cursorID uint, limit int) ([]models.Thread, error) {
+	var threads []models.Thread
+	err := r.db.
+		Where("(score, id) < (?, ?)", cursorScore, cursorID).
+		Order("score desc, id desc").
+		Limit(limit).
+		Find(&threads).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+// 尚未采纳答案的问答帖，byActivity 时按最后活跃时间排序
+func (r *ThreadRepo) ListUnanswered(byActivity bool, limit, offset int) ([]models.Thread, error) {
+	col := threadSortColumn(byActivity)
+	var threads []models.Thread
+	if err := r.db.Where("type = ? and accepted_reply_id = 0", models.ThreadTypeQuestion).
+		Order(col + " desc, id desc").
+		Limit(limit).Offset(offset).
+		Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+func (r *ThreadRepo) ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	col := threadSortColumn(byActivity)
+	var threads []models.Thread
+	err := r.db.
+		Where("type = ? and accepted_reply_id = 0", models.ThreadTypeQuestion).
+		Where("("+col+", id) < (?, ?)", cursorTime, cursorID).
+		Order(col + " desc, id desc").
+		Limit(limit).
+		Find(&threads).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+func (r *ThreadRepo) CountUnanswered() (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Thread{}).
+		Where("type = ? and accepted_reply_id = 0", models.ThreadTypeQuestion).
+		Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计帖子失败：%w", err)
+	}
+	return total, nil
+}
+
+func threadSortColumn(byActivity bool) string {
+	if byActivity {
+		return "last_activity_at"
+	}
+	return "created_at"
+}
+
 func (r *ThreadRepo) FindByID(id uint) (*models.Thread, error) {
 	var t models.Thread
 	if err := r.db.First(&t, id).Error; err != nil {
@@ -77,6 +182,17 @@ func (r *ThreadRepo) FindByID(id uint) (*models.Thread, error) {
 	return &t, nil
 }
 
+func (r *ThreadRepo) FindByIDs(ids []uint) ([]models.Thread, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var threads []models.Thread
+	if err := r.db.Where("id IN ?", ids).Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
 func (r *ThreadRepo) Count() (int64, error) {
 	var total int64
 	if err := r.db.Model(&models.Thread{}).Count(&total).Error; err != nil {
@@ -120,20 +236,46 @@ func (r *ThreadRepo) CountByUserID(userID uint) (int64, error) {
 	return total, nil
 }
 
+// 以 t.Version 作为期望版本做条件更新，成功后版本号加一
 func (r *ThreadRepo) Update(t *models.Thread) error {
-	if err := r.db.Model(&models.Thread{}).
-		Where("id = ?", t.ID).
+	res := r.db.Model(&models.Thread{}).
+		Where("id = ? and version = ?", t.ID, t.Version).
 		Updates(map[string]interface{}{
-			"title":   t.Title,
-			"content": t.Content,
-		}).Error; err != nil {
-		return fmt.Errorf("更新帖子失败：%w", err)
+			"title":          t.Title,
+			"content":        t.Content,
+			"content_format": t.ContentFormat,
+			"content_html":   t.ContentHTML,
+			"version":        gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return fmt.Errorf("更新帖子失败：%w", res.Error)
 	}
+	if res.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	t.Version++
 	return nil
 }
 
+// 帖子、回复、点赞使用同一个 deleted_at，恢复时据此只还原本次级联删除的数据
 func (r *ThreadRepo) DeleteByID(id uint) error {
-	if err := r.db.Delete(&models.Thread{}, id).Error; err != nil {
+	at := time.Now().Truncate(time.Millisecond)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Thread{}).
+			Where("id = ?", id).
+			UpdateColumn("deleted_at", at).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Reply{}).
+			Where("thread_id = ?", id).
+			UpdateColumn("deleted_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ThreadLike{}).
+			Where("thread_id = ?", id).
+			UpdateColumn("deleted_at", at).Error
+	})
+	if err != nil {
 		return fmt.Errorf("删除帖子失败：%w", err)
 	}
 	return nil
@@ -142,7 +284,10 @@ func (r *ThreadRepo) DeleteByID(id uint) error {
 func (r *ThreadRepo) IncrementLikeCount(threadID uint, delta int) error {
 	if err := r.db.Model(&models.Thread{}).
 		Where("id = ?", threadID).
-		UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error; err != nil {
+		UpdateColumns(map[string]interface{}{
+			"like_count": gorm.Expr("like_count + ?", delta),
+			"score":      gorm.Expr("score + ?", delta),
+		}).Error; err != nil {
 		return fmt.Errorf("更新点赞数失败：%w", err)
 	}
 	return nil
@@ -159,15 +304,73 @@ func (r *ThreadRepo) GetLikeCount(threadID uint) (int64, error) {
 	return res.LikeCount, nil
 }
 
-func (r *ThreadRepo) SetLikeCount(threadID uint, value int64) error {
+func (r *ThreadRepo) UpdateReplyStats(threadID uint, delta int, last *models.Reply) error {
+	updates := map[string]interface{}{
+		"reply_count":        gorm.Expr("GREATEST(reply_count + ?, 0)", delta),
+		"last_reply_at":      nil,
+		"last_reply_user_id": 0,
+	}
+	if last != nil {
+		updates["last_reply_at"] = last.CreatedAt
+		updates["last_reply_user_id"] = last.UserID
+	}
+	if delta > 0 && last != nil {
+		updates["last_activity_at"] = gorm.Expr("GREATEST(last_activity_at, ?)", last.CreatedAt)
+	}
 	if err := r.db.Model(&models.Thread{}).
 		Where("id = ?", threadID).
-		UpdateColumn("like_count", value).Error; err != nil {
+		UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("更新回复统计失败：%w", err)
+	}
+	return nil
+}
+
+// 仅当当前采纳的仍是 fromID 时改为 toID，toID 为 0 表示取消采纳；返回是否更新成功
+func (r *ThreadRepo) SetAcceptedReply(threadID, fromID, toID uint) (bool, error) {
+	res := r.db.Model(&models.Thread{}).
+		Where("id = ? and accepted_reply_id = ?", threadID, fromID).
+		UpdateColumn("accepted_reply_id", toID)
+	if res.Error != nil {
+		return false, fmt.Errorf("更新采纳答案失败：%w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// 仅当被采纳的正是该回复时清除，用于回复删除
+func (r *ThreadRepo) ClearAcceptedReply(threadID, replyID uint) error {
+	if err := r.db.Model(&models.Thread{}).
+		Where("id = ? and accepted_reply_id = ?", threadID, replyID).
+		UpdateColumn("accepted_reply_id", 0).Error; err != nil {
+		return fmt.Errorf("更新采纳答案失败：%w", err)
+	}
+	return nil
+}
+
+func (r *ThreadRepo) SetLikeCounts(counts map[uint]int64) error {
+	if err := updateVoteCounts(r.db.Model(&models.Thread{}), "like_count", counts); err != nil {
 		return fmt.Errorf("更新点赞数失败：%w", err)
 	}
 	return nil
 }
 
+func (r *ThreadRepo) GetDownvoteCount(threadID uint) (int64, error) {
+	var res struct{ DownvoteCount int64 }
+	if err := r.db.Model(&models.Thread{}).
+		Select("downvote_count").
+		Where("id = ?", threadID).
+		Scan(&res).Error; err != nil {
+		return 0, fmt.Errorf("获取踩数失败：%w", err)
+	}
+	return res.DownvoteCount, nil
+}
+
+func (r *ThreadRepo) SetDownvoteCounts(counts map[uint]int64) error {
+	if err := updateVoteCounts(r.db.Model(&models.Thread{}), "downvote_count", counts); err != nil {
+		return fmt.Errorf("更新踩数失败：%w", err)
+	}
+	return nil
+}
+
 func (r *ThreadRepo) Transaction(fn func(tx *gorm.DB) error) error {
 	return r.db.Transaction(fn)
 }
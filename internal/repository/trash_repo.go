@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"errors"
+	"exchangeapp/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TrashRepository interface {
+	ListDeletedThreads(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error)
+	ListDeletedReplies(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error)
+	FindThread(id uint) (*models.Thread, error)
+	FindReply(id uint) (*models.Reply, error)
+	RestoreThread(id uint) error
+	RestoreReply(id uint) error
+	PurgeThreadsBefore(before time.Time, limit int) (int, error)
+	PurgeRepliesBefore(before time.Time, limit int) (int, error)
+}
+
+type TrashRepo struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &TrashRepo{db: db}
+}
+
+// userID 为 0 时不按作者过滤；cursorTime 为零值时从最近删除的开始
+func (r *TrashRepo) deletedScope(userID uint, cursorTime time.Time, cursorID uint, limit int) *gorm.DB {
+	q := r.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	if !cursorTime.IsZero() {
+		q = q.Where("(deleted_at, id) < (?, ?)", cursorTime, cursorID)
+	}
+	return q.Order("deleted_at desc, id desc").Limit(limit)
+}
+
+func (r *TrashRepo) ListDeletedThreads(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	var threads []models.Thread
+	if err := r.deletedScope(userID, cursorTime, cursorID, limit).Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("查询回收站帖子失败：%w", err)
+	}
+	return threads, nil
+}
+
+func (r *TrashRepo) ListDeletedReplies(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+	var replies []models.Reply
+	if err := r.deletedScope(userID, cursorTime, cursorID, limit).Find(&replies).Error; err != nil {
+		return nil, fmt.Errorf("查询回收站回复失败：%w", err)
+	}
+	return replies, nil
+}
+
+func (r *TrashRepo) FindThread(id uint) (*models.Thread, error) {
+	var t models.Thread
+	if err := r.db.Unscoped().First(&t, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询帖子失败：%w", err)
+	}
+	return &t, nil
+}
+
+func (r *TrashRepo) FindReply(id uint) (*models.Reply, error) {
+	var rp models.Reply
+	if err := r.db.Unscoped().First(&rp, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询回复失败：%w", err)
+	}
+	return &rp, nil
+}
+
+// 连同删除帖子时一并删除的回复、点赞一起恢复，并按恢复后的记录重算 like_count、downvote_count 与 score
+func (r *TrashRepo) RestoreThread(id uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var t models.Thread
+		if err := tx.Unscoped().Select("id", "deleted_at").First(&t, id).Error; err != nil {
+			return err
+		}
+		if !t.DeletedAt.Valid {
+			return nil
+		}
+		at := t.DeletedAt.Time
+
+		if err := tx.Unscoped().Model(&models.Reply{}).
+			Where("thread_id = ? and deleted_at = ?", id, at).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.ThreadLike{}).
+			Where("thread_id = ? and deleted_at = ?", id, at).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		likes := tx.Model(&models.ThreadLike{}).Select("COUNT(*)").
+			Where("thread_id = ? and reaction = ?", id, models.ReactionLike)
+		downvotes := tx.Model(&models.ThreadLike{}).Select("COUNT(*)").
+			Where("thread_id = ? and reaction = ?", id, models.ReactionDownvote)
+		if err := tx.Unscoped().Model(&models.Thread{}).
+			Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{
+				"deleted_at":     nil,
+				"like_count":     likes,
+				"downvote_count": downvotes,
+				"score":          gorm.Expr("(?) - (?)", likes, downvotes),
+			}).Error; err != nil {
+			return err
+		}
+		return rebuildThreadReactionCounts(tx, id)
+	})
+	if err != nil {
+		return fmt.Errorf("恢复帖子失败：%w", err)
+	}
+	return nil
+}
+
+func (r *TrashRepo) RestoreReply(id uint) error {
+	if err := r.db.Unscoped().Model(&models.Reply{}).
+		Where("id = ?", id).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return fmt.Errorf("恢复回复失败：%w", err)
+	}
+	return nil
+}
+
+// 彻底删除过期帖子及其回复、点赞与回应、提及；附件只解除关联，交给 UploadGC 清理文件
+func (r *TrashRepo) PurgeThreadsBefore(before time.Time, limit int) (int, error) {
+	var ids []uint
+	if err := r.db.Unscoped().Model(&models.Thread{}).
+		Where("deleted_at IS NOT NULL and deleted_at < ?", before).
+		Order("deleted_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("查询过期帖子失败：%w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		replyIDs := tx.Unscoped().Model(&models.Reply{}).Select("id").Where("thread_id IN ?", ids)
+		if err := tx.Unscoped().Model(&models.Upload{}).
+			Where("thread_id IN ? OR reply_id IN (?)", ids, replyIDs).
+			UpdateColumns(map[string]interface{}{"thread_id": 0, "reply_id": 0}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("(source_type = ? and source_id IN ?) OR (source_type = ? and source_id IN (?))",
+			models.MentionSourceThread, ids, models.MentionSourceReply, replyIDs).
+			Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("reply_id IN (?)", replyIDs).Delete(&models.ReplyLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("(target_type = ? and target_id IN ?) OR (target_type = ? and target_id IN (?))",
+			LikeTargetThread, ids, LikeTargetReply, replyIDs).
+			Delete(&models.ReactionCount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("thread_id IN ?", ids).Delete(&models.Reply{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("thread_id IN ?", ids).Delete(&models.ThreadLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("thread_id IN ?", ids).Delete(&models.ThreadBookmark{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Thread{}, ids).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("清理过期帖子失败：%w", err)
+	}
+	return len(ids), nil
+}
+
+// 仍有子回复的回复保留为占位，等子回复都被清理后再删除
+func (r *TrashRepo) PurgeRepliesBefore(before time.Time, limit int) (int, error) {
+	var ids []uint
+	if err := r.db.Unscoped().Model(&models.Reply{}).
+		Where("deleted_at IS NOT NULL and deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM replies c WHERE c.parent_id = replies.id)").
+		Order("deleted_at asc").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("查询过期回复失败：%w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Upload{}).
+			Where("reply_id IN ?", ids).
+			UpdateColumn("reply_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("source_type = ? and source_id IN ?", models.MentionSourceReply, ids).
+			Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("reply_id IN ?", ids).Delete(&models.ReplyLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? and target_id IN ?", LikeTargetReply, ids).
+			Delete(&models.ReactionCount{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Reply{}, ids).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("清理过期回复失败：%w", err)
+	}
+	return len(ids), nil
+}
+
+func (r *TrashRepo) WithTx(tx *gorm.DB) TrashRepository {
+	return &TrashRepo{db: tx}
+}
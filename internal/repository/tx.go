@@ -14,6 +14,30 @@ type ThreadLikeRepoWithTx interface {
 	WithTx(tx *gorm.DB) ThreadLikeRepository
 }
 
-type ThreadLikeCounterWithTx interface {
-	WithTx(tx *gorm.DB) ThreadLikeCounter
+type LikeCounterWithTx interface {
+	WithTx(tx *gorm.DB) LikeCounter
+}
+
+type ReplyLikeRepoWithTx interface {
+	WithTx(tx *gorm.DB) ReplyLikeRepository
+}
+
+type ReplyRepoWithTx interface {
+	WithTx(tx *gorm.DB) ReplyRepository
+}
+
+type UploadRepoWithTx interface {
+	WithTx(tx *gorm.DB) UploadRepository
+}
+
+type TrashRepoWithTx interface {
+	WithTx(tx *gorm.DB) TrashRepository
+}
+
+type ModerationRepoWithTx interface {
+	WithTx(tx *gorm.DB) ModerationRepository
+}
+
+type ReputationRepoWithTx interface {
+	WithTx(tx *gorm.DB) ReputationRepository
 }
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"exchangeapp/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUploadNotAttachable = errors.New("附件不存在或已被使用")
+
+type UploadRepository interface {
+	Create(*models.Upload) error
+	AttachToThread(userID, threadID uint, ids []uint) error
+	AttachToReply(userID, replyID uint, ids []uint) error
+	ListByThreadID(threadID uint) ([]models.Upload, error)
+	ListByReplyIDs(replyIDs []uint) ([]models.Upload, error)
+	ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error)
+	DeleteUnattached(ids []uint) ([]uint, error)
+}
+
+type UploadRepo struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) UploadRepository {
+	return &UploadRepo{db: db}
+}
+
+func (r *UploadRepo) Create(u *models.Upload) error {
+	if err := r.db.Create(u).Error; err != nil {
+		return fmt.Errorf("创建附件失败：%w", err)
+	}
+	return nil
+}
+
+func (r *UploadRepo) attach(userID uint, ids []uint, column string, targetID uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	res := r.db.Model(&models.Upload{}).
+		Where("id in ? and user_id = ? and thread_id = 0 and reply_id = 0", ids, userID).
+		UpdateColumn(column, targetID)
+	if res.Error != nil {
+		return fmt.Errorf("关联附件失败：%w", res.Error)
+	}
+	if res.RowsAffected != int64(len(ids)) {
+		return ErrUploadNotAttachable
+	}
+	return nil
+}
+
+func (r *UploadRepo) AttachToThread(userID, threadID uint, ids []uint) error {
+	return r.attach(userID, ids, "thread_id", threadID)
+}
+
+func (r *UploadRepo) AttachToReply(userID, replyID uint, ids []uint) error {
+	return r.attach(userID, ids, "reply_id", replyID)
+}
+
+func (r *UploadRepo) ListByThreadID(threadID uint) ([]models.Upload, error) {
+	var ups []models.Upload
+	if err := r.db.Where("thread_id = ?", threadID).
+		Order("id asc").
+		Find(&ups).Error; err != nil {
+		return nil, fmt.Errorf("查询附件失败：%w", err)
+	}
+	return ups, nil
+}
+
+func (r *UploadRepo) ListByReplyIDs(replyIDs []uint) ([]models.Upload, error) {
+	if len(replyIDs) == 0 {
+		return nil, nil
+	}
+	var ups []models.Upload
+	if err := r.db.Where("reply_id in ?", replyIDs).
+		Order("id asc").
+		Find(&ups).Error; err != nil {
+		return nil, fmt.Errorf("查询附件失败：%w", err)
+	}
+	return ups, nil
+}
+
+func (r *UploadRepo) ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error) {
+	var ups []models.Upload
+	if err := r.db.Unscoped().
+		Where("thread_id = 0 and reply_id = 0 and created_at < ?", before).
+		Order("id asc").
+		Limit(limit).
+		Find(&ups).Error; err != nil {
+		return nil, fmt.Errorf("查询附件失败：%w", err)
+	}
+	return ups, nil
+}
+
+// 只删除其中仍未关联的附件，返回实际删除的 ID；加行锁与并发的关联互斥，
+// 关联在删除后提交时匹配不到记录，返回 ErrUploadNotAttachable
+func (r *UploadRepo) DeleteUnattached(ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var deleted []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Upload{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? and thread_id = 0 and reply_id = 0", ids).
+			Pluck("id", &deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		return tx.Unscoped().Delete(&models.Upload{}, deleted).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("删除附件失败：%w", err)
+	}
+	return deleted, nil
+}
+
+func (r *UploadRepo) WithTx(tx *gorm.DB) UploadRepository {
+	return &UploadRepo{db: tx}
+}
@@ -12,6 +12,9 @@ import (
 type UserRepository interface {
 	Create(*models.User) error
 	FindByUsername(username string) (*models.User, error)
+	FindByUsernames(usernames []string) ([]models.User, error)
+	FindByID(id uint) (*models.User, error)
+	UpdateHideLikes(id uint, hide bool) error
 }
 
 type UserRepo struct {
@@ -50,3 +53,36 @@ func (r *UserRepo) FindByUsername(username string) (*models.User, error) {
 	}
 	return &u, nil
 }
+
+func (r *UserRepo) FindByUsernames(usernames []string) ([]models.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	var users []models.User
+	if err := r.db.Select("id", "username").
+		Where("username IN ?", usernames).
+		Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	return users, nil
+}
+
+func (r *UserRepo) FindByID(id uint) (*models.User, error) {
+	var u models.User
+	if err := r.db.First(&u, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) UpdateHideLikes(id uint, hide bool) error {
+	if err := r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Update("hide_likes", hide).Error; err != nil {
+		return fmt.Errorf("更新用户设置失败：%w", err)
+	}
+	return nil
+}
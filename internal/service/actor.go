@@ -0,0 +1,16 @@
+package service
+
+import "exchangeapp/internal/models"
+
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+func (a Actor) IsModerator() bool {
+	return a.Role == models.RoleModerator
+}
+
+func (a Actor) CanManage(ownerID uint) bool {
+	return a.UserID == ownerID || a.IsModerator()
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"fmt"
+	"time"
+)
+
+type BookmarkService struct {
+	threadRepo   repository.ThreadRepository
+	bookmarkRepo repository.BookmarkRepository
+	counter      repository.LikeCounter
+}
+
+func NewBookmarkService(
+	threadRepo repository.ThreadRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	counter repository.LikeCounter,
+) *BookmarkService {
+	return &BookmarkService{
+		threadRepo:   threadRepo,
+		bookmarkRepo: bookmarkRepo,
+		counter:      counter,
+	}
+}
+
+func (s *BookmarkService) Add(userID, threadID uint) error {
+	t, err := s.threadRepo.FindByID(threadID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrThreadNotFound
+	}
+	return s.bookmarkRepo.Create(userID, threadID)
+}
+
+func (s *BookmarkService) Remove(userID, threadID uint) error {
+	return s.bookmarkRepo.Delete(userID, threadID)
+}
+
+func (s *BookmarkService) List(userID uint, size int) (*dto.BookmarkListResp, error) {
+	rows, err := s.bookmarkRepo.ListByUserID(userID, size)
+	if err != nil {
+		return nil, err
+	}
+	return s.listResp(rows, size), nil
+}
+
+func (s *BookmarkService) ListAfter(userID uint, cursorTime time.Time, cursorID uint, size int) (*dto.BookmarkListResp, error) {
+	rows, err := s.bookmarkRepo.ListByUserIDAfter(userID, cursorTime, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+	return s.listResp(rows, size), nil
+}
+
+func (s *BookmarkService) listResp(rows []repository.BookmarkedThread, size int) *dto.BookmarkListResp {
+	ts := make([]models.Thread, len(rows))
+	for i := range rows {
+		ts[i] = rows[i].Thread
+	}
+	summaries := threadSummaries(s.counter, nil, ts)
+
+	items := make([]dto.BookmarkItemResp, len(rows))
+	for i := range rows {
+		items[i] = dto.BookmarkItemResp{
+			ThreadSummaryResp: summaries[i],
+			BookmarkedAt:      rows[i].BookmarkedAt,
+		}
+	}
+
+	next := ""
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		next = fmt.Sprintf("%d_%d", last.BookmarkedAt.UnixNano(), last.ID)
+	}
+
+	return &dto.BookmarkListResp{
+		Items:      items,
+		Size:       size,
+		NextCursor: next,
+	}
+}
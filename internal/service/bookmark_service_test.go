@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+)
+
+type fakeBookmarkRepo struct {
+	exists  bool
+	rows    []repository.BookmarkedThread
+	created [][2]uint
+	deleted [][2]uint
+}
+
+func (f *fakeBookmarkRepo) Create(userID, threadID uint) error {
+	f.created = append(f.created, [2]uint{userID, threadID})
+	return nil
+}
+
+func (f *fakeBookmarkRepo) Delete(userID, threadID uint) error {
+	f.deleted = append(f.deleted, [2]uint{userID, threadID})
+	return nil
+}
+
+func (f *fakeBookmarkRepo) Exists(userID, threadID uint) (bool, error) {
+	return f.exists, nil
+}
+
+func (f *fakeBookmarkRepo) ListByUserID(userID uint, limit int) ([]repository.BookmarkedThread, error) {
+	return f.rows, nil
+}
+
+func (f *fakeBookmarkRepo) ListByUserIDAfter(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]repository.BookmarkedThread, error) {
+	return f.rows, nil
+}
+
+func TestBookmarkServiceAddThreadNotFound(t *testing.T) {
+	bookmarks := &fakeBookmarkRepo{}
+	svc := NewBookmarkService(&fakeThreadRepo{}, bookmarks, nil)
+
+	if err := svc.Add(1, 2); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected ErrThreadNotFound, got %v", err)
+	}
+	if len(bookmarks.created) != 0 {
+		t.Fatalf("expected no bookmark created")
+	}
+}
+
+func TestBookmarkServiceList(t *testing.T) {
+	at := time.Unix(200, 0)
+	repo := &fakeThreadRepo{}
+	bookmarks := &fakeBookmarkRepo{rows: []repository.BookmarkedThread{
+		{Thread: models.Thread{ID: 4, Title: "t", LikeCount: 2}, BookmarkedAt: at},
+	}}
+	svc := NewBookmarkService(repo, bookmarks, repo)
+
+	resp, err := svc.List(1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ID != 4 || resp.Items[0].LikeCount != 2 || !resp.Items[0].BookmarkedAt.Equal(at) {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+	if resp.NextCursor != "200000000000_4" {
+		t.Fatalf("unexpected next cursor: %s", resp.NextCursor)
+	}
+}
+
+func TestThreadServiceGetByIDBookmarked(t *testing.T) {
+	repo := &fakeThreadRepo{findResult: thread(1, 1)}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, &fakeBookmarkRepo{exists: true}, nil, nil, nil, nil)
+
+	resp, err := svc.GetByID(0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Bookmarked {
+		t.Fatalf("expected anonymous viewer not bookmarked")
+	}
+
+	resp, err = svc.GetByID(2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Bookmarked {
+		t.Fatalf("expected bookmarked for logged-in viewer")
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/pkg/render"
+)
+
+func renderContent(format, content string) (string, string, error) {
+	if format == "" {
+		format = render.FormatPlain
+	}
+	html, err := render.ToHTML(format, content)
+	if err != nil {
+		return "", "", err
+	}
+	return format, html, nil
+}
+
+// 旧数据没有 content_html 时按原格式现渲染一次
+func contentHTML(format, content, html string) string {
+	if html != "" || content == "" {
+		return html
+	}
+	out, err := render.ToHTML(format, content)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+func newThreadDetailResp(t *models.Thread, likeCount int64) *dto.ThreadDetailResp {
+	format := t.ContentFormat
+	if format == "" {
+		format = render.FormatPlain
+	}
+	return &dto.ThreadDetailResp{
+		ID:              t.ID,
+		Title:           t.Title,
+		Content:         t.Content,
+		ContentFormat:   format,
+		ContentHTML:     contentHTML(format, t.Content, t.ContentHTML),
+		UserID:          t.UserID,
+		Type:            threadType(t),
+		AcceptedReplyID: t.AcceptedReplyID,
+		LikeCount:       likeCount,
+		DownvoteCount:   t.DownvoteCount,
+		Score:           likeCount - t.DownvoteCount,
+		Version:         t.Version,
+		CreatedAt:       t.CreatedAt,
+	}
+}
+
+// 旧数据与旧缓存中没有类型，按普通讨论处理
+func threadType(t *models.Thread) string {
+	if t.Type == "" {
+		return models.ThreadTypeDiscussion
+	}
+	return t.Type
+}
+
+func newReplyResp(r *models.Reply) dto.ReplyResp {
+	format := r.ContentFormat
+	if format == "" {
+		format = render.FormatPlain
+	}
+	return dto.ReplyResp{
+		ID:            r.ID,
+		ThreadID:      r.ThreadID,
+		Content:       r.Content,
+		ContentFormat: format,
+		ContentHTML:   contentHTML(format, r.Content, r.ContentHTML),
+		Version:       r.Version,
+		UserID:        r.UserID,
+		ParentID:      r.ParentID,
+		Depth:         r.Depth,
+		LikeCount:     r.LikeCount,
+		DownvoteCount: r.DownvoteCount,
+		Score:         r.LikeCount - r.DownvoteCount,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// 已删除回复只保留位置信息，不暴露内容与作者
+func newReplyTombstone(r *models.Reply) dto.ReplyResp {
+	return dto.ReplyResp{
+		ID:        r.ID,
+		ThreadID:  r.ThreadID,
+		ParentID:  r.ParentID,
+		Depth:     r.Depth,
+		Deleted:   true,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func newReplyNode(r *models.Reply) dto.ReplyResp {
+	if r.DeletedAt.Valid {
+		return newReplyTombstone(r)
+	}
+	return newReplyResp(r)
+}
+
+// 点赞数优先取 Redis，一次批量查询；未命中时用库里的 like_count
+// downvotes 为 nil 时踩数取库里的 downvote_count
+func threadSummaries(counter, downvotes repository.LikeCounter, ts []models.Thread) []dto.ThreadSummaryResp {
+	ids := make([]uint, len(ts))
+	for i := range ts {
+		ids[i] = ts[i].ID
+	}
+
+	cached := cachedCounts(counter, ids)
+	cachedDownvotes := cachedCounts(downvotes, ids)
+
+	items := make([]dto.ThreadSummaryResp, len(ts))
+	for i := range ts {
+		likeCount, ok := cached[ts[i].ID]
+		if !ok {
+			likeCount = ts[i].LikeCount
+		}
+		downvoteCount, ok := cachedDownvotes[ts[i].ID]
+		if !ok {
+			downvoteCount = ts[i].DownvoteCount
+		}
+		items[i] = dto.ThreadSummaryResp{
+			ID:              ts[i].ID,
+			Title:           ts[i].Title,
+			UserID:          ts[i].UserID,
+			Type:            threadType(&ts[i]),
+			AcceptedReplyID: ts[i].AcceptedReplyID,
+			ReplyCount:      ts[i].ReplyCount,
+			LikeCount:       likeCount,
+			DownvoteCount:   downvoteCount,
+			Score:           likeCount - downvoteCount,
+			LastReplyAt:     ts[i].LastReplyAt,
+			LastReplyUserID: ts[i].LastReplyUserID,
+			LastActivityAt:  ts[i].LastActivityAt,
+			CreatedAt:       ts[i].CreatedAt,
+		}
+	}
+	return items
+}
+
+// 批量读取缓存中的计数，出错或不支持批量时返回 nil，由调用方用库里的值兜底
+func cachedCounts(counter repository.LikeCounter, ids []uint) map[uint]int64 {
+	bc, ok := counter.(repository.LikeBatchCounter)
+	if !ok || len(ids) == 0 {
+		return nil
+	}
+	counts, err := bc.GetLikeCounts(ids)
+	if err != nil {
+		return nil
+	}
+	return counts
+}
@@ -5,3 +5,25 @@ import "errors"
 var ErrForbidden = errors.New("无权限")
 var ErrThreadNotFound = errors.New("帖子不存在")
 var ErrReplyNotFound = errors.New("回复不存在")
+var ErrUploadTooLarge = errors.New("文件过大")
+var ErrUploadTypeNotAllowed = errors.New("不支持的文件类型")
+var ErrNotInTrash = errors.New("内容不在回收站")
+var ErrParentThreadDeleted = errors.New("所属帖子已删除")
+var ErrMergeSameThread = errors.New("不能合并到同一个帖子")
+var ErrInvalidReplies = errors.New("回复选择无效")
+var ErrInvalidParent = errors.New("父回复无效")
+var ErrReplyTooDeep = errors.New("回复层级过深")
+var ErrNotQuestion = errors.New("不是问答帖")
+var ErrAcceptedReplyChanged = errors.New("采纳答案已被修改")
+var ErrInvalidReaction = errors.New("不支持的回应")
+var ErrDownvoteDisabled = errors.New("未开启踩")
+var ErrReputationTooLow = errors.New("声望不足")
+var ErrInvalidWindow = errors.New("排行时间范围无效")
+
+type VersionConflictError struct {
+	Current uint
+}
+
+func (e *VersionConflictError) Error() string {
+	return "内容已被修改"
+}
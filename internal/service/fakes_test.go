@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
 
 	"gorm.io/gorm"
 )
@@ -23,11 +26,19 @@ type fakeThreadRepo struct {
 	updateErr error
 	deleteErr error
 
+	created  *models.Thread
 	updated  *models.Thread
 	deleteID uint
+
+	replyDeltas []int
+	lastReply   *models.Reply
+
+	accepted       []uint
+	unansweredArgs []bool
 }
 
-func (f *fakeThreadRepo) Create(*models.Thread) error {
+func (f *fakeThreadRepo) Create(t *models.Thread) error {
+	f.created = t
 	return nil
 }
 
@@ -42,6 +53,28 @@ func (f *fakeThreadRepo) ListAfter(cursorTime time.Time, cursorID uint, limit in
 	return f.listResult, f.listErr
 }
 
+func (f *fakeThreadRepo) ListByActivity(limit, offset int) ([]models.Thread, error) {
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByActivityAfter(cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	if f.listAfterResult != nil || f.listAfterErr != nil {
+		return f.listAfterResult, f.listAfterErr
+	}
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByScore(limit, offset int) ([]models.Thread, error) {
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListByScoreAfter(cursorScore int64, cursorID uint, limit int) ([]models.Thread, error) {
+	if f.listAfterResult != nil || f.listAfterErr != nil {
+		return f.listAfterResult, f.listAfterErr
+	}
+	return f.listResult, f.listErr
+}
+
 func (f *fakeThreadRepo) Count() (int64, error) {
 	return f.countResult, f.countErr
 }
@@ -65,6 +98,21 @@ func (f *fakeThreadRepo) FindByID(uint) (*models.Thread, error) {
 	return f.findResult, f.findErr
 }
 
+func (f *fakeThreadRepo) FindByIDs(ids []uint) ([]models.Thread, error) {
+	if f.findErr != nil {
+		return nil, f.findErr
+	}
+	var res []models.Thread
+	for _, id := range ids {
+		for _, t := range f.listResult {
+			if t.ID == id {
+				res = append(res, t)
+			}
+		}
+	}
+	return res, nil
+}
+
 func (f *fakeThreadRepo) Update(t *models.Thread) error {
 	f.updated = t
 	return f.updateErr
@@ -83,12 +131,55 @@ func (f *fakeThreadRepo) GetLikeCount(threadID uint) (int64, error) {
 	return 0, nil
 }
 
+func (f *fakeThreadRepo) ListUnanswered(byActivity bool, limit, offset int) ([]models.Thread, error) {
+	f.unansweredArgs = append(f.unansweredArgs, byActivity)
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	f.unansweredArgs = append(f.unansweredArgs, byActivity)
+	return f.listResult, f.listErr
+}
+
+func (f *fakeThreadRepo) CountUnanswered() (int64, error) {
+	return f.countResult, f.countErr
+}
+
+// 模拟条件更新：当前采纳的不是 fromID 时不更新
+func (f *fakeThreadRepo) SetAcceptedReply(threadID, fromID, toID uint) (bool, error) {
+	if f.findResult == nil || f.findResult.AcceptedReplyID != fromID {
+		return false, nil
+	}
+	f.findResult.AcceptedReplyID = toID
+	f.accepted = append(f.accepted, toID)
+	return true, nil
+}
+
+func (f *fakeThreadRepo) ClearAcceptedReply(threadID, replyID uint) error {
+	return nil
+}
+
+func (f *fakeThreadRepo) UpdateReplyStats(threadID uint, delta int, last *models.Reply) error {
+	f.replyDeltas = append(f.replyDeltas, delta)
+	f.lastReply = last
+	return nil
+}
+
 type fakeThreadLikeRepo struct {
 	createErr error
 	deleteErr error
 	existsErr error
 	exists    bool
 
+	reactions []string
+	likers    []repository.ThreadLiker
+
+	likersAfter []uint
+
+	likedIDs     []uint
+	likedQueries [][]uint
+	recentCalls  int
+
 	created *models.ThreadLike
 	deleted bool
 }
@@ -98,19 +189,53 @@ func (f *fakeThreadLikeRepo) Create(t *models.ThreadLike) error {
 	return f.createErr
 }
 
-func (f *fakeThreadLikeRepo) Delete(userID, threadID uint) error {
+func (f *fakeThreadLikeRepo) Delete(userID, threadID uint, reaction string) error {
 	f.deleted = true
 	return f.deleteErr
 }
 
-func (f *fakeThreadLikeRepo) Exists(userID, threadID uint) (bool, error) {
+func (f *fakeThreadLikeRepo) Exists(userID, threadID uint, reaction string) (bool, error) {
 	return f.exists, f.existsErr
 }
 
+func (f *fakeThreadLikeRepo) ListReactions(userID, threadID uint) ([]string, error) {
+	return f.reactions, nil
+}
+
 func (f *fakeThreadLikeRepo) CountByThreadID(threadID uint) (int64, error) {
 	return 0, nil
 }
 
+func (f *fakeThreadLikeRepo) ListLikers(threadID uint, limit int) ([]repository.ThreadLiker, error) {
+	return f.likers, nil
+}
+
+func (f *fakeThreadLikeRepo) ListLikedThreadIDs(userID uint, threadIDs []uint) ([]uint, error) {
+	f.likedQueries = append(f.likedQueries, threadIDs)
+	var res []uint
+	for _, id := range threadIDs {
+		for _, liked := range f.likedIDs {
+			if id == liked {
+				res = append(res, id)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeThreadLikeRepo) ListRecentLikedThreadIDs(userID uint, limit int) ([]uint, error) {
+	f.recentCalls++
+	if len(f.likedIDs) > limit {
+		return f.likedIDs[:limit], nil
+	}
+	return f.likedIDs, nil
+}
+
+func (f *fakeThreadLikeRepo) ListLikersAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]repository.ThreadLiker, error) {
+	f.likersAfter = append(f.likersAfter, cursorID)
+	return f.likers, nil
+}
+
 func gormModel(id uint) gorm.Model {
 	return gorm.Model{
 		ID: id,
@@ -131,3 +256,83 @@ func reply(id, userID, threadID uint) *models.Reply {
 		ThreadID: threadID,
 	}
 }
+
+type fakeUploadRepo struct {
+	createErr error
+	attachErr error
+
+	byThread []models.Upload
+	byReply  []models.Upload
+
+	created        *models.Upload
+	attachedThread uint
+	attachedReply  uint
+	attachedIDs    []uint
+}
+
+func (f *fakeUploadRepo) Create(u *models.Upload) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	u.ID = 1
+	f.created = u
+	return nil
+}
+
+func (f *fakeUploadRepo) AttachToThread(userID, threadID uint, ids []uint) error {
+	f.attachedThread = threadID
+	f.attachedIDs = ids
+	return f.attachErr
+}
+
+func (f *fakeUploadRepo) AttachToReply(userID, replyID uint, ids []uint) error {
+	f.attachedReply = replyID
+	f.attachedIDs = ids
+	return f.attachErr
+}
+
+func (f *fakeUploadRepo) ListByThreadID(threadID uint) ([]models.Upload, error) {
+	return f.byThread, nil
+}
+
+func (f *fakeUploadRepo) ListByReplyIDs(replyIDs []uint) ([]models.Upload, error) {
+	return f.byReply, nil
+}
+
+func (f *fakeUploadRepo) ListUnattachedBefore(before time.Time, limit int) ([]models.Upload, error) {
+	return nil, nil
+}
+
+func (f *fakeUploadRepo) DeleteUnattached(ids []uint) ([]uint, error) {
+	return ids, nil
+}
+
+type fakeBlobStore struct {
+	putErr  error
+	puts    map[string][]byte
+	deleted []string
+}
+
+func (f *fakeBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	if f.putErr != nil {
+		return f.putErr
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if f.puts == nil {
+		f.puts = make(map[string][]byte)
+	}
+	f.puts[key] = b
+	return nil
+}
+
+func (f *fakeBlobStore) Delete(_ context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *fakeBlobStore) URL(key string) string {
+	return "/uploads/" + key
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/repository"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrReconcileRunning = errors.New("对账正在进行")
+
+const maxReportedDrifts = 100
+
+// 以 thread_likes 行数为准，修正 Redis 计数与 threads.like_count
+type LikeReconcileService struct {
+	counts  repository.LikeReconcileRepository
+	cache   repository.LikeCountResetter
+	batch   int
+	running atomic.Bool
+
+	mu     sync.Mutex
+	status dto.LikeReconcileStatusResp
+}
+
+func NewLikeReconcileService(
+	counts repository.LikeReconcileRepository,
+	cache repository.LikeCountResetter,
+	batch int) *LikeReconcileService {
+	if batch <= 0 {
+		batch = 200
+	}
+	return &LikeReconcileService{
+		counts: counts,
+		cache:  cache,
+		batch:  batch,
+	}
+}
+
+// 在后台启动一次对账并立即返回，ctx 控制对账的生命周期，不应传入请求的 context
+func (s *LikeReconcileService) Trigger(ctx context.Context, actor Actor) (*dto.LikeReconcileStatusResp, error) {
+	if !actor.IsModerator() {
+		return nil, ErrForbidden
+	}
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrReconcileRunning
+	}
+	s.start()
+	go func() {
+		defer s.running.Store(false)
+		s.finish(s.reconcile(ctx))
+	}()
+	return s.snapshot(), nil
+}
+
+// 当前或最近一次对账的状态与报告
+func (s *LikeReconcileService) Status(actor Actor) (*dto.LikeReconcileStatusResp, error) {
+	if !actor.IsModerator() {
+		return nil, ErrForbidden
+	}
+	return s.snapshot(), nil
+}
+
+// 同一时间只允许一次对账；计数与修正之间的并发点赞可能留下少量偏差，由下一轮修正
+func (s *LikeReconcileService) Reconcile(ctx context.Context) (*dto.LikeReconcileResp, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrReconcileRunning
+	}
+	defer s.running.Store(false)
+
+	s.start()
+	resp, err := s.reconcile(ctx)
+	s.finish(resp, err)
+	return resp, err
+}
+
+func (s *LikeReconcileService) start() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = dto.LikeReconcileStatusResp{Running: true, StartedAt: &now}
+}
+
+func (s *LikeReconcileService) finish(resp *dto.LikeReconcileResp, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	s.status.FinishedAt = &now
+	s.status.Report = resp
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+}
+
+func (s *LikeReconcileService) snapshot() *dto.LikeReconcileStatusResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	return &status
+}
+
+func (s *LikeReconcileService) reconcile(ctx context.Context) (*dto.LikeReconcileResp, error) {
+	resp := &dto.LikeReconcileResp{Drifts: []dto.LikeDriftResp{}}
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return resp, err
+		}
+		rows, err := s.counts.ListThreadLikeCounts(afterID, s.batch)
+		if err != nil {
+			return resp, err
+		}
+		if len(rows) == 0 {
+			return resp, nil
+		}
+		if err := s.reconcileBatch(rows, resp); err != nil {
+			return resp, err
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (s *LikeReconcileService) reconcileBatch(rows []repository.ThreadLikeCount, resp *dto.LikeReconcileResp) error {
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	cached, err := s.cache.GetLikeCounts(ids)
+	if err != nil {
+		return err
+	}
+	actuals, err := s.counts.CountThreadLikes(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		actual := actuals[row.ID]
+		resp.Checked++
+
+		cachedVal, hasCache := cached[row.ID]
+		if actual == row.LikeCount && (!hasCache || cachedVal == actual) {
+			continue
+		}
+
+		// 先改 Redis，避免 dirty 回写把旧值再写回库
+		if hasCache && cachedVal != actual {
+			if err := s.cache.ResetLikeCount(row.ID, actual); err != nil {
+				return err
+			}
+		}
+		if row.LikeCount != actual {
+			if err := s.counts.SetLikeCount(row.ID, actual); err != nil {
+				return err
+			}
+		}
+
+		resp.Drifted++
+		if len(resp.Drifts) < maxReportedDrifts {
+			drift := dto.LikeDriftResp{ThreadID: row.ID, Actual: actual, Stored: row.LikeCount}
+			if hasCache {
+				drift.Cached = &cachedVal
+			}
+			resp.Drifts = append(resp.Drifts, drift)
+		}
+	}
+	return nil
+}
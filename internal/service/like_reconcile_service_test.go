@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"exchangeapp/internal/repository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeReconcileRepo struct {
+	rows   []repository.ThreadLikeCount
+	counts map[uint]int64
+	set    map[uint]int64
+}
+
+func (f *fakeReconcileRepo) CountThreadLikes(ids []uint) (map[uint]int64, error) {
+	res := make(map[uint]int64)
+	for _, id := range ids {
+		if v, ok := f.counts[id]; ok {
+			res[id] = v
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeReconcileRepo) ListThreadLikeCounts(afterID uint, limit int) ([]repository.ThreadLikeCount, error) {
+	var res []repository.ThreadLikeCount
+	for _, row := range f.rows {
+		if row.ID > afterID && len(res) < limit {
+			res = append(res, row)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeReconcileRepo) SetLikeCount(id uint, value int64) error {
+	if f.set == nil {
+		f.set = make(map[uint]int64)
+	}
+	f.set[id] = value
+	return nil
+}
+
+type fakeLikeResetter struct {
+	cached map[uint]int64
+	reset  map[uint]int64
+}
+
+func (f *fakeLikeResetter) GetLikeCounts(ids []uint) (map[uint]int64, error) {
+	res := make(map[uint]int64)
+	for _, id := range ids {
+		if v, ok := f.cached[id]; ok {
+			res[id] = v
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeLikeResetter) ResetLikeCount(id uint, value int64) error {
+	if f.reset == nil {
+		f.reset = make(map[uint]int64)
+	}
+	f.reset[id] = value
+	return nil
+}
+
+func TestLikeReconcileServiceReconcile(t *testing.T) {
+	repo := &fakeReconcileRepo{
+		rows: []repository.ThreadLikeCount{
+			{ID: 1, LikeCount: 2},
+			{ID: 2, LikeCount: 5},
+			{ID: 3, LikeCount: 1},
+			{ID: 4, LikeCount: 1},
+		},
+		counts: map[uint]int64{1: 2, 2: 3, 3: 1},
+	}
+	cache := &fakeLikeResetter{cached: map[uint]int64{1: 2, 2: 4, 3: 7}}
+	svc := NewLikeReconcileService(repo, cache, 2)
+
+	resp, err := svc.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Checked != 4 || resp.Drifted != 3 {
+		t.Fatalf("expected 4 checked and 3 drifted, got %d %d", resp.Checked, resp.Drifted)
+	}
+	// 帖子 4 没有点赞行，分组计数中缺失即为 0
+	if want := map[uint]int64{2: 3, 4: 0}; !reflect.DeepEqual(repo.set, want) {
+		t.Fatalf("expected db fixes %v, got %v", want, repo.set)
+	}
+	if want := map[uint]int64{2: 3, 3: 1}; !reflect.DeepEqual(cache.reset, want) {
+		t.Fatalf("expected cache fixes %v, got %v", want, cache.reset)
+	}
+	if d := resp.Drifts[0]; d.ThreadID != 2 || d.Actual != 3 || d.Stored != 5 || d.Cached == nil || *d.Cached != 4 {
+		t.Fatalf("unexpected drift: %+v", d)
+	}
+}
+
+func TestLikeReconcileServiceTrigger(t *testing.T) {
+	repo := &fakeReconcileRepo{rows: []repository.ThreadLikeCount{{ID: 1, LikeCount: 3}}}
+	svc := NewLikeReconcileService(repo, &fakeLikeResetter{}, 0)
+	mod := Actor{UserID: 1, Role: "moderator"}
+
+	if _, err := svc.Trigger(context.Background(), Actor{UserID: 1}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.Status(Actor{UserID: 1}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	svc.running.Store(true)
+	if _, err := svc.Trigger(context.Background(), mod); !errors.Is(err, ErrReconcileRunning) {
+		t.Fatalf("expected ErrReconcileRunning, got %v", err)
+	}
+	svc.running.Store(false)
+
+	resp, err := svc.Trigger(context.Background(), mod)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Running || resp.StartedAt == nil {
+		t.Fatalf("expected running status, got %+v", resp)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		status, err := svc.Status(mod)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !status.Running {
+			if status.Report == nil || status.Report.Drifted != 1 || status.FinishedAt == nil {
+				t.Fatalf("expected finished report with 1 drift, got %+v", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("reconcile did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"log"
+
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/pkg/mention"
+)
+
+// 解析并保存内容中的 @提及 与 >>引用；为 nil 时不解析，读取时返回空列表
+type MentionService struct {
+	userRepo    repository.UserRepository
+	mentionRepo repository.MentionRepository
+}
+
+func NewMentionService(userRepo repository.UserRepository, mentionRepo repository.MentionRepository) *MentionService {
+	return &MentionService{userRepo: userRepo, mentionRepo: mentionRepo}
+}
+
+// 写入主体内容后调用；提及记录可由内容重新推导，因此不与主体写入放在同一事务。
+// 主体已提交，失败时只记录日志，不让请求失败，避免客户端重试造成重复发布
+func (m *MentionService) sync(sourceType string, sourceID, threadID, authorID uint, content string) {
+	if m == nil {
+		return
+	}
+	if err := m.save(sourceType, sourceID, threadID, authorID, content); err != nil {
+		log.Printf("同步提及失败（%s %d）：%v", sourceType, sourceID, err)
+	}
+}
+
+func (m *MentionService) save(sourceType string, sourceID, threadID, authorID uint, content string) error {
+	refs := mention.Parse(content)
+
+	users, err := m.userRepo.FindByUsernames(refs.Usernames)
+	if err != nil {
+		return err
+	}
+	var rows []models.Mention
+	for _, u := range users {
+		if u.ID == authorID {
+			continue
+		}
+		rows = append(rows, models.Mention{
+			Kind:     models.MentionKindUser,
+			TargetID: u.ID,
+			AuthorID: authorID,
+			Username: u.Username,
+		})
+	}
+
+	quoted, err := m.mentionRepo.FilterReplyIDs(threadID, refs.ReplyIDs)
+	if err != nil {
+		return err
+	}
+	for _, id := range quoted {
+		if sourceType == models.MentionSourceReply && id == sourceID {
+			continue
+		}
+		rows = append(rows, models.Mention{
+			Kind:     models.MentionKindQuote,
+			TargetID: id,
+			AuthorID: authorID,
+		})
+	}
+
+	return m.mentionRepo.Sync(sourceType, sourceID, rows)
+}
+
+type contentRefs struct {
+	mentions []dto.MentionResp
+	quotes   []dto.QuoteResp
+}
+
+func (m *MentionService) load(sourceType string, ids []uint) (map[uint]*contentRefs, error) {
+	res := make(map[uint]*contentRefs, len(ids))
+	if m == nil || len(ids) == 0 {
+		return res, nil
+	}
+	rows, err := m.mentionRepo.ListBySources(sourceType, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		refs, ok := res[row.SourceID]
+		if !ok {
+			refs = &contentRefs{}
+			res[row.SourceID] = refs
+		}
+		switch row.Kind {
+		case models.MentionKindUser:
+			refs.mentions = append(refs.mentions, dto.MentionResp{UserID: row.TargetID, Username: row.Username})
+		case models.MentionKindQuote:
+			refs.quotes = append(refs.quotes, dto.QuoteResp{ReplyID: row.TargetID})
+		}
+	}
+	return res, nil
+}
+
+func (m *MentionService) fillReplies(items []dto.ReplyResp) error {
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		items[i].Mentions = []dto.MentionResp{}
+		items[i].Quotes = []dto.QuoteResp{}
+		if !items[i].Deleted {
+			ids = append(ids, items[i].ID)
+		}
+	}
+	byID, err := m.load(models.MentionSourceReply, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if refs, ok := byID[items[i].ID]; ok && !items[i].Deleted {
+			items[i].Mentions = append(items[i].Mentions, refs.mentions...)
+			items[i].Quotes = append(items[i].Quotes, refs.quotes...)
+		}
+	}
+	return nil
+}
+
+func (m *MentionService) fillThread(resp *dto.ThreadDetailResp) error {
+	resp.Mentions = []dto.MentionResp{}
+	resp.Quotes = []dto.QuoteResp{}
+	byID, err := m.load(models.MentionSourceThread, []uint{resp.ID})
+	if err != nil {
+		return err
+	}
+	if refs, ok := byID[resp.ID]; ok {
+		resp.Mentions = append(resp.Mentions, refs.mentions...)
+		resp.Quotes = append(resp.Quotes, refs.quotes...)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"testing"
+)
+
+type fakeUserRepo struct {
+	users []models.User
+}
+
+func (f *fakeUserRepo) Create(*models.User) error {
+	return nil
+}
+
+func (f *fakeUserRepo) FindByUsername(string) (*models.User, error) {
+	return nil, nil
+}
+
+func (f *fakeUserRepo) FindByID(id uint) (*models.User, error) {
+	for i := range f.users {
+		if f.users[i].ID == id {
+			return &f.users[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeUserRepo) UpdateHideLikes(uint, bool) error {
+	return nil
+}
+
+func (f *fakeUserRepo) FindByUsernames(names []string) ([]models.User, error) {
+	var res []models.User
+	for _, u := range f.users {
+		for _, n := range names {
+			if u.Username == n {
+				res = append(res, u)
+			}
+		}
+	}
+	return res, nil
+}
+
+type fakeMentionRepo struct {
+	replyIDs []uint
+	rows     []models.Mention
+	syncErr  error
+}
+
+func (f *fakeMentionRepo) Sync(sourceType string, sourceID uint, mentions []models.Mention) error {
+	if f.syncErr != nil {
+		return f.syncErr
+	}
+	kept := f.rows[:0]
+	for _, m := range f.rows {
+		if m.SourceType != sourceType || m.SourceID != sourceID {
+			kept = append(kept, m)
+		}
+	}
+	for _, m := range mentions {
+		m.SourceType = sourceType
+		m.SourceID = sourceID
+		kept = append(kept, m)
+	}
+	f.rows = kept
+	return nil
+}
+
+func (f *fakeMentionRepo) ListBySources(sourceType string, ids []uint) ([]models.Mention, error) {
+	var res []models.Mention
+	for _, m := range f.rows {
+		for _, id := range ids {
+			if m.SourceType == sourceType && m.SourceID == id {
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeMentionRepo) FilterReplyIDs(threadID uint, ids []uint) ([]uint, error) {
+	var res []uint
+	for _, id := range ids {
+		for _, known := range f.replyIDs {
+			if id == known {
+				res = append(res, id)
+			}
+		}
+	}
+	return res, nil
+}
+
+func TestReplyServiceCreateResolvesMentions(t *testing.T) {
+	users := &fakeUserRepo{users: []models.User{
+		{Username: "alice"},
+		{Username: "bob"},
+	}}
+	users.users[0].ID = 7
+	users.users[1].ID = 2
+	mentions := &fakeMentionRepo{replyIDs: []uint{3}}
+	svc := NewReplyService(&fakeReplyRepo{}, &fakeThreadRepo{findResult: thread(1, 1)}, nil, NewMentionService(users, mentions), nil, nil, nil, nil)
+
+	resp, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "@alice @bob @nobody >>3 >>99"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Mentions) != 1 || resp.Mentions[0].UserID != 7 || resp.Mentions[0].Username != "alice" {
+		t.Fatalf("expected only alice mentioned, got %+v", resp.Mentions)
+	}
+	if len(resp.Quotes) != 1 || resp.Quotes[0].ReplyID != 3 {
+		t.Fatalf("expected quote of reply 3, got %+v", resp.Quotes)
+	}
+
+	repo := &fakeReplyRepo{findResult: &models.Reply{ID: 1, ThreadID: 1, UserID: 2, Version: 1}}
+	svc = NewReplyService(repo, &fakeThreadRepo{}, nil, NewMentionService(users, mentions), nil, nil, nil, nil)
+	resp, err = svc.Update(2, 1, 1, dto.UpdateReplyReq{Content: "no refs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Mentions) != 0 || len(resp.Quotes) != 0 {
+		t.Fatalf("expected mentions cleared on update, got %+v %+v", resp.Mentions, resp.Quotes)
+	}
+}
+
+func TestThreadServiceCreateIgnoresMentionSyncError(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	mentions := &fakeMentionRepo{syncErr: errors.New("boom")}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, NewMentionService(&fakeUserRepo{}, mentions), nil, nil, nil)
+
+	// 帖子已写入，提及同步失败不能让请求失败，否则客户端重试会重复发帖
+	if _, err := svc.Create(1, dto.CreateThreadReq{Title: "t", Content: "@alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.created == nil {
+		t.Fatalf("expected thread created")
+	}
+}
+
+func TestThreadDetailWithoutMentionService(t *testing.T) {
+	repo := &fakeThreadRepo{findResult: thread(1, 1)}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.GetByID(0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Mentions == nil || resp.Quotes == nil {
+		t.Fatalf("expected empty mention lists, got %+v %+v", resp.Mentions, resp.Quotes)
+	}
+}
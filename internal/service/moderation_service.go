@@ -0,0 +1,231 @@
+package service
+
+import (
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ModerationService struct {
+	threadRepo repository.ThreadRepository
+	replyRepo  repository.ReplyRepository
+	modRepo    repository.ModerationRepository
+	counter    repository.LikeCounter
+	reactions  repository.ReactionCounter
+	// 为 nil 时未开启踩
+	downvotes repository.LikeCounter
+}
+
+func NewModerationService(
+	threadRepo repository.ThreadRepository,
+	replyRepo repository.ReplyRepository,
+	modRepo repository.ModerationRepository,
+	counter repository.LikeCounter,
+	reactions repository.ReactionCounter,
+	downvotes repository.LikeCounter,
+) *ModerationService {
+	return &ModerationService{
+		threadRepo: threadRepo,
+		replyRepo:  replyRepo,
+		modRepo:    modRepo,
+		counter:    counter,
+		reactions:  reactions,
+		downvotes:  downvotes,
+	}
+}
+
+type moderationRepos struct {
+	threads repository.ThreadRepository
+	replies repository.ReplyRepository
+	mod     repository.ModerationRepository
+}
+
+func (s *ModerationService) inTx(fn func(r moderationRepos) error) error {
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+	rrWithTx, ok3 := s.replyRepo.(repository.ReplyRepoWithTx)
+	mrWithTx, ok4 := s.modRepo.(repository.ModerationRepoWithTx)
+
+	if ok1 && ok2 && ok3 && ok4 {
+		return txer.Transaction(func(tx *gorm.DB) error {
+			return fn(moderationRepos{
+				threads: trWithTx.WithTx(tx),
+				replies: rrWithTx.WithTx(tx),
+				mod:     mrWithTx.WithTx(tx),
+			})
+		})
+	}
+
+	return fn(moderationRepos{threads: s.threadRepo, replies: s.replyRepo, mod: s.modRepo})
+}
+
+// 源帖正文转为目标帖的一条回复，回复与点赞并入目标帖，源帖进入回收站
+func (s *ModerationService) Merge(actor Actor, sourceID, targetID uint) (*dto.ThreadRefResp, error) {
+	if !actor.IsModerator() {
+		return nil, ErrForbidden
+	}
+	if sourceID == targetID {
+		return nil, ErrMergeSameThread
+	}
+
+	source, err := s.threadRepo.FindByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.threadRepo.FindByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || target == nil {
+		return nil, ErrThreadNotFound
+	}
+
+	err = s.inTx(func(r moderationRepos) error {
+		opening := &models.Reply{
+			CreatedAt:     source.CreatedAt,
+			ThreadID:      targetID,
+			Content:       source.Content,
+			ContentFormat: source.ContentFormat,
+			ContentHTML:   source.ContentHTML,
+			UserID:        source.UserID,
+		}
+		if err := r.replies.Create(opening); err != nil {
+			return err
+		}
+		if err := r.mod.MoveThreadUploadsToReply(sourceID, opening.ID); err != nil {
+			return err
+		}
+		if err := r.mod.MoveMentions(models.MentionSourceThread, sourceID, models.MentionSourceReply, opening.ID); err != nil {
+			return err
+		}
+		if err := r.mod.MoveReplies(sourceID, targetID, nil); err != nil {
+			return err
+		}
+		if err := r.mod.MergeLikes(sourceID, targetID); err != nil {
+			return err
+		}
+		if err := r.threads.DeleteByID(sourceID); err != nil {
+			return err
+		}
+		return r.mod.RecalcThreadStats(targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(sourceID, targetID)
+	return &dto.ThreadRefResp{ThreadID: targetID}, nil
+}
+
+// 选中回复中最早的一条成为新帖正文，其余回复移到新帖下
+func (s *ModerationService) Split(actor Actor, threadID uint, req dto.SplitThreadReq) (*dto.ThreadRefResp, error) {
+	if !actor.IsModerator() {
+		return nil, ErrForbidden
+	}
+
+	t, err := s.threadRepo.FindByID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrThreadNotFound
+	}
+
+	ids := uniqueIDs(req.ReplyIDs)
+	replies, err := s.modRepo.ListRepliesByIDs(threadID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) != len(ids) {
+		return nil, ErrInvalidReplies
+	}
+
+	first := replies[0]
+	nt := &models.Thread{
+		CreatedAt:     first.CreatedAt,
+		Title:         req.Title,
+		Content:       first.Content,
+		ContentFormat: first.ContentFormat,
+		ContentHTML:   first.ContentHTML,
+		UserID:        first.UserID,
+	}
+
+	err = s.inTx(func(r moderationRepos) error {
+		if err := r.threads.Create(nt); err != nil {
+			return err
+		}
+		if err := r.mod.MoveReplyUploadsToThread(first.ID, nt.ID); err != nil {
+			return err
+		}
+		if err := r.mod.MoveMentions(models.MentionSourceReply, first.ID, models.MentionSourceThread, nt.ID); err != nil {
+			return err
+		}
+		if err := r.mod.HardDeleteReply(first.ID); err != nil {
+			return err
+		}
+		if len(replies) > 1 {
+			rest := make([]uint, 0, len(replies)-1)
+			for _, rp := range replies[1:] {
+				rest = append(rest, rp.ID)
+			}
+			if err := r.mod.MoveReplies(threadID, nt.ID, rest); err != nil {
+				return err
+			}
+		}
+		for _, id := range []uint{threadID, nt.ID} {
+			if err := r.mod.DetachOrphanReplies(id); err != nil {
+				return err
+			}
+			if err := r.mod.RecalcThreadStats(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(threadID, nt.ID)
+	return &dto.ThreadRefResp{ThreadID: nt.ID}, nil
+}
+
+// 统计已在库中重算：Redis 点赞数与踩数改为库中的新值，清掉回应计数与详情缓存让后续读取回源
+func (s *ModerationService) invalidate(threadIDs ...uint) {
+	for _, id := range threadIDs {
+		rewarmLikeCount(s.counter, id)
+		rewarmLikeCount(s.downvotes, id)
+		if s.reactions != nil {
+			_ = s.reactions.PurgeReactionCounts(id)
+		}
+		if refresher, ok := s.threadRepo.(repository.ThreadCacheRefresher); ok {
+			_ = refresher.RefreshCache(id)
+		}
+	}
+}
+
+// 删掉旧 key 后立即回源，计数从库中重算后的值继续累加，不会被 worker 用旧值回写
+func rewarmLikeCount(counter repository.LikeCounter, id uint) {
+	if counter == nil {
+		return
+	}
+	if purger, ok := counter.(repository.LikeCountPurger); ok {
+		_ = purger.PurgeLikeCount(id)
+	}
+	_, _ = counter.GetLikeCount(id)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]struct{}, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
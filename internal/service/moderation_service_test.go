@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+)
+
+type fakeModerationRepo struct {
+	replies []models.Reply
+
+	moved       map[uint][]uint
+	mergedLikes [][2]uint
+	hardDeleted []uint
+	recalced    []uint
+	detached    []uint
+}
+
+func (f *fakeModerationRepo) ListRepliesByIDs(threadID uint, ids []uint) ([]models.Reply, error) {
+	return f.replies, nil
+}
+
+func (f *fakeModerationRepo) MoveReplies(fromThreadID, toThreadID uint, ids []uint) error {
+	if f.moved == nil {
+		f.moved = make(map[uint][]uint)
+	}
+	f.moved[toThreadID] = append(f.moved[toThreadID], ids...)
+	return nil
+}
+
+func (f *fakeModerationRepo) MergeLikes(fromThreadID, toThreadID uint) error {
+	f.mergedLikes = append(f.mergedLikes, [2]uint{fromThreadID, toThreadID})
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveThreadUploadsToReply(uint, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveReplyUploadsToThread(uint, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) HardDeleteReply(id uint) error {
+	f.hardDeleted = append(f.hardDeleted, id)
+	return nil
+}
+
+func (f *fakeModerationRepo) RecalcThreadStats(threadID uint) error {
+	f.recalced = append(f.recalced, threadID)
+	return nil
+}
+
+func (f *fakeModerationRepo) MoveMentions(string, uint, string, uint) error {
+	return nil
+}
+
+func (f *fakeModerationRepo) DetachOrphanReplies(threadID uint) error {
+	f.detached = append(f.detached, threadID)
+	return nil
+}
+
+var moderator = Actor{UserID: 9, Role: models.RoleModerator}
+
+func TestModerationServiceMergeRequiresModerator(t *testing.T) {
+	svc := NewModerationService(&fakeThreadRepo{findResult: thread(1, 1)}, &fakeReplyRepo{}, &fakeModerationRepo{}, nil, nil, nil)
+
+	if _, err := svc.Merge(Actor{UserID: 1}, 1, 2); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.Merge(moderator, 2, 2); !errors.Is(err, ErrMergeSameThread) {
+		t.Fatalf("expected ErrMergeSameThread, got %v", err)
+	}
+}
+
+func TestModerationServiceMerge(t *testing.T) {
+	source := &models.Thread{ID: 1, UserID: 3, Content: "dup", ContentFormat: "plain", CreatedAt: time.Unix(50, 0)}
+	threadRepo := &fakeThreadRepo{findResult: source}
+	replyRepo := &fakeReplyRepo{}
+	modRepo := &fakeModerationRepo{}
+	svc := NewModerationService(threadRepo, replyRepo, modRepo, nil, nil, nil)
+
+	resp, err := svc.Merge(moderator, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ThreadID != 2 {
+		t.Fatalf("expected target thread, got %d", resp.ThreadID)
+	}
+	if replyRepo.created == nil || replyRepo.created.ThreadID != 2 || replyRepo.created.UserID != 3 || !replyRepo.created.CreatedAt.Equal(source.CreatedAt) {
+		t.Fatalf("expected source body copied as reply, got %+v", replyRepo.created)
+	}
+	if len(modRepo.mergedLikes) != 1 || modRepo.mergedLikes[0] != [2]uint{1, 2} {
+		t.Fatalf("expected likes merged, got %+v", modRepo.mergedLikes)
+	}
+	if threadRepo.deleteID != 1 {
+		t.Fatalf("expected source deleted, got %d", threadRepo.deleteID)
+	}
+	if len(modRepo.recalced) != 1 || modRepo.recalced[0] != 2 {
+		t.Fatalf("expected target stats recalculated, got %+v", modRepo.recalced)
+	}
+}
+
+// 模拟 Redis 计数：key 不存在时从 db 回源
+type fakeCachedCounter struct {
+	db     map[uint]int64
+	keys   map[uint]int64
+	purged []uint
+}
+
+func newFakeCachedCounter() *fakeCachedCounter {
+	return &fakeCachedCounter{db: map[uint]int64{}, keys: map[uint]int64{}}
+}
+
+func (f *fakeCachedCounter) IncrementLikeCount(id uint, delta int) error {
+	if _, ok := f.keys[id]; !ok {
+		f.keys[id] = f.db[id]
+	}
+	f.keys[id] += int64(delta)
+	return nil
+}
+
+func (f *fakeCachedCounter) GetLikeCount(id uint) (int64, error) {
+	if _, ok := f.keys[id]; !ok {
+		f.keys[id] = f.db[id]
+	}
+	return f.keys[id], nil
+}
+
+func (f *fakeCachedCounter) PurgeLikeCount(id uint) error {
+	f.purged = append(f.purged, id)
+	delete(f.keys, id)
+	return nil
+}
+
+func TestModerationServiceLikeAfterMergeKeepsMergedCount(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 3)}
+	counter := newFakeCachedCounter()
+	// Redis 中是合并前的旧值，库中已重算为合并后的 8
+	counter.keys[2] = 5
+	counter.db[2] = 8
+	downvotes := newFakeCachedCounter()
+	downvotes.keys[2] = 1
+	downvotes.db[2] = 3
+	svc := NewModerationService(threadRepo, &fakeReplyRepo{}, &fakeModerationRepo{}, counter, nil, downvotes)
+
+	if _, err := svc.Merge(moderator, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []uint{1, 2}; !reflect.DeepEqual(counter.purged, want) {
+		t.Fatalf("expected like counts refreshed for %v, got %v", want, counter.purged)
+	}
+	if counter.keys[2] != 8 {
+		t.Fatalf("expected merged count warmed into cache, got %d", counter.keys[2])
+	}
+	if err := counter.IncrementLikeCount(2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.keys[2] != 9 {
+		t.Fatalf("expected like after merge to keep merged count, got %d", counter.keys[2])
+	}
+	if err := downvotes.IncrementLikeCount(2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if downvotes.keys[2] != 4 {
+		t.Fatalf("expected downvote after merge to keep merged count, got %d", downvotes.keys[2])
+	}
+}
+
+func TestModerationServiceSplit(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	modRepo := &fakeModerationRepo{replies: []models.Reply{
+		{ID: 4, ThreadID: 1, UserID: 5, Content: "first"},
+		{ID: 6, ThreadID: 1, UserID: 7, Content: "second"},
+	}}
+	svc := NewModerationService(threadRepo, &fakeReplyRepo{}, modRepo, nil, nil, nil)
+
+	if _, err := svc.Split(moderator, 1, dto.SplitThreadReq{Title: "t", ReplyIDs: []uint{4, 6, 8}}); !errors.Is(err, ErrInvalidReplies) {
+		t.Fatalf("expected ErrInvalidReplies, got %v", err)
+	}
+
+	if _, err := svc.Split(moderator, 1, dto.SplitThreadReq{Title: "t", ReplyIDs: []uint{6, 4, 6}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created := threadRepo.created
+	if created == nil || created.Title != "t" || created.Content != "first" || created.UserID != 5 {
+		t.Fatalf("expected first reply promoted to thread, got %+v", created)
+	}
+	if len(modRepo.hardDeleted) != 1 || modRepo.hardDeleted[0] != 4 {
+		t.Fatalf("expected promoted reply removed, got %+v", modRepo.hardDeleted)
+	}
+	if got := modRepo.moved[created.ID]; len(got) != 1 || got[0] != 6 {
+		t.Fatalf("expected remaining reply moved, got %+v", modRepo.moved)
+	}
+	if len(modRepo.recalced) != 2 {
+		t.Fatalf("expected both threads recalculated, got %+v", modRepo.recalced)
+	}
+}
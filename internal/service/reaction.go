@@ -0,0 +1,32 @@
+package service
+
+import "exchangeapp/internal/models"
+
+// 配置中允许的回应名称，like 始终可用
+type ReactionSet map[string]bool
+
+func NewReactionSet(types []string) ReactionSet {
+	set := ReactionSet{models.ReactionLike: true}
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
+
+func (s ReactionSet) Has(reaction string) bool {
+	return reaction == models.ReactionLike || s[reaction]
+}
+
+// like 的数量来自点赞计数，其余来自回应计数，只保留大于 0 的项；踩不属于表情回应
+func mergeReactionCounts(likeCount int64, counts map[string]int64) map[string]int64 {
+	res := make(map[string]int64, len(counts)+1)
+	for reaction, n := range counts {
+		if n > 0 && reaction != models.ReactionLike && reaction != models.ReactionDownvote {
+			res[reaction] = n
+		}
+	}
+	if likeCount > 0 {
+		res[models.ReactionLike] = likeCount
+	}
+	return res
+}
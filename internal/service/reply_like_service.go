@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ReplyLikeService struct {
+	replyRepo repository.ReplyRepository
+	likeRepo  repository.ReplyLikeRepository
+	counter   repository.LikeCounter
+	reactions repository.ReactionCounter
+	types     ReactionSet
+	// 为 nil 时未开启踩
+	downvotes repository.LikeCounter
+}
+
+func NewReplyLikeService(
+	replyRepo repository.ReplyRepository,
+	likeRepo repository.ReplyLikeRepository,
+	counter repository.LikeCounter,
+	reactions repository.ReactionCounter,
+	types ReactionSet,
+	downvotes repository.LikeCounter) *ReplyLikeService {
+	return &ReplyLikeService{
+		replyRepo: replyRepo,
+		likeRepo:  likeRepo,
+		counter:   counter,
+		reactions: reactions,
+		types:     types,
+		downvotes: downvotes,
+	}
+}
+
+func (s *ReplyLikeService) ensureReply(replyID uint) error {
+	r, err := s.replyRepo.FindByID(replyID)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return ErrReplyNotFound
+	}
+	return nil
+}
+
+// 点赞即 like 回应
+func (s *ReplyLikeService) Like(userID, replyID uint) error {
+	return s.React(userID, replyID, models.ReactionLike)
+}
+
+func (s *ReplyLikeService) Unlike(userID, replyID uint) error {
+	return s.Unreact(userID, replyID, models.ReactionLike)
+}
+
+func (s *ReplyLikeService) IsLiked(userID, replyID uint) (bool, error) {
+	if err := s.ensureReply(replyID); err != nil {
+		return false, err
+	}
+	return s.likeRepo.Exists(userID, replyID, models.ReactionLike)
+}
+
+// 回应记录写库，计数只写 Redis 并标记 dirty，由后台任务回写
+func (s *ReplyLikeService) React(userID, replyID uint, reaction string) error {
+	if !s.types.Has(reaction) {
+		return ErrInvalidReaction
+	}
+	return s.react(userID, replyID, reaction)
+}
+
+func (s *ReplyLikeService) Unreact(userID, replyID uint, reaction string) error {
+	if !s.types.Has(reaction) {
+		return ErrInvalidReaction
+	}
+	return s.unreact(userID, replyID, reaction)
+}
+
+// 与 ThreadLikeService.Vote 相同：重复投同一票不报错，改投在同一事务内完成
+func (s *ReplyLikeService) Vote(userID, replyID uint, vote string) (*dto.VoteResp, error) {
+	reaction := voteReaction(vote)
+	if reaction == models.ReactionDownvote && s.downvotes == nil {
+		return nil, ErrDownvoteDisabled
+	}
+	if err := s.react(userID, replyID, reaction); err != nil && !errors.Is(err, repository.ErrAlreadyLiked) {
+		return nil, err
+	}
+	return s.VoteStatus(userID, replyID)
+}
+
+func (s *ReplyLikeService) Unvote(userID, replyID uint) (*dto.VoteResp, error) {
+	for _, reaction := range []string{models.ReactionLike, models.ReactionDownvote} {
+		if reaction == models.ReactionDownvote && s.downvotes == nil {
+			continue
+		}
+		if err := s.unreact(userID, replyID, reaction); err != nil && !errors.Is(err, repository.ErrLikeNotFound) {
+			return nil, err
+		}
+	}
+	return s.VoteStatus(userID, replyID)
+}
+
+func (s *ReplyLikeService) VoteStatus(userID, replyID uint) (*dto.VoteResp, error) {
+	if err := s.ensureReply(replyID); err != nil {
+		return nil, err
+	}
+	mine, err := s.likeRepo.ListReactions(userID, replyID)
+	if err != nil {
+		return nil, err
+	}
+	likeCount, err := s.counter.GetLikeCount(replyID)
+	if err != nil {
+		return nil, err
+	}
+	var downvoteCount int64
+	if s.downvotes != nil {
+		if downvoteCount, err = s.downvotes.GetLikeCount(replyID); err != nil {
+			return nil, err
+		}
+	}
+	return newVoteResp(mine, likeCount, downvoteCount), nil
+}
+
+func (s *ReplyLikeService) react(userID, replyID uint, reaction string) error {
+	if err := s.ensureReply(replyID); err != nil {
+		return err
+	}
+	return s.inTx(func(lr repository.ReplyLikeRepository) error {
+		if s.downvotes != nil {
+			if err := dropOppositeVote(lr, userID, replyID, reaction, func(opposite string) error {
+				return s.increment(replyID, opposite, -1)
+			}); err != nil {
+				return err
+			}
+		}
+		if err := lr.Create(&models.ReplyLike{
+			UserID:   userID,
+			ReplyID:  replyID,
+			Reaction: reaction,
+		}); err != nil {
+			return err
+		}
+		return s.increment(replyID, reaction, 1)
+	})
+}
+
+func (s *ReplyLikeService) unreact(userID, replyID uint, reaction string) error {
+	if err := s.ensureReply(replyID); err != nil {
+		return err
+	}
+	if err := s.likeRepo.Delete(userID, replyID, reaction); err != nil {
+		return err
+	}
+	return s.increment(replyID, reaction, -1)
+}
+
+// 改投时撤票与投票需同时生效，仓库支持事务时在事务内执行
+func (s *ReplyLikeService) inTx(fn func(lr repository.ReplyLikeRepository) error) error {
+	txer, ok1 := s.likeRepo.(repository.Transactioner)
+	lrWithTx, ok2 := s.likeRepo.(repository.ReplyLikeRepoWithTx)
+	if ok1 && ok2 {
+		return txer.Transaction(func(tx *gorm.DB) error {
+			return fn(lrWithTx.WithTx(tx))
+		})
+	}
+	return fn(s.likeRepo)
+}
+
+func (s *ReplyLikeService) increment(replyID uint, reaction string, delta int) error {
+	switch reaction {
+	case models.ReactionLike:
+		return s.counter.IncrementLikeCount(replyID, delta)
+	case models.ReactionDownvote:
+		if s.downvotes == nil {
+			return nil
+		}
+		return s.downvotes.IncrementLikeCount(replyID, delta)
+	}
+	if s.reactions == nil {
+		return nil
+	}
+	return s.reactions.IncrementReaction(replyID, reaction, delta)
+}
+
+func (s *ReplyLikeService) Reactions(userID, replyID uint) (*dto.ReactionsResp, error) {
+	if err := s.ensureReply(replyID); err != nil {
+		return nil, err
+	}
+	likeCount, err := s.counter.GetLikeCount(replyID)
+	if err != nil {
+		return nil, err
+	}
+	var counts map[string]int64
+	if s.reactions != nil {
+		if counts, err = s.reactions.GetReactionCounts(replyID); err != nil {
+			return nil, err
+		}
+	}
+	mine, err := s.likeRepo.ListReactions(userID, replyID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ReactionsResp{Counts: mergeReactionCounts(likeCount, counts), Mine: withoutDownvote(mine)}, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"testing"
+)
+
+type fakeReplyLikeRepo struct {
+	createErr error
+	deleteErr error
+	exists    bool
+}
+
+func (f *fakeReplyLikeRepo) Create(*models.ReplyLike) error {
+	return f.createErr
+}
+
+func (f *fakeReplyLikeRepo) Delete(uint, uint, string) error {
+	return f.deleteErr
+}
+
+func (f *fakeReplyLikeRepo) Exists(uint, uint, string) (bool, error) {
+	return f.exists, nil
+}
+
+func (f *fakeReplyLikeRepo) ListReactions(uint, uint) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeReplyLikeRepo) GetLikeCount(uint) (int64, error) {
+	return 0, nil
+}
+
+type fakeLikeCounter struct {
+	deltas map[uint][]int
+	counts map[uint]int64
+}
+
+func (f *fakeLikeCounter) IncrementLikeCount(id uint, delta int) error {
+	if f.deltas == nil {
+		f.deltas = make(map[uint][]int)
+	}
+	f.deltas[id] = append(f.deltas[id], delta)
+	return nil
+}
+
+func (f *fakeLikeCounter) GetLikeCount(id uint) (int64, error) {
+	return f.counts[id], nil
+}
+
+func (f *fakeLikeCounter) GetLikeCounts([]uint) (map[uint]int64, error) {
+	return f.counts, nil
+}
+
+func TestReplyLikeService(t *testing.T) {
+	counter := &fakeLikeCounter{}
+	replyRepo := &fakeReplyRepo{}
+	likeRepo := &fakeReplyLikeRepo{}
+	svc := NewReplyLikeService(replyRepo, likeRepo, counter, nil, nil, nil)
+
+	if err := svc.Like(1, 5); !errors.Is(err, ErrReplyNotFound) {
+		t.Fatalf("expected ErrReplyNotFound, got %v", err)
+	}
+
+	replyRepo.findResult = &models.Reply{ID: 5, ThreadID: 1}
+	if err := svc.Like(1, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Unlike(1, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := counter.deltas[5]; len(got) != 2 || got[0] != 1 || got[1] != -1 {
+		t.Fatalf("expected deltas [1 -1], got %v", got)
+	}
+
+	likeRepo.createErr = repository.ErrAlreadyLiked
+	if err := svc.Like(1, 5); !errors.Is(err, repository.ErrAlreadyLiked) {
+		t.Fatalf("expected ErrAlreadyLiked, got %v", err)
+	}
+	if len(counter.deltas[5]) != 2 {
+		t.Fatalf("expected counter untouched on duplicate like")
+	}
+}
+
+func TestReplyServiceListUsesCachedLikeCounts(t *testing.T) {
+	repo := &fakeReplyRepo{listResult: []models.Reply{{ID: 1, ThreadID: 1, LikeCount: 2}, {ID: 2, ThreadID: 1, LikeCount: 3}}}
+	counter := &fakeLikeCounter{counts: map[uint]int64{1: 9}}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, counter, nil, nil, nil)
+
+	resp, err := svc.ListByThreadID(1, false, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Items[0].LikeCount != 9 || resp.Items[1].LikeCount != 3 {
+		t.Fatalf("expected cached count with db fallback, got %d %d", resp.Items[0].LikeCount, resp.Items[1].LikeCount)
+	}
+}
+
+func TestReplyServiceListScoresWithCachedDownvotes(t *testing.T) {
+	repo := &fakeReplyRepo{listResult: []models.Reply{
+		{ID: 1, ThreadID: 1, LikeCount: 2, DownvoteCount: 1},
+		{ID: 2, ThreadID: 1, LikeCount: 3, DownvoteCount: 5},
+	}}
+	counter := &fakeLikeCounter{counts: map[uint]int64{1: 9}}
+	downvotes := &fakeLikeCounter{counts: map[uint]int64{1: 4}}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, counter, nil, downvotes, nil)
+
+	resp, err := svc.ListByThreadID(1, false, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Items[0].Score != 5 || resp.Items[1].Score != -2 {
+		t.Fatalf("expected scores 5 -2, got %d %d", resp.Items[0].Score, resp.Items[1].Score)
+	}
+}
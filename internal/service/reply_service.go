@@ -1,23 +1,47 @@
 package service
 
 import (
+	"errors"
 	"exchangeapp/internal/dto"
 	"exchangeapp/internal/models"
 	"exchangeapp/internal/repository"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// 顶层回复深度为 0，超过该深度的回复不允许再被回复
+const maxReplyDepth = 5
+
 type ReplyService struct {
 	replyRepo  repository.ReplyRepository
 	threadRepo repository.ThreadRepository
+	uploadRepo repository.UploadRepository
+	mentions   *MentionService
+	counter    repository.LikeCounter
+	reactions  repository.ReactionCounter
+	downvotes  repository.LikeCounter
+	reputation *ReputationService
 }
 
 func NewReplyService(replyRepo repository.ReplyRepository,
-	threadRepo repository.ThreadRepository) *ReplyService {
+	threadRepo repository.ThreadRepository,
+	uploadRepo repository.UploadRepository,
+	mentions *MentionService,
+	counter repository.LikeCounter,
+	reactions repository.ReactionCounter,
+	downvotes repository.LikeCounter,
+	reputation *ReputationService) *ReplyService {
 	return &ReplyService{
 		replyRepo:  replyRepo,
 		threadRepo: threadRepo,
+		uploadRepo: uploadRepo,
+		mentions:   mentions,
+		counter:    counter,
+		reactions:  reactions,
+		downvotes:  downvotes,
+		reputation: reputation,
 	}
 }
 
@@ -30,26 +54,218 @@ func (s *ReplyService) Create(userID uint, threadID uint, req dto.CreateReplyReq
 		return nil, ErrThreadNotFound
 	}
 
+	format, html, err := renderContent(req.ContentFormat, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reputation.requireLinks(userID, req.Content, html); err != nil {
+		return nil, err
+	}
+
 	r := &models.Reply{
-		ThreadID: threadID,
-		Content:  req.Content,
-		UserID:   userID,
+		ThreadID:      threadID,
+		Content:       req.Content,
+		ContentFormat: format,
+		ContentHTML:   html,
+		UserID:        userID,
+	}
+	if req.ParentID != 0 {
+		parent, err := s.replyRepo.FindByID(req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.ThreadID != threadID {
+			return nil, ErrInvalidParent
+		}
+		if parent.Depth >= maxReplyDepth {
+			return nil, ErrReplyTooDeep
+		}
+		r.ParentID = parent.ID
+		r.Depth = parent.Depth + 1
 	}
 
-	if err := s.replyRepo.Create(r); err != nil {
+	if err := s.createReply(r, req.AttachmentIDs); err != nil {
 		return nil, err
 	}
+	s.mentions.sync(models.MentionSourceReply, r.ID, r.ThreadID, userID, r.Content)
 
-	return &dto.ReplyResp{
-		ID:        r.ID,
-		ThreadID:  r.ThreadID,
-		Content:   r.Content,
-		UserID:    r.UserID,
-		CreatedAt: r.CreatedAt,
-	}, nil
+	items := []dto.ReplyResp{newReplyResp(r)}
+	if err := s.fill(items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *ReplyService) createReply(r *models.Reply, attachmentIDs []uint) error {
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+	rrWithTx, ok3 := s.replyRepo.(repository.ReplyRepoWithTx)
+
+	if ok1 && ok2 && ok3 {
+		err := txer.Transaction(func(tx *gorm.DB) error {
+			ur := s.uploadRepo
+			if urWithTx, ok := s.uploadRepo.(repository.UploadRepoWithTx); ok {
+				ur = urWithTx.WithTx(tx)
+			}
+			return createReply(trWithTx.WithTx(tx), rrWithTx.WithTx(tx), ur, r, attachmentIDs)
+		})
+		if err != nil {
+			return err
+		}
+		// 事务内绕过了详情缓存，回复数与最后活跃时间已变
+		if refresher, ok := s.threadRepo.(repository.ThreadCacheRefresher); ok {
+			_ = refresher.RefreshCache(r.ThreadID)
+		}
+		return nil
+	}
+
+	return createReply(s.threadRepo, s.replyRepo, s.uploadRepo, r, attachmentIDs)
+}
+
+func createReply(
+	tr repository.ThreadRepository,
+	rr repository.ReplyRepository,
+	ur repository.UploadRepository,
+	r *models.Reply,
+	attachmentIDs []uint,
+) error {
+	if err := rr.Create(r); err != nil {
+		return err
+	}
+	if len(attachmentIDs) > 0 && ur != nil {
+		if err := ur.AttachToReply(r.UserID, r.ID, attachmentIDs); err != nil {
+			return err
+		}
+	}
+	return tr.UpdateReplyStats(r.ThreadID, 1, r)
+}
+
+func deleteReply(tr repository.ThreadRepository, rr repository.ReplyRepository, r *models.Reply) error {
+	if err := rr.DeleteByID(r.ID); err != nil {
+		return err
+	}
+	if err := tr.ClearAcceptedReply(r.ThreadID, r.ID); err != nil {
+		return err
+	}
+	last, err := rr.FindLatestByThreadID(r.ThreadID)
+	if err != nil {
+		return err
+	}
+	return tr.UpdateReplyStats(r.ThreadID, -1, last)
+}
+
+func (s *ReplyService) fill(items []dto.ReplyResp) error {
+	if err := s.fillAttachments(items); err != nil {
+		return err
+	}
+	if err := s.mentions.fillReplies(items); err != nil {
+		return err
+	}
+	s.fillLikeCounts(items)
+	if err := s.fillReactions(items); err != nil {
+		return err
+	}
+	return s.fillReplyCounts(items)
+}
+
+// like 取自 like_count，其余回应整页一次批量获取
+func (s *ReplyService) fillReactions(items []dto.ReplyResp) error {
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		if !items[i].Deleted {
+			ids = append(ids, items[i].ID)
+		}
+	}
+	var counts map[uint]map[string]int64
+	if s.reactions != nil && len(ids) > 0 {
+		var err error
+		if counts, err = s.reactions.GetReactionCountsBatch(ids); err != nil {
+			return err
+		}
+	}
+	for i := range items {
+		if !items[i].Deleted {
+			items[i].Reactions = mergeReactionCounts(items[i].LikeCount, counts[items[i].ID])
+		}
+	}
+	return nil
+}
+
+// Redis 未命中的沿用库里的 like_count、downvote_count
+func (s *ReplyService) fillLikeCounts(items []dto.ReplyResp) {
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		if !items[i].Deleted {
+			ids = append(ids, items[i].ID)
+		}
+	}
+	cached := cachedCounts(s.counter, ids)
+	cachedDownvotes := cachedCounts(s.downvotes, ids)
+	for i := range items {
+		if items[i].Deleted {
+			continue
+		}
+		if v, ok := cached[items[i].ID]; ok {
+			items[i].LikeCount = v
+		}
+		if v, ok := cachedDownvotes[items[i].ID]; ok {
+			items[i].DownvoteCount = v
+		}
+		items[i].Score = items[i].LikeCount - items[i].DownvoteCount
+	}
 }
 
-func (s *ReplyService) ListByThreadID(threadID uint, page, size int) (*dto.ReplyListResp, error) {
+func (s *ReplyService) fillReplyCounts(items []dto.ReplyResp) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	counts, err := s.replyRepo.CountChildren(ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].ReplyCount = counts[items[i].ID]
+	}
+	return nil
+}
+
+func (s *ReplyService) fillAttachments(items []dto.ReplyResp) error {
+	for i := range items {
+		items[i].Attachments = []dto.UploadResp{}
+	}
+	if s.uploadRepo == nil || len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	ups, err := s.uploadRepo.ListByReplyIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byReply := make(map[uint][]dto.UploadResp)
+	for i := range ups {
+		byReply[ups[i].ReplyID] = append(byReply[ups[i].ReplyID], newUploadResp(&ups[i]))
+	}
+	for i := range items {
+		if items[i].Deleted {
+			continue
+		}
+		if ups, ok := byReply[items[i].ID]; ok {
+			items[i].Attachments = ups
+		}
+	}
+	return nil
+}
+
+func (s *ReplyService) ListByThreadID(threadID uint, desc bool, page, size int) (*dto.ReplyListResp, error) {
 	t, err := s.threadRepo.FindByID(threadID)
 	if err != nil {
 		return nil, err
@@ -64,20 +280,32 @@ func (s *ReplyService) ListByThreadID(threadID uint, page, size int) (*dto.Reply
 	if err != nil {
 		return nil, err
 	}
-	rs, err := s.replyRepo.ListByThreadID(threadID, size, offset)
+	rs, err := s.replyRepo.ListByThreadID(threadID, desc, size, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]dto.ReplyResp, len(rs))
+	items := make([]dto.ReplyResp, 0, len(rs)+1)
+	if page == 1 && t.AcceptedReplyID != 0 {
+		accepted, err := s.replyRepo.FindByID(t.AcceptedReplyID)
+		if err != nil {
+			return nil, err
+		}
+		if accepted != nil && accepted.ThreadID == threadID {
+			item := newReplyResp(accepted)
+			item.Accepted = true
+			items = append(items, item)
+		}
+	}
+	// 被采纳的回复只在第一页置顶出现，不再出现在原有位置
 	for i := range rs {
-		items[i] = dto.ReplyResp{
-			ID:        rs[i].ID,
-			ThreadID:  rs[i].ThreadID,
-			Content:   rs[i].Content,
-			UserID:    rs[i].UserID,
-			CreatedAt: rs[i].CreatedAt,
+		if rs[i].ID == t.AcceptedReplyID {
+			continue
 		}
+		items = append(items, newReplyResp(&rs[i]))
+	}
+	if err := s.fill(items); err != nil {
+		return nil, err
 	}
 
 	next := ""
@@ -95,7 +323,7 @@ func (s *ReplyService) ListByThreadID(threadID uint, page, size int) (*dto.Reply
 	}, nil
 }
 
-func (s *ReplyService) ListByThreadIDAfter(threadID uint, cursorTime time.Time, cursorID uint, size int) (*dto.ReplyListResp, error) {
+func (s *ReplyService) ListByThreadIDAfter(threadID uint, desc bool, cursorTime time.Time, cursorID uint, size int) (*dto.ReplyListResp, error) {
 	t, err := s.threadRepo.FindByID(threadID)
 	if err != nil {
 		return nil, err
@@ -104,20 +332,20 @@ func (s *ReplyService) ListByThreadIDAfter(threadID uint, cursorTime time.Time,
 		return nil, ErrThreadNotFound
 	}
 
-	replies, err := s.replyRepo.ListByThreadIDAfter(threadID, cursorTime, cursorID, size)
+	replies, err := s.replyRepo.ListByThreadIDAfter(threadID, desc, cursorTime, cursorID, size)
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]dto.ReplyResp, len(replies))
+	items := make([]dto.ReplyResp, 0, len(replies))
 	for i := range replies {
-		items[i] = dto.ReplyResp{
-			ID:        replies[i].ID,
-			ThreadID:  replies[i].ThreadID,
-			Content:   replies[i].Content,
-			UserID:    replies[i].UserID,
-			CreatedAt: replies[i].CreatedAt,
+		if replies[i].ID == t.AcceptedReplyID {
+			continue
 		}
+		items = append(items, newReplyResp(&replies[i]))
+	}
+	if err := s.fill(items); err != nil {
+		return nil, err
 	}
 
 	next := ""
@@ -135,6 +363,230 @@ func (s *ReplyService) ListByThreadIDAfter(threadID uint, cursorTime time.Time,
 	}, nil
 }
 
+// 回复本身已删除时渲染为占位；已删除且没有子回复的视为不存在
+func (s *ReplyService) ListChildren(id uint, cursorTime time.Time, cursorID uint, size int) (*dto.ReplyTreeResp, error) {
+	node, err := s.visibleReply(id)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := s.replyRepo.ListChildren(id, cursorTime, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.ReplyResp, 0, len(children)+1)
+	items = append(items, newReplyNode(node))
+	for i := range children {
+		items = append(items, newReplyNode(&children[i]))
+	}
+	if err := s.fill(items); err != nil {
+		return nil, err
+	}
+	if node.DeletedAt.Valid && items[0].ReplyCount == 0 {
+		return nil, ErrReplyNotFound
+	}
+
+	next := ""
+	if len(children) > 0 {
+		last := children[len(children)-1]
+		next = fmt.Sprintf("%d_%d", last.CreatedAt.UnixNano(), last.ID)
+	}
+	return &dto.ReplyTreeResp{
+		Reply:      items[0],
+		Children:   items[1:],
+		Size:       size,
+		NextCursor: next,
+	}, nil
+}
+
+// 从顶层回复到目标回复的完整链路，已删除的祖先渲染为占位
+func (s *ReplyService) Context(id uint) (*dto.ReplyContextResp, error) {
+	node, err := s.visibleReply(id)
+	if err != nil {
+		return nil, err
+	}
+	if node.DeletedAt.Valid {
+		return nil, ErrReplyNotFound
+	}
+
+	var chain []models.Reply
+	for pid := node.ParentID; pid != 0 && len(chain) < maxReplyDepth; {
+		p, err := s.replyRepo.FindWithDeleted(pid)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil || p.ThreadID != node.ThreadID {
+			break
+		}
+		chain = append(chain, *p)
+		pid = p.ParentID
+	}
+
+	items := make([]dto.ReplyResp, 0, len(chain)+1)
+	for i := len(chain) - 1; i >= 0; i-- {
+		items = append(items, newReplyNode(&chain[i]))
+	}
+	items = append(items, newReplyResp(node))
+	if err := s.fill(items); err != nil {
+		return nil, err
+	}
+	return &dto.ReplyContextResp{
+		Ancestors: items[:len(items)-1],
+		Reply:     items[len(items)-1],
+	}, nil
+}
+
+// 问答帖的作者或版主采纳一条回复，可改选；replyID 为 0 时取消采纳
+func (s *ReplyService) Accept(actor Actor, threadID, replyID uint) (*dto.AcceptedReplyResp, error) {
+	t, err := s.threadRepo.FindByID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrThreadNotFound
+	}
+	if !actor.CanManage(t.UserID) {
+		return nil, ErrForbidden
+	}
+	if threadType(t) != models.ThreadTypeQuestion {
+		return nil, ErrNotQuestion
+	}
+	var accepted *models.Reply
+	if replyID != 0 {
+		if accepted, err = s.replyRepo.FindByID(replyID); err != nil {
+			return nil, err
+		}
+		if accepted == nil || accepted.ThreadID != threadID {
+			return nil, ErrReplyNotFound
+		}
+	}
+
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+
+	if ok1 && ok2 {
+		err := txer.Transaction(func(tx *gorm.DB) error {
+			return s.acceptReply(trWithTx.WithTx(tx), s.reputation.repoFor(tx), actor.UserID, threadID, accepted)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// 事务内绕过了详情缓存
+		if refresher, ok := s.threadRepo.(repository.ThreadCacheRefresher); ok {
+			_ = refresher.RefreshCache(threadID)
+		}
+	} else if err := s.acceptReply(s.threadRepo, s.reputation.repoFor(nil), actor.UserID, threadID, accepted); err != nil {
+		return nil, err
+	}
+	return &dto.AcceptedReplyResp{ThreadID: threadID, AcceptedReplyID: replyID}, nil
+}
+
+// 以库中当前采纳的回复为准做条件更新，更新成功才追加声望流水；
+// 重复采纳同一回复不重复计声望，原答案已被删除时不再扣回声望
+func (s *ReplyService) acceptReply(tr repository.ThreadRepository, rr repository.ReputationRepository, actorID, threadID uint, accepted *models.Reply) error {
+	t, err := tr.FindByID(threadID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrThreadNotFound
+	}
+	replyID := uint(0)
+	if accepted != nil {
+		replyID = accepted.ID
+	}
+	previousID := t.AcceptedReplyID
+	if previousID == replyID {
+		return nil
+	}
+
+	ok, err := tr.SetAcceptedReply(threadID, previousID, replyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAcceptedReplyChanged
+	}
+	if previousID != 0 {
+		previous, err := s.replyRepo.FindByID(previousID)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			if err := s.reputation.answerAccepted(rr, actorID, t, previous, -1); err != nil {
+				return err
+			}
+		}
+	}
+	if accepted != nil {
+		return s.reputation.answerAccepted(rr, actorID, t, accepted, 1)
+	}
+	return nil
+}
+
+// 计算回复在帖子回复列表中所在的页码，以及能打开同一页的 cursor（第一页为空）
+func (s *ReplyService) Locate(id uint, desc bool, size int) (*dto.ReplyLocateResp, error) {
+	r, err := s.replyRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrReplyNotFound
+	}
+	t, err := s.threadRepo.FindByID(r.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrReplyNotFound
+	}
+
+	before, err := s.replyRepo.CountByThreadIDBefore(r.ThreadID, desc, r.CreatedAt, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	offset := int(before) / size * size
+
+	cursor := ""
+	if offset > 0 {
+		prev, err := s.replyRepo.ListByThreadID(r.ThreadID, desc, 1, offset-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(prev) > 0 {
+			cursor = fmt.Sprintf("%d_%d", prev[0].CreatedAt.UnixNano(), prev[0].ID)
+		}
+	}
+
+	return &dto.ReplyLocateResp{
+		ReplyID:  r.ID,
+		ThreadID: r.ThreadID,
+		Page:     offset/size + 1,
+		Size:     size,
+		Cursor:   cursor,
+	}, nil
+}
+
+// 包含已删除的回复，但所属帖子必须仍存在
+func (s *ReplyService) visibleReply(id uint) (*models.Reply, error) {
+	r, err := s.replyRepo.FindWithDeleted(id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrReplyNotFound
+	}
+	t, err := s.threadRepo.FindByID(r.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrReplyNotFound
+	}
+	return r, nil
+}
+
 func (s *ReplyService) ListByUserID(userID uint, page, size int) (*dto.ReplyListResp, error) {
 	offset := (page - 1) * size
 
@@ -149,13 +601,10 @@ func (s *ReplyService) ListByUserID(userID uint, page, size int) (*dto.ReplyList
 
 	items := make([]dto.ReplyResp, len(rs))
 	for i := range rs {
-		items[i] = dto.ReplyResp{
-			ID:        rs[i].ID,
-			ThreadID:  rs[i].ThreadID,
-			Content:   rs[i].Content,
-			UserID:    rs[i].UserID,
-			CreatedAt: rs[i].CreatedAt,
-		}
+		items[i] = newReplyResp(&rs[i])
+	}
+	if err := s.fill(items); err != nil {
+		return nil, err
 	}
 
 	next := ""
@@ -181,13 +630,10 @@ func (s *ReplyService) ListByUserIDAfter(userID uint, cursorTime time.Time, curs
 
 	items := make([]dto.ReplyResp, len(replies))
 	for i := range replies {
-		items[i] = dto.ReplyResp{
-			ID:        replies[i].ID,
-			ThreadID:  replies[i].ThreadID,
-			Content:   replies[i].Content,
-			UserID:    replies[i].UserID,
-			CreatedAt: replies[i].CreatedAt,
-		}
+		items[i] = newReplyResp(&replies[i])
+	}
+	if err := s.fill(items); err != nil {
+		return nil, err
 	}
 
 	next := ""
@@ -205,7 +651,7 @@ func (s *ReplyService) ListByUserIDAfter(userID uint, cursorTime time.Time, curs
 	}, nil
 }
 
-func (s *ReplyService) Update(userID, id uint, req dto.UpdateReplyReq) (*dto.ReplyResp, error) {
+func (s *ReplyService) Update(userID, id, version uint, req dto.UpdateReplyReq) (*dto.ReplyResp, error) {
 	r, err := s.replyRepo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -216,19 +662,49 @@ func (s *ReplyService) Update(userID, id uint, req dto.UpdateReplyReq) (*dto.Rep
 	if r.UserID != userID {
 		return nil, ErrForbidden
 	}
+	if r.Version != version {
+		return nil, &VersionConflictError{Current: r.Version}
+	}
+
+	format := req.ContentFormat
+	if format == "" {
+		format = r.ContentFormat
+	}
+	format, html, err := renderContent(format, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reputation.requireLinks(userID, req.Content, html); err != nil {
+		return nil, err
+	}
 
 	r.Content = req.Content
+	r.ContentFormat = format
+	r.ContentHTML = html
 
 	if err := s.replyRepo.Update(r); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, s.conflict(id)
+		}
 		return nil, err
 	}
-	return &dto.ReplyResp{
-		ID:        r.ID,
-		ThreadID:  r.ThreadID,
-		Content:   r.Content,
-		UserID:    r.UserID,
-		CreatedAt: r.CreatedAt,
-	}, nil
+	s.mentions.sync(models.MentionSourceReply, r.ID, r.ThreadID, userID, r.Content)
+	items := []dto.ReplyResp{newReplyResp(r)}
+	if err := s.fill(items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *ReplyService) conflict(id uint) error {
+	r, err := s.replyRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return ErrReplyNotFound
+	}
+	return &VersionConflictError{Current: r.Version}
 }
 
 func (s *ReplyService) Delete(userID, id uint) error {
@@ -243,5 +719,23 @@ func (s *ReplyService) Delete(userID, id uint) error {
 		return ErrForbidden
 	}
 
-	return s.replyRepo.DeleteByID(id)
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+	rrWithTx, ok3 := s.replyRepo.(repository.ReplyRepoWithTx)
+
+	if ok1 && ok2 && ok3 {
+		err := txer.Transaction(func(tx *gorm.DB) error {
+			return deleteReply(trWithTx.WithTx(tx), rrWithTx.WithTx(tx), r)
+		})
+		if err != nil {
+			return err
+		}
+		// 事务内绕过了详情缓存，删除的可能是被采纳的回复
+		if refresher, ok := s.threadRepo.(repository.ThreadCacheRefresher); ok {
+			_ = refresher.RefreshCache(r.ThreadID)
+		}
+		return nil
+	}
+
+	return deleteReply(s.threadRepo, s.replyRepo, r)
 }
@@ -4,8 +4,12 @@ import (
 	"errors"
 	"exchangeapp/internal/dto"
 	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"strings"
 	"testing"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type fakeReplyRepo struct {
@@ -18,30 +22,42 @@ type fakeReplyRepo struct {
 	listAfterErr       error
 	listByUserAfterRes []models.Reply
 	countResult        int64
+	beforeCount        int64
 	countErr           error
 
 	updateErr error
 	deleteErr error
 
+	created   *models.Reply
+	latest    *models.Reply
 	updated   *models.Reply
 	deletedID uint
+
+	withDeleted map[uint]*models.Reply
+	children    []models.Reply
+	childCounts map[uint]int64
 }
 
-func (f *fakeReplyRepo) Create(*models.Reply) error {
+func (f *fakeReplyRepo) Create(r *models.Reply) error {
+	f.created = r
 	return nil
 }
 
-func (f *fakeReplyRepo) ListByThreadID(threadID uint, limit, offset int) ([]models.Reply, error) {
+func (f *fakeReplyRepo) ListByThreadID(threadID uint, desc bool, limit, offset int) ([]models.Reply, error) {
 	return f.listResult, f.listErr
 }
 
-func (f *fakeReplyRepo) ListByThreadIDAfter(threadID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+func (f *fakeReplyRepo) ListByThreadIDAfter(threadID uint, desc bool, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
 	if f.listAfterResult != nil || f.listAfterErr != nil {
 		return f.listAfterResult, f.listAfterErr
 	}
 	return f.listResult, f.listErr
 }
 
+func (f *fakeReplyRepo) CountByThreadIDBefore(uint, bool, time.Time, uint) (int64, error) {
+	return f.beforeCount, f.countErr
+}
+
 func (f *fakeReplyRepo) CountByThreadID(threadID uint) (int64, error) {
 	return f.countResult, f.countErr
 }
@@ -65,6 +81,25 @@ func (f *fakeReplyRepo) FindByID(id uint) (*models.Reply, error) {
 	return f.findResult, f.findErr
 }
 
+func (f *fakeReplyRepo) FindWithDeleted(id uint) (*models.Reply, error) {
+	if r, ok := f.withDeleted[id]; ok {
+		return r, nil
+	}
+	return f.findResult, f.findErr
+}
+
+func (f *fakeReplyRepo) ListChildren(uint, time.Time, uint, int) ([]models.Reply, error) {
+	return f.children, nil
+}
+
+func (f *fakeReplyRepo) CountChildren([]uint) (map[uint]int64, error) {
+	return f.childCounts, nil
+}
+
+func (f *fakeReplyRepo) FindLatestByThreadID(threadID uint) (*models.Reply, error) {
+	return f.latest, nil
+}
+
 func (f *fakeReplyRepo) Update(r *models.Reply) error {
 	f.updated = r
 	return f.updateErr
@@ -79,8 +114,14 @@ func TestReplyServiceListByThreadID(t *testing.T) {
 	svc := NewReplyService(
 		&fakeReplyRepo{},
 		&fakeThreadRepo{findResult: nil},
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
-	_, err := svc.ListByThreadID(1, 1, 10)
+	_, err := svc.ListByThreadID(1, false, 1, 10)
 	if !errors.Is(err, ErrThreadNotFound) {
 		t.Fatalf("expected ErrThreadNotFound, got %v", err)
 	}
@@ -89,8 +130,8 @@ func TestReplyServiceListByThreadID(t *testing.T) {
 		listResult:  []models.Reply{*reply(1, 2, 1)},
 		countResult: 1,
 	}
-	svc = NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)})
-	resp, err := svc.ListByThreadID(1, 1, 10)
+	svc = NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+	resp, err := svc.ListByThreadID(1, false, 1, 10)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -114,10 +155,10 @@ func TestReplyServiceUpdate(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			repo := &fakeReplyRepo{findResult: c.reply}
-			svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)})
+			svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
 
 			req := dto.UpdateReplyReq{Content: "new"}
-			_, err := svc.Update(c.userID, 1, req)
+			_, err := svc.Update(c.userID, 1, 0, req)
 
 			if !errors.Is(err, c.wantErr) {
 				t.Fatalf("expected %v, got %v", c.wantErr, err)
@@ -126,21 +167,146 @@ func TestReplyServiceUpdate(t *testing.T) {
 	}
 }
 
+func TestReplyServiceCreateRendersMarkdown(t *testing.T) {
+	repo := &fakeReplyRepo{}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	req := dto.CreateReplyReq{Content: "*hi*<script>x</script>", ContentFormat: "markdown"}
+	resp, err := svc.Create(1, 1, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.created == nil || repo.created.ContentFormat != "markdown" {
+		t.Fatalf("expected markdown reply stored, got %+v", repo.created)
+	}
+	if !strings.Contains(resp.ContentHTML, "<em>hi</em>") || strings.Contains(resp.ContentHTML, "<script") {
+		t.Fatalf("unexpected content_html: %q", resp.ContentHTML)
+	}
+	if repo.created.ContentHTML != resp.ContentHTML {
+		t.Fatalf("expected rendered html stored with reply")
+	}
+}
+
+func TestReplyServiceUpdateKeepsFormat(t *testing.T) {
+	old := reply(1, 1, 1)
+	old.ContentFormat = "markdown"
+	repo := &fakeReplyRepo{findResult: old}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.Update(1, 1, 0, dto.UpdateReplyReq{Content: "**b**"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ContentFormat != "markdown" || !strings.Contains(resp.ContentHTML, "<strong>b</strong>") {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+}
+
+func TestReplyServiceListAttachments(t *testing.T) {
+	replyRepo := &fakeReplyRepo{
+		listResult:  []models.Reply{*reply(1, 2, 1), *reply(2, 2, 1)},
+		countResult: 2,
+	}
+	uploads := &fakeUploadRepo{
+		byReply: []models.Upload{{Model: gormModel(5), ReplyID: 2}},
+	}
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)}, uploads, nil, nil, nil, nil, nil)
+
+	resp, err := svc.ListByThreadID(1, false, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items[0].Attachments) != 0 || len(resp.Items[1].Attachments) != 1 {
+		t.Fatalf("unexpected attachments: %+v", resp.Items)
+	}
+}
+
 func TestReplyServiceDelete(t *testing.T) {
 	repo := &fakeReplyRepo{findResult: reply(1, 1, 1)}
-	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)})
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
 
 	if err := svc.Delete(1, 1); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+func TestReplyServiceCreateUpdatesThreadStats(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	svc := NewReplyService(&fakeReplyRepo{}, threadRepo, nil, nil, nil, nil, nil, nil)
+
+	if _, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(threadRepo.replyDeltas) != 1 || threadRepo.replyDeltas[0] != 1 {
+		t.Fatalf("expected reply count +1, got %v", threadRepo.replyDeltas)
+	}
+	if threadRepo.lastReply == nil || threadRepo.lastReply.UserID != 2 {
+		t.Fatalf("expected last reply by user 2, got %+v", threadRepo.lastReply)
+	}
+}
+
+// 事务内直接调用 fn，WithTx 返回不带缓存的底层仓库
+type fakeTxThreadRepo struct {
+	*fakeRefreshingThreadRepo
+}
+
+func (f *fakeTxThreadRepo) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (f *fakeTxThreadRepo) WithTx(*gorm.DB) repository.ThreadRepository {
+	return f.fakeThreadRepo
+}
+
+type fakeTxReplyRepo struct {
+	*fakeReplyRepo
+}
+
+func (f *fakeTxReplyRepo) WithTx(*gorm.DB) repository.ReplyRepository {
+	return f.fakeReplyRepo
+}
+
+func TestReplyServiceCreateRefreshesThreadCache(t *testing.T) {
+	threadRepo := &fakeTxThreadRepo{&fakeRefreshingThreadRepo{fakeThreadRepo: &fakeThreadRepo{findResult: thread(1, 1)}}}
+	svc := NewReplyService(&fakeTxReplyRepo{&fakeReplyRepo{}}, threadRepo, nil, nil, nil, nil, nil, nil)
+
+	if _, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(threadRepo.replyDeltas) != 1 || threadRepo.replyDeltas[0] != 1 {
+		t.Fatalf("expected reply count +1, got %v", threadRepo.replyDeltas)
+	}
+	if len(threadRepo.refreshed) != 1 || threadRepo.refreshed[0] != 1 {
+		t.Fatalf("expected thread 1 cache refreshed, got %v", threadRepo.refreshed)
+	}
+}
+
+func TestReplyServiceDeleteUpdatesThreadStats(t *testing.T) {
+	prev := reply(3, 4, 1)
+	repo := &fakeReplyRepo{findResult: reply(5, 1, 1), latest: prev}
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	svc := NewReplyService(repo, threadRepo, nil, nil, nil, nil, nil, nil)
+
+	if err := svc.Delete(1, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.deletedID != 5 {
+		t.Fatalf("expected reply 5 deleted, got %d", repo.deletedID)
+	}
+	if len(threadRepo.replyDeltas) != 1 || threadRepo.replyDeltas[0] != -1 {
+		t.Fatalf("expected reply count -1, got %v", threadRepo.replyDeltas)
+	}
+	if threadRepo.lastReply != prev {
+		t.Fatalf("expected last reply recomputed, got %+v", threadRepo.lastReply)
+	}
+}
+
 func TestReplyServiceListByUserID(t *testing.T) {
 	repo := &fakeReplyRepo{
 		listResult:  []models.Reply{*reply(1, 1, 1)},
 		countResult: 1,
 	}
-	svc := NewReplyService(repo, &fakeThreadRepo{})
+	svc := NewReplyService(repo, &fakeThreadRepo{}, nil, nil, nil, nil, nil, nil)
 
 	resp, err := svc.ListByUserID(1, 1, 10)
 	if err != nil {
@@ -158,9 +324,9 @@ func TestReplyServiceListByThreadIDAfter(t *testing.T) {
 			{ID: 7, CreatedAt: ts, ThreadID: 1, UserID: 2, Content: "c"},
 		},
 	}
-	svc := NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)})
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
 
-	resp, err := svc.ListByThreadIDAfter(1, time.Unix(0, 1), 1, 10)
+	resp, err := svc.ListByThreadIDAfter(1, false, time.Unix(0, 1), 1, 10)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -179,7 +345,7 @@ func TestReplyServiceListByUserIDAfter(t *testing.T) {
 			{ID: 9, CreatedAt: ts, ThreadID: 1, UserID: 1, Content: "c"},
 		},
 	}
-	svc := NewReplyService(replyRepo, &fakeThreadRepo{})
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{}, nil, nil, nil, nil, nil, nil)
 
 	resp, err := svc.ListByUserIDAfter(1, time.Unix(0, 1), 1, 10)
 	if err != nil {
@@ -192,3 +358,189 @@ func TestReplyServiceListByUserIDAfter(t *testing.T) {
 		t.Fatalf("expected next_cursor 456_9, got %s", resp.NextCursor)
 	}
 }
+
+func TestReplyServiceUpdateVersionConflict(t *testing.T) {
+	replyRepo := &fakeReplyRepo{findResult: &models.Reply{ID: 1, UserID: 1, Version: 4}}
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{}, nil, nil, nil, nil, nil, nil)
+
+	_, err := svc.Update(1, 1, 3, dto.UpdateReplyReq{Content: "b"})
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Current != 4 {
+		t.Fatalf("expected version conflict with current 4, got %v", err)
+	}
+	if replyRepo.updated != nil {
+		t.Fatalf("expected no update on stale version")
+	}
+}
+
+func TestReplyServiceCreateNested(t *testing.T) {
+	repo := &fakeReplyRepo{findResult: &models.Reply{ID: 5, ThreadID: 1, Depth: 1}}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "c", ParentID: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.created.ParentID != 5 || repo.created.Depth != 2 || resp.ParentID != 5 || resp.Depth != 2 {
+		t.Fatalf("unexpected nested reply: %+v", repo.created)
+	}
+
+	repo.findResult = &models.Reply{ID: 5, ThreadID: 3}
+	if _, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "c", ParentID: 5}); !errors.Is(err, ErrInvalidParent) {
+		t.Fatalf("expected ErrInvalidParent, got %v", err)
+	}
+
+	repo.findResult = &models.Reply{ID: 5, ThreadID: 1, Depth: maxReplyDepth}
+	if _, err := svc.Create(2, 1, dto.CreateReplyReq{Content: "c", ParentID: 5}); !errors.Is(err, ErrReplyTooDeep) {
+		t.Fatalf("expected ErrReplyTooDeep, got %v", err)
+	}
+}
+
+func TestReplyServiceListChildrenTombstone(t *testing.T) {
+	deleted := &models.Reply{ID: 5, ThreadID: 1, UserID: 2, Content: "secret"}
+	deleted.DeletedAt.Valid = true
+	repo := &fakeReplyRepo{
+		withDeleted: map[uint]*models.Reply{5: deleted},
+		children:    []models.Reply{{ID: 6, ThreadID: 1, ParentID: 5, Depth: 1, Content: "child"}},
+		childCounts: map[uint]int64{5: 1},
+	}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.ListChildren(5, time.Time{}, 0, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Reply.Deleted || resp.Reply.Content != "" || resp.Reply.UserID != 0 || resp.Reply.ReplyCount != 1 {
+		t.Fatalf("expected tombstone, got %+v", resp.Reply)
+	}
+	if len(resp.Children) != 1 || resp.Children[0].Content != "child" {
+		t.Fatalf("unexpected children: %+v", resp.Children)
+	}
+
+	repo.childCounts = nil
+	repo.children = nil
+	if _, err := svc.ListChildren(5, time.Time{}, 0, 20); !errors.Is(err, ErrReplyNotFound) {
+		t.Fatalf("expected ErrReplyNotFound for childless tombstone, got %v", err)
+	}
+}
+
+func TestReplyServiceContext(t *testing.T) {
+	mid := &models.Reply{ID: 6, ThreadID: 1, ParentID: 5, Depth: 1, Content: "gone"}
+	mid.DeletedAt.Valid = true
+	repo := &fakeReplyRepo{withDeleted: map[uint]*models.Reply{
+		5: {ID: 5, ThreadID: 1, Content: "root"},
+		6: mid,
+		7: {ID: 7, ThreadID: 1, ParentID: 6, Depth: 2, Content: "leaf"},
+	}}
+	svc := NewReplyService(repo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.Context(7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Reply.ID != 7 || len(resp.Ancestors) != 2 {
+		t.Fatalf("unexpected context: %+v", resp)
+	}
+	if resp.Ancestors[0].ID != 5 || resp.Ancestors[0].Content != "root" {
+		t.Fatalf("expected root first, got %+v", resp.Ancestors[0])
+	}
+	if resp.Ancestors[1].ID != 6 || !resp.Ancestors[1].Deleted || resp.Ancestors[1].Content != "" {
+		t.Fatalf("expected tombstone ancestor, got %+v", resp.Ancestors[1])
+	}
+}
+
+func TestReplyServiceLocate(t *testing.T) {
+	target := &models.Reply{ID: 30, ThreadID: 1, CreatedAt: time.Unix(0, 300)}
+	replyRepo := &fakeReplyRepo{
+		findResult:  target,
+		beforeCount: 25,
+		listResult:  []models.Reply{{ID: 19, ThreadID: 1, CreatedAt: time.Unix(0, 190)}},
+	}
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{findResult: thread(1, 1)}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.Locate(30, false, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Page != 3 || resp.Size != 10 || resp.Cursor != "190_19" || resp.ThreadID != 1 {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+
+	replyRepo.beforeCount = 4
+	resp, err = svc.Locate(30, true, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Page != 1 || resp.Cursor != "" {
+		t.Fatalf("expected first page without cursor, got %+v", resp)
+	}
+
+	svc = NewReplyService(replyRepo, &fakeThreadRepo{}, nil, nil, nil, nil, nil, nil)
+	if _, err := svc.Locate(30, false, 10); !errors.Is(err, ErrReplyNotFound) {
+		t.Fatalf("expected ErrReplyNotFound, got %v", err)
+	}
+}
+
+func TestReplyServiceAccept(t *testing.T) {
+	question := &models.Thread{ID: 1, UserID: 1, Type: models.ThreadTypeQuestion}
+	threadRepo := &fakeThreadRepo{findResult: question}
+	replyRepo := &fakeReplyRepo{findResult: reply(5, 2, 1)}
+	svc := NewReplyService(replyRepo, threadRepo, nil, nil, nil, nil, nil, nil)
+
+	if _, err := svc.Accept(Actor{UserID: 2}, 1, 5); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	resp, err := svc.Accept(Actor{UserID: 1}, 1, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.AcceptedReplyID != 5 {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+	if _, err := svc.Accept(Actor{UserID: 9, Role: models.RoleModerator}, 1, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(threadRepo.accepted) != 2 || threadRepo.accepted[0] != 5 || threadRepo.accepted[1] != 0 {
+		t.Fatalf("unexpected accepted calls: %v", threadRepo.accepted)
+	}
+
+	replyRepo.findResult = reply(6, 2, 3)
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 6); !errors.Is(err, ErrReplyNotFound) {
+		t.Fatalf("expected ErrReplyNotFound, got %v", err)
+	}
+
+	threadRepo.findResult = thread(1, 1)
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 5); !errors.Is(err, ErrNotQuestion) {
+		t.Fatalf("expected ErrNotQuestion, got %v", err)
+	}
+}
+
+func TestReplyServiceListPinsAcceptedReply(t *testing.T) {
+	question := &models.Thread{ID: 1, UserID: 1, Type: models.ThreadTypeQuestion, AcceptedReplyID: 9}
+	replyRepo := &fakeReplyRepo{
+		findResult: reply(9, 2, 1),
+		listResult: []models.Reply{*reply(3, 2, 1), *reply(9, 2, 1)},
+	}
+	svc := NewReplyService(replyRepo, &fakeThreadRepo{findResult: question}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.ListByThreadID(1, false, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 置顶后不再在原有位置重复出现
+	if len(resp.Items) != 2 || resp.Items[0].ID != 9 || !resp.Items[0].Accepted || resp.Items[1].ID != 3 || resp.Items[1].Accepted {
+		t.Fatalf("expected accepted reply pinned first only once, got %+v", resp.Items)
+	}
+
+	resp, err = svc.ListByThreadID(1, false, 2, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ID != 3 {
+		t.Fatalf("expected no pin and no accepted reply after first page, got %+v", resp.Items)
+	}
+	if resp.NextCursor == "" {
+		t.Fatalf("expected next cursor from the last listed reply")
+	}
+}
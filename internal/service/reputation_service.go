@@ -0,0 +1,177 @@
+package service
+
+import (
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 排行时间范围：day、week 为最近 24 小时、7 天内的流水合计，all 为累计值
+const (
+	LeaderboardDay  = "day"
+	LeaderboardWeek = "week"
+	LeaderboardAll  = "all"
+)
+
+// 权限名，对应配置 reputation.privileges 中的键
+const PrivilegePostLinks = "post_links"
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S`)
+
+// 声望流水与权限门槛；为 nil 时不记录声望、不限制权限
+type ReputationService struct {
+	repo         repository.ReputationRepository
+	users        repository.UserRepository
+	likePoints   int64
+	acceptPoints int64
+	privileges   map[string]int64
+	now          func() time.Time
+}
+
+func NewReputationService(repo repository.ReputationRepository, users repository.UserRepository, cfg config.ReputationConfig) *ReputationService {
+	return &ReputationService{
+		repo:         repo,
+		users:        users,
+		likePoints:   cfg.ThreadLiked,
+		acceptPoints: cfg.AnswerAccepted,
+		privileges:   cfg.Privileges,
+		now:          time.Now,
+	}
+}
+
+// 与调用方的写入放在同一事务时传入 tx，否则传 nil
+func (s *ReputationService) repoFor(tx *gorm.DB) repository.ReputationRepository {
+	if s == nil {
+		return nil
+	}
+	if rrWithTx, ok := s.repo.(repository.ReputationRepoWithTx); ok && tx != nil {
+		return rrWithTx.WithTx(tx)
+	}
+	return s.repo
+}
+
+// 帖子被点赞或取消点赞，delta 为 1 或 -1；给自己点赞不计声望
+func (s *ReputationService) threadLiked(rr repository.ReputationRepository, likerID uint, t *models.Thread, delta int) error {
+	if s == nil || s.likePoints == 0 || likerID == t.UserID {
+		return nil
+	}
+	reason := models.ReputationThreadLiked
+	if delta < 0 {
+		reason = models.ReputationThreadUnliked
+	}
+	return rr.Append(&models.ReputationEvent{
+		UserID:     t.UserID,
+		Delta:      s.likePoints * int64(delta),
+		Reason:     reason,
+		SourceType: models.ReputationSourceThread,
+		SourceID:   t.ID,
+		ActorID:    likerID,
+	})
+}
+
+// 回复被采纳或取消采纳，delta 为 1 或 -1；帖子作者采纳自己的回复不计声望
+func (s *ReputationService) answerAccepted(rr repository.ReputationRepository, actorID uint, t *models.Thread, r *models.Reply, delta int) error {
+	if s == nil || s.acceptPoints == 0 || r.UserID == t.UserID {
+		return nil
+	}
+	reason := models.ReputationAnswerAccepted
+	if delta < 0 {
+		reason = models.ReputationAnswerUnaccepted
+	}
+	return rr.Append(&models.ReputationEvent{
+		UserID:     r.UserID,
+		Delta:      s.acceptPoints * int64(delta),
+		Reason:     reason,
+		SourceType: models.ReputationSourceReply,
+		SourceID:   r.ID,
+		ActorID:    actorID,
+	})
+}
+
+func (s *ReputationService) require(userID uint, privilege string) error {
+	if s == nil {
+		return nil
+	}
+	threshold := s.privileges[privilege]
+	if threshold <= 0 {
+		return nil
+	}
+	total, err := s.repo.Total(userID)
+	if err != nil {
+		return err
+	}
+	if total < threshold {
+		return ErrReputationTooLow
+	}
+	return nil
+}
+
+// 内容中含有链接时检查发布链接的权限；markdown 渲染出的 <a> 与纯文本中的网址都算链接
+func (s *ReputationService) requireLinks(userID uint, content, html string) error {
+	if !strings.Contains(html, "<a ") && !linkPattern.MatchString(content) {
+		return nil
+	}
+	return s.require(userID, PrivilegePostLinks)
+}
+
+// 版主扣除用户声望
+func (s *ReputationService) Penalize(actor Actor, userID uint, req dto.PenaltyReq) (*dto.ReputationResp, error) {
+	if !actor.IsModerator() {
+		return nil, ErrForbidden
+	}
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.repo.Append(&models.ReputationEvent{
+		UserID:  userID,
+		Delta:   -req.Points,
+		Reason:  models.ReputationPenalty,
+		ActorID: actor.UserID,
+		Note:    req.Note,
+	}); err != nil {
+		return nil, err
+	}
+	total, err := s.repo.Total(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ReputationResp{UserID: userID, Reputation: total}, nil
+}
+
+func (s *ReputationService) Leaderboard(window string, size int) (*dto.LeaderboardResp, error) {
+	var since time.Time
+	switch window {
+	case LeaderboardDay:
+		since = s.now().Add(-24 * time.Hour)
+	case LeaderboardWeek:
+		since = s.now().Add(-7 * 24 * time.Hour)
+	case LeaderboardAll:
+	default:
+		return nil, ErrInvalidWindow
+	}
+
+	rows, err := s.repo.Leaderboard(since, size)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.LeaderboardItem, len(rows))
+	for i, row := range rows {
+		items[i] = dto.LeaderboardItem{
+			Rank:       i + 1,
+			UserID:     row.UserID,
+			Username:   row.Username,
+			Reputation: row.Reputation,
+		}
+	}
+	return &dto.LeaderboardResp{Window: window, Items: items}, nil
+}
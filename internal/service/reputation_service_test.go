@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"testing"
+	"time"
+)
+
+type fakeReputationRepo struct {
+	events []models.ReputationEvent
+	totals map[uint]int64
+	since  []time.Time
+	ranks  []repository.ReputationRank
+}
+
+func (f *fakeReputationRepo) Append(e *models.ReputationEvent) error {
+	f.events = append(f.events, *e)
+	if f.totals == nil {
+		f.totals = make(map[uint]int64)
+	}
+	f.totals[e.UserID] += e.Delta
+	return nil
+}
+
+func (f *fakeReputationRepo) Total(userID uint) (int64, error) {
+	return f.totals[userID], nil
+}
+
+func (f *fakeReputationRepo) Leaderboard(since time.Time, limit int) ([]repository.ReputationRank, error) {
+	f.since = append(f.since, since)
+	return f.ranks, nil
+}
+
+func newTestReputation(repo *fakeReputationRepo, users *fakeUserRepo) *ReputationService {
+	return NewReputationService(repo, users, config.ReputationConfig{
+		ThreadLiked:    5,
+		AnswerAccepted: 15,
+		Privileges:     map[string]int64{PrivilegePostLinks: 10},
+	})
+}
+
+func TestThreadLikeServiceRecordsReputation(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 7)}
+	repRepo := &fakeReputationRepo{}
+	svc := NewThreadLikeService(threadRepo, &fakeThreadLikeRepo{}, threadRepo, nil, nil, nil, nil, newTestReputation(repRepo, &fakeUserRepo{}))
+
+	if err := svc.Like(2, 1); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+	if err := svc.Unlike(2, 1); err != nil {
+		t.Fatalf("unlike: %v", err)
+	}
+	// 给自己点赞不计声望
+	if err := svc.Like(7, 1); err != nil {
+		t.Fatalf("self like: %v", err)
+	}
+
+	if len(repRepo.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", repRepo.events)
+	}
+	liked, unliked := repRepo.events[0], repRepo.events[1]
+	if liked.UserID != 7 || liked.Delta != 5 || liked.Reason != models.ReputationThreadLiked || liked.ActorID != 2 || liked.SourceID != 1 {
+		t.Fatalf("unexpected like event: %+v", liked)
+	}
+	if unliked.Delta != -5 || unliked.Reason != models.ReputationThreadUnliked {
+		t.Fatalf("unexpected unlike event: %+v", unliked)
+	}
+	if repRepo.totals[7] != 0 {
+		t.Fatalf("expected total 0, got %d", repRepo.totals[7])
+	}
+}
+
+func TestThreadLikeServiceLikeFailureSkipsReputation(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 7)}
+	repRepo := &fakeReputationRepo{}
+	likeRepo := &fakeThreadLikeRepo{createErr: repository.ErrAlreadyLiked}
+	svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo, nil, nil, nil, nil, newTestReputation(repRepo, &fakeUserRepo{}))
+
+	if err := svc.Like(2, 1); !errors.Is(err, repository.ErrAlreadyLiked) {
+		t.Fatalf("expected ErrAlreadyLiked, got %v", err)
+	}
+	if len(repRepo.events) != 0 {
+		t.Fatalf("expected no events, got %+v", repRepo.events)
+	}
+}
+
+func TestReplyServiceAcceptRecordsReputation(t *testing.T) {
+	question := &models.Thread{ID: 1, UserID: 1, Type: models.ThreadTypeQuestion}
+	threadRepo := &fakeThreadRepo{findResult: question}
+	replyRepo := &fakeReplyRepo{findResult: reply(5, 2, 1)}
+	repRepo := &fakeReputationRepo{}
+	svc := NewReplyService(replyRepo, threadRepo, nil, nil, nil, nil, nil, newTestReputation(repRepo, &fakeUserRepo{}))
+
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 5); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	// 重复采纳同一回复不重复计声望
+	question.AcceptedReplyID = 5
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 5); err != nil {
+		t.Fatalf("accept again: %v", err)
+	}
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 0); err != nil {
+		t.Fatalf("unaccept: %v", err)
+	}
+
+	if len(repRepo.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", repRepo.events)
+	}
+	if e := repRepo.events[0]; e.UserID != 2 || e.Delta != 15 || e.Reason != models.ReputationAnswerAccepted {
+		t.Fatalf("unexpected accept event: %+v", e)
+	}
+	if e := repRepo.events[1]; e.UserID != 2 || e.Delta != -15 || e.Reason != models.ReputationAnswerUnaccepted {
+		t.Fatalf("unexpected unaccept event: %+v", e)
+	}
+}
+
+// 条件更新未命中：采纳答案已被并发修改
+type fakeRacingThreadRepo struct {
+	*fakeThreadRepo
+}
+
+func (f *fakeRacingThreadRepo) SetAcceptedReply(uint, uint, uint) (bool, error) {
+	return false, nil
+}
+
+func TestReplyServiceAcceptSkipsReputationWhenChanged(t *testing.T) {
+	question := &models.Thread{ID: 1, UserID: 1, Type: models.ThreadTypeQuestion}
+	threadRepo := &fakeRacingThreadRepo{fakeThreadRepo: &fakeThreadRepo{findResult: question}}
+	repRepo := &fakeReputationRepo{}
+	svc := NewReplyService(&fakeReplyRepo{findResult: reply(5, 2, 1)}, threadRepo, nil, nil, nil, nil, nil, newTestReputation(repRepo, &fakeUserRepo{}))
+
+	if _, err := svc.Accept(Actor{UserID: 1}, 1, 5); !errors.Is(err, ErrAcceptedReplyChanged) {
+		t.Fatalf("expected ErrAcceptedReplyChanged, got %v", err)
+	}
+	if len(repRepo.events) != 0 {
+		t.Fatalf("expected no events, got %+v", repRepo.events)
+	}
+}
+
+func TestThreadServiceCreateRequiresReputationForLinks(t *testing.T) {
+	repRepo := &fakeReputationRepo{totals: map[uint]int64{1: 3}}
+	repo := &fakeThreadRepo{}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, newTestReputation(repRepo, &fakeUserRepo{}))
+
+	cases := []struct {
+		name    string
+		format  string
+		content string
+		wantErr error
+	}{
+		{"plain_url", "plain", "see https://example.com", ErrReputationTooLow},
+		{"www", "plain", "see www.example.com", ErrReputationTooLow},
+		{"markdown_link", "markdown", "[mail](mailto:a@example.com)", ErrReputationTooLow},
+		{"no_link", "markdown", "**hello**", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.Create(1, dto.CreateThreadReq{Title: "t", Content: c.content, ContentFormat: c.format})
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("expected %v, got %v", c.wantErr, err)
+			}
+		})
+	}
+
+	repRepo.totals[1] = 10
+	if _, err := svc.Create(1, dto.CreateThreadReq{Title: "t", Content: "see https://example.com"}); err != nil {
+		t.Fatalf("expected link allowed at threshold, got %v", err)
+	}
+}
+
+func TestReputationServicePenalize(t *testing.T) {
+	repRepo := &fakeReputationRepo{totals: map[uint]int64{2: 30}}
+	users := &fakeUserRepo{users: []models.User{{Model: gormModel(2), Username: "bob"}}}
+	svc := newTestReputation(repRepo, users)
+	req := dto.PenaltyReq{Points: 20, Note: "spam"}
+
+	if _, err := svc.Penalize(Actor{UserID: 1}, 2, req); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	mod := Actor{UserID: 9, Role: models.RoleModerator}
+	if _, err := svc.Penalize(mod, 3, req); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+	resp, err := svc.Penalize(mod, 2, req)
+	if err != nil {
+		t.Fatalf("penalize: %v", err)
+	}
+	if resp.Reputation != 10 {
+		t.Fatalf("expected reputation 10, got %d", resp.Reputation)
+	}
+	if e := repRepo.events[0]; e.Delta != -20 || e.Reason != models.ReputationPenalty || e.ActorID != 9 || e.Note != "spam" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+func TestReputationServiceLeaderboard(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	repRepo := &fakeReputationRepo{ranks: []repository.ReputationRank{{UserID: 3, Username: "a", Reputation: 9}, {UserID: 1, Username: "b", Reputation: 4}}}
+	svc := newTestReputation(repRepo, &fakeUserRepo{})
+	svc.now = func() time.Time { return now }
+
+	resp, err := svc.Leaderboard(LeaderboardWeek, 10)
+	if err != nil {
+		t.Fatalf("leaderboard: %v", err)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].Rank != 1 || resp.Items[1].Rank != 2 || resp.Items[1].UserID != 1 {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+	if _, err := svc.Leaderboard(LeaderboardDay, 10); err != nil {
+		t.Fatalf("leaderboard: %v", err)
+	}
+	if _, err := svc.Leaderboard(LeaderboardAll, 10); err != nil {
+		t.Fatalf("leaderboard: %v", err)
+	}
+	want := []time.Time{now.Add(-7 * 24 * time.Hour), now.Add(-24 * time.Hour), {}}
+	for i, since := range repRepo.since {
+		if !since.Equal(want[i]) {
+			t.Fatalf("window %d: expected since %v, got %v", i, want[i], since)
+		}
+	}
+
+	if _, err := svc.Leaderboard("month", 10); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("expected ErrInvalidWindow, got %v", err)
+	}
+}
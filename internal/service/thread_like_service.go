@@ -1,8 +1,12 @@
 package service
 
 import (
+	"errors"
+	"exchangeapp/internal/dto"
 	"exchangeapp/internal/models"
 	"exchangeapp/internal/repository"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -10,116 +14,342 @@ import (
 type ThreadLikeService struct {
 	threadRepo repository.ThreadRepository
 	likeRepo   repository.ThreadLikeRepository
-	counter    repository.ThreadLikeCounter
+	counter    repository.LikeCounter
+	reactions  repository.ReactionCounter
+	types      ReactionSet
+	likedCache repository.LikedThreadCache
+	// 为 nil 时未开启踩
+	downvotes  repository.LikeCounter
+	reputation *ReputationService
 }
 
 func NewThreadLikeService(
 	threadRepo repository.ThreadRepository,
 	likeRepo repository.ThreadLikeRepository,
-	counter repository.ThreadLikeCounter) *ThreadLikeService {
+	counter repository.LikeCounter,
+	reactions repository.ReactionCounter,
+	types ReactionSet,
+	likedCache repository.LikedThreadCache,
+	downvotes repository.LikeCounter,
+	reputation *ReputationService) *ThreadLikeService {
 	return &ThreadLikeService{
 		threadRepo: threadRepo,
 		likeRepo:   likeRepo,
 		counter:    counter,
+		reactions:  reactions,
+		types:      types,
+		likedCache: likedCache,
+		downvotes:  downvotes,
+		reputation: reputation,
 	}
 }
 
+// 点赞即 like 回应
 func (s *ThreadLikeService) Like(userID, threadID uint) error {
-	txer, ok1 := s.threadRepo.(repository.Transactioner)
-	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
-	lrWithTx, ok3 := s.likeRepo.(repository.ThreadLikeRepoWithTx)
-	ctrWithTx, ok4 := s.counter.(repository.ThreadLikeCounterWithTx)
+	return s.React(userID, threadID, models.ReactionLike)
+}
 
-	if ok1 && ok2 && ok3 && ok4 {
-		return txer.Transaction(func(tx *gorm.DB) error {
-			tr := trWithTx.WithTx(tx)
-			lr := lrWithTx.WithTx(tx)
-			ctr := ctrWithTx.WithTx(tx)
+func (s *ThreadLikeService) Unlike(userID, threadID uint) error {
+	return s.Unreact(userID, threadID, models.ReactionLike)
+}
 
-			t, err := tr.FindByID(threadID)
-			if err != nil {
-				return err
-			}
-			if t == nil {
-				return ErrThreadNotFound
-			}
+func (s *ThreadLikeService) IsLiked(userID, threadID uint) (bool, error) {
+	if err := s.ensureThread(s.threadRepo, threadID); err != nil {
+		return false, err
+	}
+	return s.likeRepo.Exists(userID, threadID, models.ReactionLike)
+}
 
-			if err := lr.Create(&models.ThreadLike{
-				UserID:   userID,
-				ThreadID: threadID,
-			}); err != nil {
-				return err
-			}
-			return ctr.IncrementLikeCount(threadID, 1)
+func (s *ThreadLikeService) React(userID, threadID uint, reaction string) error {
+	if !s.types.Has(reaction) {
+		return ErrInvalidReaction
+	}
+	return s.react(userID, threadID, reaction)
+}
+
+func (s *ThreadLikeService) Unreact(userID, threadID uint, reaction string) error {
+	if !s.types.Has(reaction) {
+		return ErrInvalidReaction
+	}
+	return s.unreact(userID, threadID, reaction)
+}
+
+// 重复投同一票不报错；改投时在同一事务内撤掉原来的票
+func (s *ThreadLikeService) Vote(userID, threadID uint, vote string) (*dto.VoteResp, error) {
+	reaction := voteReaction(vote)
+	if reaction == models.ReactionDownvote && s.downvotes == nil {
+		return nil, ErrDownvoteDisabled
+	}
+	if err := s.react(userID, threadID, reaction); err != nil && !errors.Is(err, repository.ErrAlreadyLiked) {
+		return nil, err
+	}
+	return s.VoteStatus(userID, threadID)
+}
+
+func (s *ThreadLikeService) Unvote(userID, threadID uint) (*dto.VoteResp, error) {
+	for _, reaction := range []string{models.ReactionLike, models.ReactionDownvote} {
+		if reaction == models.ReactionDownvote && s.downvotes == nil {
+			continue
+		}
+		if err := s.unreact(userID, threadID, reaction); err != nil && !errors.Is(err, repository.ErrLikeNotFound) {
+			return nil, err
+		}
+	}
+	return s.VoteStatus(userID, threadID)
+}
+
+func (s *ThreadLikeService) VoteStatus(userID, threadID uint) (*dto.VoteResp, error) {
+	if err := s.ensureThread(s.threadRepo, threadID); err != nil {
+		return nil, err
+	}
+	mine, err := s.likeRepo.ListReactions(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	likeCount, err := s.counter.GetLikeCount(threadID)
+	if err != nil {
+		return nil, err
+	}
+	var downvoteCount int64
+	if s.downvotes != nil {
+		if downvoteCount, err = s.downvotes.GetLikeCount(threadID); err != nil {
+			return nil, err
+		}
+	}
+	return newVoteResp(mine, likeCount, downvoteCount), nil
+}
+
+func (s *ThreadLikeService) react(userID, threadID uint, reaction string) error {
+	defer s.invalidateLiked(userID, reaction)
+	return s.change(userID, threadID, reaction, 1, func(lr repository.ThreadLikeRepository) error {
+		return lr.Create(&models.ThreadLike{
+			UserID:   userID,
+			ThreadID: threadID,
+			Reaction: reaction,
 		})
+	})
+}
+
+func (s *ThreadLikeService) unreact(userID, threadID uint, reaction string) error {
+	defer s.invalidateLiked(userID, reaction)
+	return s.change(userID, threadID, reaction, -1, func(lr repository.ThreadLikeRepository) error {
+		return lr.Delete(userID, threadID, reaction)
+	})
+}
+
+// 批量查询点赞状态，先查用户最近点赞缓存，缓存无法确定的再查一次库
+func (s *ThreadLikeService) BatchStatus(userID uint, threadIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool, len(threadIDs))
+	misses := threadIDs
+	if s.likedCache != nil {
+		misses = s.cachedStatus(userID, threadIDs, liked)
+	}
+	if len(misses) == 0 {
+		return liked, nil
 	}
 
-	t, err := s.threadRepo.FindByID(threadID)
+	ids, err := s.likeRepo.ListLikedThreadIDs(userID, misses)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if t == nil {
-		return ErrThreadNotFound
+	for _, id := range misses {
+		liked[id] = false
 	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
 
-	if err := s.likeRepo.Create(&models.ThreadLike{
-		UserID:   userID,
-		ThreadID: threadID,
-	}); err != nil {
-		return err
+// 填入缓存能确定的结果，返回仍需查库的 ID；缓存出错时全部查库
+func (s *ThreadLikeService) cachedStatus(userID uint, threadIDs []uint, liked map[uint]bool) []uint {
+	hits, complete, err := s.likedCache.Contains(userID, threadIDs)
+	if errors.Is(err, repository.ErrLikedCacheMiss) {
+		hits, complete, err = s.loadLiked(userID, threadIDs)
+	}
+	if err != nil {
+		return threadIDs
 	}
 
-	return s.counter.IncrementLikeCount(threadID, 1)
+	var misses []uint
+	for i, id := range threadIDs {
+		switch {
+		case hits[i]:
+			liked[id] = true
+		case complete:
+			liked[id] = false
+		default:
+			misses = append(misses, id)
+		}
+	}
+	return misses
 }
 
-func (s *ThreadLikeService) Unlike(userID, threadID uint) error {
-	txer, ok1 := s.threadRepo.(repository.Transactioner)
-	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
-	lrWithTx, ok3 := s.likeRepo.(repository.ThreadLikeRepoWithTx)
-	ctrWithTx, ok4 := s.counter.(repository.ThreadLikeCounterWithTx)
+func (s *ThreadLikeService) loadLiked(userID uint, threadIDs []uint) ([]bool, bool, error) {
+	recent, err := s.likeRepo.ListRecentLikedThreadIDs(userID, repository.LikedThreadCacheSize+1)
+	if err != nil {
+		return nil, false, err
+	}
+	complete := len(recent) <= repository.LikedThreadCacheSize
+	if !complete {
+		recent = recent[:repository.LikedThreadCacheSize]
+	}
+	_ = s.likedCache.Store(userID, recent, complete)
 
-	if ok1 && ok2 && ok3 && ok4 {
-		return txer.Transaction(func(tx *gorm.DB) error {
-			tr := trWithTx.WithTx(tx)
-			lr := lrWithTx.WithTx(tx)
-			ctr := ctrWithTx.WithTx(tx)
+	set := make(map[uint]bool, len(recent))
+	for _, id := range recent {
+		set[id] = true
+	}
+	hits := make([]bool, len(threadIDs))
+	for i, id := range threadIDs {
+		hits[i] = set[id]
+	}
+	return hits, complete, nil
+}
 
-			t, err := tr.FindByID(threadID)
-			if err != nil {
-				return err
-			}
-			if t == nil {
-				return ErrThreadNotFound
-			}
+// 踩会撤掉点赞，同样需要失效
+func (s *ThreadLikeService) invalidateLiked(userID uint, reaction string) {
+	if (reaction == models.ReactionLike || reaction == models.ReactionDownvote) && s.likedCache != nil {
+		_ = s.likedCache.Invalidate(userID)
+	}
+}
 
-			if err := lr.Delete(userID, threadID); err != nil {
-				return err
-			}
-			return ctr.IncrementLikeCount(threadID, -1)
-		})
+func (s *ThreadLikeService) Reactions(userID, threadID uint) (*dto.ReactionsResp, error) {
+	if err := s.ensureThread(s.threadRepo, threadID); err != nil {
+		return nil, err
+	}
+	likeCount, err := s.counter.GetLikeCount(threadID)
+	if err != nil {
+		return nil, err
+	}
+	var counts map[string]int64
+	if s.reactions != nil {
+		if counts, err = s.reactions.GetReactionCounts(threadID); err != nil {
+			return nil, err
+		}
+	}
+	mine, err := s.likeRepo.ListReactions(userID, threadID)
+	if err != nil {
+		return nil, err
 	}
+	return &dto.ReactionsResp{Counts: mergeReactionCounts(likeCount, counts), Mine: withoutDownvote(mine)}, nil
+}
 
-	t, err := s.threadRepo.FindByID(threadID)
+func (s *ThreadLikeService) ListLikers(threadID uint, size int) (*dto.ThreadLikerListResp, error) {
+	if err := s.ensureThread(s.threadRepo, threadID); err != nil {
+		return nil, err
+	}
+	rows, err := s.likeRepo.ListLikers(threadID, size)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if t == nil {
-		return ErrThreadNotFound
+	return likersResp(rows, size), nil
+}
+
+func (s *ThreadLikeService) ListLikersAfter(threadID uint, cursorTime time.Time, cursorID uint, size int) (*dto.ThreadLikerListResp, error) {
+	if err := s.ensureThread(s.threadRepo, threadID); err != nil {
+		return nil, err
+	}
+	rows, err := s.likeRepo.ListLikersAfter(threadID, cursorTime, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+	return likersResp(rows, size), nil
+}
+
+func likersResp(rows []repository.ThreadLiker, size int) *dto.ThreadLikerListResp {
+	items := make([]dto.ThreadLikerResp, len(rows))
+	for i, row := range rows {
+		items[i] = dto.ThreadLikerResp{
+			UserID:   row.UserID,
+			Username: row.Username,
+			LikedAt:  row.CreatedAt,
+		}
 	}
 
-	if err := s.likeRepo.Delete(userID, threadID); err != nil {
-		return err
+	next := ""
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		next = fmt.Sprintf("%d_%d", last.CreatedAt.UnixNano(), last.ID)
+	}
+
+	return &dto.ThreadLikerListResp{
+		Items:      items,
+		Size:       size,
+		NextCursor: next,
 	}
-	return s.counter.IncrementLikeCount(threadID, -1)
 }
 
-func (s *ThreadLikeService) IsLiked(userID, threadID uint) (bool, error) {
-	t, err := s.threadRepo.FindByID(threadID)
+func (s *ThreadLikeService) ensureThread(tr repository.ThreadRepository, threadID uint) error {
+	_, err := s.findThread(tr, threadID)
+	return err
+}
+
+func (s *ThreadLikeService) findThread(tr repository.ThreadRepository, threadID uint) (*models.Thread, error) {
+	t, err := tr.FindByID(threadID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if t == nil {
-		return false, ErrThreadNotFound
+		return nil, ErrThreadNotFound
+	}
+	return t, nil
+}
+
+// like 计入点赞数并给帖子作者记声望，downvote 计入踩数，其余回应计入回应计数
+func (s *ThreadLikeService) change(userID, threadID uint, reaction string, delta int, write func(repository.ThreadLikeRepository) error) error {
+	apply := func(tr repository.ThreadRepository, lr repository.ThreadLikeRepository, ctr repository.LikeCounter, rr repository.ReputationRepository) error {
+		t, err := s.findThread(tr, threadID)
+		if err != nil {
+			return err
+		}
+		tally := func(reaction string, delta int) error {
+			if err := s.count(ctr, threadID, reaction, delta); err != nil {
+				return err
+			}
+			if reaction != models.ReactionLike {
+				return nil
+			}
+			return s.reputation.threadLiked(rr, userID, t, delta)
+		}
+		if delta > 0 && s.downvotes != nil {
+			if err := dropOppositeVote(lr, userID, threadID, reaction, func(opposite string) error {
+				return tally(opposite, -1)
+			}); err != nil {
+				return err
+			}
+		}
+		if err := write(lr); err != nil {
+			return err
+		}
+		return tally(reaction, delta)
+	}
+
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+	lrWithTx, ok3 := s.likeRepo.(repository.ThreadLikeRepoWithTx)
+	ctrWithTx, ok4 := s.counter.(repository.LikeCounterWithTx)
+
+	if ok1 && ok2 && ok3 && ok4 {
+		return txer.Transaction(func(tx *gorm.DB) error {
+			return apply(trWithTx.WithTx(tx), lrWithTx.WithTx(tx), ctrWithTx.WithTx(tx), s.reputation.repoFor(tx))
+		})
+	}
+	return apply(s.threadRepo, s.likeRepo, s.counter, s.reputation.repoFor(nil))
+}
+
+func (s *ThreadLikeService) count(ctr repository.LikeCounter, threadID uint, reaction string, delta int) error {
+	switch reaction {
+	case models.ReactionLike:
+		return ctr.IncrementLikeCount(threadID, delta)
+	case models.ReactionDownvote:
+		if s.downvotes == nil {
+			return nil
+		}
+		return s.downvotes.IncrementLikeCount(threadID, delta)
+	}
+	if s.reactions == nil {
+		return nil
 	}
-	return s.likeRepo.Exists(userID, threadID)
+	return s.reactions.IncrementReaction(threadID, reaction, delta)
 }
@@ -2,8 +2,11 @@ package service
 
 import (
 	"errors"
+	"exchangeapp/internal/models"
 	"exchangeapp/internal/repository"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestThreadLikeServiceLike(t *testing.T) {
@@ -26,7 +29,7 @@ func TestThreadLikeServiceLike(t *testing.T) {
 			}
 			likeRepo := &fakeThreadLikeRepo{createErr: c.repoErr}
 
-			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo)
+			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo, nil, nil, nil, nil, nil)
 			err := svc.Like(1, 1)
 
 			if !errors.Is(err, c.wantErr) {
@@ -56,7 +59,7 @@ func TestThreadLikeServiceUnlike(t *testing.T) {
 			}
 			likeRepo := &fakeThreadLikeRepo{deleteErr: c.repoErr}
 
-			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo)
+			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo, nil, nil, nil, nil, nil)
 			err := svc.Unlike(1, 1)
 
 			if !errors.Is(err, c.wantErr) {
@@ -93,7 +96,7 @@ func TestThreadLikeServiceIsLiked(t *testing.T) {
 				existsErr: c.repoErr,
 			}
 
-			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo)
+			svc := NewThreadLikeService(threadRepo, likeRepo, threadRepo, nil, nil, nil, nil, nil)
 			got, err := svc.IsLiked(1, 1)
 
 			if !errors.Is(err, c.wantErr) {
@@ -105,3 +108,274 @@ func TestThreadLikeServiceIsLiked(t *testing.T) {
 		})
 	}
 }
+
+type fakeReactionCounter struct {
+	deltas map[string][]int
+	counts map[string]int64
+}
+
+func (f *fakeReactionCounter) IncrementReaction(id uint, reaction string, delta int) error {
+	if f.deltas == nil {
+		f.deltas = make(map[string][]int)
+	}
+	f.deltas[reaction] = append(f.deltas[reaction], delta)
+	return nil
+}
+
+func (f *fakeReactionCounter) GetReactionCounts(uint) (map[string]int64, error) {
+	return f.counts, nil
+}
+
+func (f *fakeReactionCounter) GetReactionCountsBatch(ids []uint) (map[uint]map[string]int64, error) {
+	res := make(map[uint]map[string]int64, len(ids))
+	for _, id := range ids {
+		res[id] = f.counts
+	}
+	return res, nil
+}
+
+func (f *fakeReactionCounter) PurgeReactionCounts(uint) error {
+	return nil
+}
+
+func TestThreadLikeServiceReact(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	counter := &fakeLikeCounter{}
+	reactions := &fakeReactionCounter{}
+	svc := NewThreadLikeService(threadRepo, &fakeThreadLikeRepo{}, counter, reactions, NewReactionSet([]string{"heart"}), nil, nil, nil)
+
+	if err := svc.React(1, 1, "laugh"); !errors.Is(err, ErrInvalidReaction) {
+		t.Fatalf("expected ErrInvalidReaction, got %v", err)
+	}
+	if err := svc.React(1, 1, "heart"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Unreact(1, 1, "heart"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.React(1, 1, "like"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := reactions.deltas["heart"]; len(got) != 2 || got[0] != 1 || got[1] != -1 {
+		t.Fatalf("expected heart deltas [1 -1], got %v", got)
+	}
+	if _, ok := reactions.deltas["like"]; ok {
+		t.Fatalf("like should not go to the reaction counter")
+	}
+	if got := counter.deltas[1]; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("expected like delta [1], got %v", got)
+	}
+}
+
+func TestThreadLikeServiceReactions(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	counter := &fakeLikeCounter{counts: map[uint]int64{1: 3}}
+	// 旧数据中的 downvote 计数不能当作表情回应返回
+	reactions := &fakeReactionCounter{counts: map[string]int64{"heart": 2, "laugh": 0, "downvote": 4}}
+	likeRepo := &fakeThreadLikeRepo{reactions: []string{"like", "heart"}}
+	svc := NewThreadLikeService(threadRepo, likeRepo, counter, reactions, nil, nil, nil, nil)
+
+	resp, err := svc.Reactions(1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]int64{"like": 3, "heart": 2}
+	if !reflect.DeepEqual(resp.Counts, want) {
+		t.Fatalf("expected %v, got %v", want, resp.Counts)
+	}
+	if !reflect.DeepEqual(resp.Mine, []string{"like", "heart"}) {
+		t.Fatalf("unexpected mine: %v", resp.Mine)
+	}
+}
+
+func TestThreadLikeServiceListLikers(t *testing.T) {
+	ts := time.Unix(0, 500)
+	likeRepo := &fakeThreadLikeRepo{likers: []repository.ThreadLiker{
+		{ID: 9, UserID: 2, Username: "bob", CreatedAt: ts},
+	}}
+	svc := NewThreadLikeService(&fakeThreadRepo{}, likeRepo, nil, nil, nil, nil, nil, nil)
+
+	if _, err := svc.ListLikers(1, 20); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected ErrThreadNotFound, got %v", err)
+	}
+
+	svc = NewThreadLikeService(&fakeThreadRepo{findResult: thread(1, 1)}, likeRepo, nil, nil, nil, nil, nil, nil)
+	resp, err := svc.ListLikers(1, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Username != "bob" || !resp.Items[0].LikedAt.Equal(ts) {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+	if resp.NextCursor != "500_9" {
+		t.Fatalf("expected cursor 500_9, got %s", resp.NextCursor)
+	}
+}
+
+type fakeLikedCache struct {
+	loaded      bool
+	ids         []uint
+	complete    bool
+	invalidated int
+}
+
+func (f *fakeLikedCache) Contains(userID uint, threadIDs []uint) ([]bool, bool, error) {
+	if !f.loaded {
+		return nil, false, repository.ErrLikedCacheMiss
+	}
+	hits := make([]bool, len(threadIDs))
+	for i, id := range threadIDs {
+		for _, liked := range f.ids {
+			hits[i] = hits[i] || id == liked
+		}
+	}
+	return hits, f.complete, nil
+}
+
+func (f *fakeLikedCache) Store(userID uint, threadIDs []uint, complete bool) error {
+	f.loaded, f.ids, f.complete = true, threadIDs, complete
+	return nil
+}
+
+func (f *fakeLikedCache) Invalidate(uint) error {
+	f.loaded = false
+	f.invalidated++
+	return nil
+}
+
+func TestThreadLikeServiceBatchStatus(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	likeRepo := &fakeThreadLikeRepo{likedIDs: []uint{2, 4}}
+	cache := &fakeLikedCache{}
+	svc := NewThreadLikeService(threadRepo, likeRepo, &fakeLikeCounter{}, nil, NewReactionSet([]string{"heart"}), cache, nil, nil)
+
+	got, err := svc.BatchStatus(1, []uint{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[uint]bool{1: false, 2: true, 3: false}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if likeRepo.recentCalls != 1 || len(likeRepo.likedQueries) != 0 {
+		t.Fatalf("expected one cache load and no status query, got %d %d", likeRepo.recentCalls, len(likeRepo.likedQueries))
+	}
+
+	if _, err := svc.BatchStatus(1, []uint{4}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if likeRepo.recentCalls != 1 {
+		t.Fatalf("expected cache hit, got %d loads", likeRepo.recentCalls)
+	}
+
+	if err := svc.React(1, 1, "heart"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cache.invalidated != 0 {
+		t.Fatalf("non-like reactions should keep the cache")
+	}
+	if err := svc.Like(1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cache.invalidated != 1 || cache.loaded {
+		t.Fatalf("expected like to invalidate the cache")
+	}
+}
+
+func TestThreadLikeServiceBatchStatusIncompleteCache(t *testing.T) {
+	likeRepo := &fakeThreadLikeRepo{likedIDs: []uint{9}}
+	cache := &fakeLikedCache{loaded: true, ids: []uint{2}}
+	svc := NewThreadLikeService(&fakeThreadRepo{}, likeRepo, nil, nil, nil, cache, nil, nil)
+
+	got, err := svc.BatchStatus(1, []uint{2, 9, 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[uint]bool{2: true, 9: true, 10: false}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if len(likeRepo.likedQueries) != 1 || !reflect.DeepEqual(likeRepo.likedQueries[0], []uint{9, 10}) {
+		t.Fatalf("expected one query for cache misses, got %v", likeRepo.likedQueries)
+	}
+}
+
+// 记录每种回应是否存在，用于校验改投
+type voteLikeRepo struct {
+	fakeThreadLikeRepo
+	rows map[string]bool
+}
+
+func (f *voteLikeRepo) Create(t *models.ThreadLike) error {
+	if f.rows[t.Reaction] {
+		return repository.ErrAlreadyLiked
+	}
+	f.rows[t.Reaction] = true
+	return nil
+}
+
+func (f *voteLikeRepo) Delete(userID, threadID uint, reaction string) error {
+	if !f.rows[reaction] {
+		return repository.ErrLikeNotFound
+	}
+	delete(f.rows, reaction)
+	return nil
+}
+
+func (f *voteLikeRepo) ListReactions(userID, threadID uint) ([]string, error) {
+	var res []string
+	for reaction := range f.rows {
+		res = append(res, reaction)
+	}
+	return res, nil
+}
+
+func TestThreadLikeServiceVote(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	likeRepo := &voteLikeRepo{rows: map[string]bool{}}
+	likes := &fakeLikeCounter{}
+	downvotes := &fakeLikeCounter{}
+	svc := NewThreadLikeService(threadRepo, likeRepo, likes, nil, nil, nil, downvotes, nil)
+
+	resp, err := svc.Vote(2, 1, VoteUp)
+	if err != nil || resp.Vote != VoteUp {
+		t.Fatalf("up: %+v %v", resp, err)
+	}
+	// 重复投同一票不报错也不重复计数
+	if _, err := svc.Vote(2, 1, VoteUp); err != nil {
+		t.Fatalf("repeat up: %v", err)
+	}
+	resp, err = svc.Vote(2, 1, VoteDown)
+	if err != nil || resp.Vote != VoteDown {
+		t.Fatalf("down: %+v %v", resp, err)
+	}
+	if !reflect.DeepEqual(likes.deltas[1], []int{1, -1}) {
+		t.Fatalf("like deltas = %v", likes.deltas[1])
+	}
+	if !reflect.DeepEqual(downvotes.deltas[1], []int{1}) {
+		t.Fatalf("downvote deltas = %v", downvotes.deltas[1])
+	}
+	if likeRepo.rows[models.ReactionLike] || !likeRepo.rows[models.ReactionDownvote] {
+		t.Fatalf("rows = %v", likeRepo.rows)
+	}
+
+	resp, err = svc.Unvote(2, 1)
+	if err != nil || resp.Vote != "" {
+		t.Fatalf("unvote: %+v %v", resp, err)
+	}
+	if !reflect.DeepEqual(downvotes.deltas[1], []int{1, -1}) {
+		t.Fatalf("downvote deltas after unvote = %v", downvotes.deltas[1])
+	}
+}
+
+func TestThreadLikeServiceVoteDownvoteDisabled(t *testing.T) {
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 1)}
+	likeRepo := &voteLikeRepo{rows: map[string]bool{}}
+	svc := NewThreadLikeService(threadRepo, likeRepo, &fakeLikeCounter{}, nil, nil, nil, nil, nil)
+
+	if _, err := svc.Vote(2, 1, VoteDown); !errors.Is(err, ErrDownvoteDisabled) {
+		t.Fatalf("expected ErrDownvoteDisabled, got %v", err)
+	}
+	if len(likeRepo.rows) != 0 {
+		t.Fatalf("rows = %v", likeRepo.rows)
+	}
+}
@@ -1,49 +1,122 @@
 package service
 
 import (
+	"errors"
 	"exchangeapp/internal/dto"
 	"exchangeapp/internal/models"
 	"exchangeapp/internal/repository"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ThreadService struct {
-	repo     repository.ThreadRepository
-	likeRepo repository.ThreadLikeRepository
-	counter  repository.ThreadLikeCounter
+	repo         repository.ThreadRepository
+	likeRepo     repository.ThreadLikeRepository
+	counter      repository.LikeCounter
+	uploadRepo   repository.UploadRepository
+	bookmarkRepo repository.BookmarkRepository
+	mentions     *MentionService
+	reactions    repository.ReactionCounter
+	downvotes    repository.LikeCounter
+	reputation   *ReputationService
 }
 
 func NewThreadService(
 	repo repository.ThreadRepository,
 	likeRepo repository.ThreadLikeRepository,
-	counter repository.ThreadLikeCounter,
+	counter repository.LikeCounter,
+	uploadRepo repository.UploadRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	mentions *MentionService,
+	reactions repository.ReactionCounter,
+	downvotes repository.LikeCounter,
+	reputation *ReputationService,
 ) *ThreadService {
 	return &ThreadService{
-		repo:     repo,
-		likeRepo: likeRepo,
-		counter:  counter,
+		repo:         repo,
+		likeRepo:     likeRepo,
+		counter:      counter,
+		uploadRepo:   uploadRepo,
+		bookmarkRepo: bookmarkRepo,
+		mentions:     mentions,
+		reactions:    reactions,
+		downvotes:    downvotes,
+		reputation:   reputation,
 	}
 }
 
 func (s *ThreadService) Create(userID uint, req dto.CreateThreadReq) (*dto.ThreadDetailResp, error) {
+	format, html, err := renderContent(req.ContentFormat, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reputation.requireLinks(userID, req.Content, html); err != nil {
+		return nil, err
+	}
+
 	t := &models.Thread{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  userID,
+		Title:         req.Title,
+		Content:       req.Content,
+		ContentFormat: format,
+		ContentHTML:   html,
+		UserID:        userID,
+		Type:          req.Type,
+	}
+	if t.Type == "" {
+		t.Type = models.ThreadTypeDiscussion
 	}
 
-	if err := s.repo.Create(t); err != nil {
+	if len(req.AttachmentIDs) > 0 && s.uploadRepo != nil {
+		err = s.createWithAttachments(t, req.AttachmentIDs)
+	} else {
+		err = s.repo.Create(t)
+	}
+	if err != nil {
 		return nil, err
 	}
+	s.mentions.sync(models.MentionSourceThread, t.ID, t.ID, userID, t.Content)
 
-	return &dto.ThreadDetailResp{
-		ID:        t.ID,
-		Title:     t.Title,
-		Content:   t.Content,
-		UserID:    t.UserID,
-		CreatedAt: t.CreatedAt,
-	}, nil
+	resp := newThreadDetailResp(t, 0)
+	if resp.Attachments, err = s.attachments(t.ID); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.fillThread(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *ThreadService) createWithAttachments(t *models.Thread, ids []uint) error {
+	txer, ok1 := s.repo.(repository.Transactioner)
+	trWithTx, ok2 := s.repo.(repository.ThreadRepoWithTx)
+	urWithTx, ok3 := s.uploadRepo.(repository.UploadRepoWithTx)
+
+	if ok1 && ok2 && ok3 {
+		return txer.Transaction(func(tx *gorm.DB) error {
+			if err := trWithTx.WithTx(tx).Create(t); err != nil {
+				return err
+			}
+			return urWithTx.WithTx(tx).AttachToThread(t.UserID, t.ID, ids)
+		})
+	}
+
+	if err := s.repo.Create(t); err != nil {
+		return err
+	}
+	return s.uploadRepo.AttachToThread(t.UserID, t.ID, ids)
+}
+
+func (s *ThreadService) attachments(threadID uint) ([]dto.UploadResp, error) {
+	if s.uploadRepo == nil {
+		return []dto.UploadResp{}, nil
+	}
+	ups, err := s.uploadRepo.ListByThreadID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	return newUploadResps(ups), nil
 }
 
 func (s *ThreadService) List(page, size int) (*dto.ThreadListResp, error) {
@@ -58,15 +131,7 @@ func (s *ThreadService) List(page, size int) (*dto.ThreadListResp, error) {
 		return nil, err
 	}
 
-	items := make([]dto.ThreadSummaryResp, len(ts))
-	for i := range ts {
-		items[i] = dto.ThreadSummaryResp{
-			ID:        ts[i].ID,
-			Title:     ts[i].Title,
-			UserID:    ts[i].UserID,
-			CreatedAt: ts[i].CreatedAt,
-		}
-	}
+	items := s.summaries(ts)
 
 	next := ""
 	if len(ts) > 0 {
@@ -89,15 +154,7 @@ func (s *ThreadService) ListAfter(cursorTime time.Time, cursorID uint, size int)
 		return nil, err
 	}
 
-	items := make([]dto.ThreadSummaryResp, len(ts))
-	for i := range ts {
-		items[i] = dto.ThreadSummaryResp{
-			ID:        ts[i].ID,
-			Title:     ts[i].Title,
-			UserID:    ts[i].UserID,
-			CreatedAt: ts[i].CreatedAt,
-		}
-	}
+	items := s.summaries(ts)
 
 	next := ""
 	if len(ts) > 0 {
@@ -113,6 +170,150 @@ func (s *ThreadService) ListAfter(cursorTime time.Time, cursorID uint, size int)
 	}, nil
 }
 
+func (s *ThreadService) ListActive(page, size int) (*dto.ThreadListResp, error) {
+	offset := (page - 1) * size
+
+	total, err := s.repo.Count()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.repo.ListByActivity(size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	items := s.summaries(ts)
+
+	next := ""
+	if len(ts) > 0 {
+		last := ts[len(ts)-1]
+		next = fmt.Sprintf("%d_%d", last.LastActivityAt.UnixNano(), last.ID)
+	}
+
+	return &dto.ThreadListResp{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		NextCursor: next,
+	}, nil
+}
+
+func (s *ThreadService) ListActiveAfter(cursorTime time.Time, cursorID uint, size int) (*dto.ThreadListResp, error) {
+	ts, err := s.repo.ListByActivityAfter(cursorTime, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	items := s.summaries(ts)
+
+	next := ""
+	if len(ts) > 0 {
+		last := ts[len(ts)-1]
+		next = fmt.Sprintf("%d_%d", last.LastActivityAt.UnixNano(), last.ID)
+	}
+	return &dto.ThreadListResp{
+		Items:      items,
+		Size:       size,
+		Page:       0,
+		Total:      0,
+		NextCursor: next,
+	}, nil
+}
+
+// 按库里的净分排序，净分随点赞数回写更新，比缓存中的计数滞后一个回写周期
+func (s *ThreadService) ListByScore(page, size int) (*dto.ThreadListResp, error) {
+	offset := (page - 1) * size
+
+	total, err := s.repo.Count()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.repo.ListByScore(size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ThreadListResp{
+		Items:      s.summaries(ts),
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		NextCursor: scoreCursor(ts),
+	}, nil
+}
+
+func (s *ThreadService) ListByScoreAfter(cursorScore int64, cursorID uint, size int) (*dto.ThreadListResp, error) {
+	ts, err := s.repo.ListByScoreAfter(cursorScore, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ThreadListResp{
+		Items:      s.summaries(ts),
+		Size:       size,
+		Page:       0,
+		Total:      0,
+		NextCursor: scoreCursor(ts),
+	}, nil
+}
+
+// 游标取库里的净分，与排序依据一致
+func scoreCursor(ts []models.Thread) string {
+	if len(ts) == 0 {
+		return ""
+	}
+	last := ts[len(ts)-1]
+	return fmt.Sprintf("%d_%d", last.Score, last.ID)
+}
+
+// 未采纳答案的问答帖，byActivity 与 ListActive 的排序一致
+func (s *ThreadService) ListUnanswered(byActivity bool, page, size int) (*dto.ThreadListResp, error) {
+	offset := (page - 1) * size
+
+	total, err := s.repo.CountUnanswered()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.repo.ListUnanswered(byActivity, size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ThreadListResp{
+		Items:      s.summaries(ts),
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		NextCursor: threadCursor(ts, byActivity),
+	}, nil
+}
+
+func (s *ThreadService) ListUnansweredAfter(byActivity bool, cursorTime time.Time, cursorID uint, size int) (*dto.ThreadListResp, error) {
+	ts, err := s.repo.ListUnansweredAfter(byActivity, cursorTime, cursorID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ThreadListResp{
+		Items:      s.summaries(ts),
+		Size:       size,
+		NextCursor: threadCursor(ts, byActivity),
+	}, nil
+}
+
+func threadCursor(ts []models.Thread, byActivity bool) string {
+	if len(ts) == 0 {
+		return ""
+	}
+	last := ts[len(ts)-1]
+	at := last.CreatedAt
+	if byActivity {
+		at = last.LastActivityAt
+	}
+	return fmt.Sprintf("%d_%d", at.UnixNano(), last.ID)
+}
+
 func (s *ThreadService) ListByUserID(userID uint, page, size int) (*dto.ThreadListResp, error) {
 	offset := (page - 1) * size
 
@@ -125,15 +326,7 @@ func (s *ThreadService) ListByUserID(userID uint, page, size int) (*dto.ThreadLi
 		return nil, err
 	}
 
-	items := make([]dto.ThreadSummaryResp, len(ts))
-	for i := range ts {
-		items[i] = dto.ThreadSummaryResp{
-			ID:        ts[i].ID,
-			Title:     ts[i].Title,
-			UserID:    ts[i].UserID,
-			CreatedAt: ts[i].CreatedAt,
-		}
-	}
+	items := s.summaries(ts)
 
 	next := ""
 	if len(ts) > 0 {
@@ -156,15 +349,7 @@ func (s *ThreadService) ListByUserIDAfter(userID uint, cursorTime time.Time, cur
 		return nil, err
 	}
 
-	items := make([]dto.ThreadSummaryResp, len(ts))
-	for i := range ts {
-		items[i] = dto.ThreadSummaryResp{
-			ID:        ts[i].ID,
-			Title:     ts[i].Title,
-			UserID:    ts[i].UserID,
-			CreatedAt: ts[i].CreatedAt,
-		}
-	}
+	items := s.summaries(ts)
 
 	next := ""
 	if len(ts) > 0 {
@@ -180,7 +365,26 @@ func (s *ThreadService) ListByUserIDAfter(userID uint, cursorTime time.Time, cur
 	}, nil
 }
 
-func (s *ThreadService) GetByID(id uint) (*dto.ThreadDetailResp, error) {
+// 按 ids 顺序返回，不存在或已删除的帖子直接跳过
+func (s *ThreadService) ListByIDs(ids []uint) (*dto.ThreadListResp, error) {
+	ts, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	items := s.summaries(ts)
+	return &dto.ThreadListResp{
+		Items: items,
+		Total: int64(len(items)),
+		Size:  len(ids),
+	}, nil
+}
+
+func (s *ThreadService) summaries(ts []models.Thread) []dto.ThreadSummaryResp {
+	return threadSummaries(s.counter, s.downvotes, ts)
+}
+
+// viewerID 为 0 表示未登录，此时 bookmarked 恒为 false
+func (s *ThreadService) GetByID(viewerID, id uint) (*dto.ThreadDetailResp, error) {
 	t, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -194,17 +398,35 @@ func (s *ThreadService) GetByID(id uint) (*dto.ThreadDetailResp, error) {
 		return nil, err
 	}
 
-	return &dto.ThreadDetailResp{
-		ID:        t.ID,
-		Title:     t.Title,
-		Content:   t.Content,
-		UserID:    t.UserID,
-		LikeCount: likeCount,
-		CreatedAt: t.CreatedAt,
-	}, nil
+	resp := newThreadDetailResp(t, likeCount)
+	if s.downvotes != nil {
+		if resp.DownvoteCount, err = s.downvotes.GetLikeCount(t.ID); err != nil {
+			return nil, err
+		}
+		resp.Score = likeCount - resp.DownvoteCount
+	}
+	if resp.Attachments, err = s.attachments(t.ID); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.fillThread(resp); err != nil {
+		return nil, err
+	}
+	var counts map[string]int64
+	if s.reactions != nil {
+		if counts, err = s.reactions.GetReactionCounts(t.ID); err != nil {
+			return nil, err
+		}
+	}
+	resp.Reactions = mergeReactionCounts(likeCount, counts)
+	if viewerID != 0 && s.bookmarkRepo != nil {
+		if resp.Bookmarked, err = s.bookmarkRepo.Exists(viewerID, t.ID); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
-func (s *ThreadService) Update(userID, id uint, req dto.UpdateThreadReq) (*dto.ThreadDetailResp, error) {
+func (s *ThreadService) Update(userID, id, version uint, req dto.UpdateThreadReq) (*dto.ThreadDetailResp, error) {
 	t, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -215,20 +437,74 @@ func (s *ThreadService) Update(userID, id uint, req dto.UpdateThreadReq) (*dto.T
 	if t.UserID != userID {
 		return nil, ErrForbidden
 	}
+	if t.Version != version {
+		// 详情缓存可能落后于库，以库中的版本为准
+		if t, err = s.reload(id); err != nil {
+			return nil, err
+		}
+		if t.Version != version {
+			return nil, &VersionConflictError{Current: t.Version}
+		}
+	}
+
+	format := req.ContentFormat
+	if format == "" {
+		format = t.ContentFormat
+	}
+	format, html, err := renderContent(format, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reputation.requireLinks(userID, req.Content, html); err != nil {
+		return nil, err
+	}
 
 	t.Title = req.Title
 	t.Content = req.Content
+	t.ContentFormat = format
+	t.ContentHTML = html
 
 	if err := s.repo.Update(t); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, s.conflict(id)
+		}
 		return nil, err
 	}
-	return &dto.ThreadDetailResp{
-		ID:        t.ID,
-		Title:     t.Title,
-		Content:   t.Content,
-		UserID:    t.UserID,
-		CreatedAt: t.CreatedAt,
-	}, nil
+	s.mentions.sync(models.MentionSourceThread, t.ID, t.ID, userID, t.Content)
+	resp := newThreadDetailResp(t, 0)
+	if resp.Attachments, err = s.attachments(t.ID); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.fillThread(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// 条件更新失败说明有并发写入，重新读取最新版本返回给调用方
+func (s *ThreadService) conflict(id uint) error {
+	t, err := s.reload(id)
+	if err != nil {
+		return err
+	}
+	return &VersionConflictError{Current: t.Version}
+}
+
+// 先用库中数据覆盖详情缓存再读取，避免返回缓存中的旧版本
+func (s *ThreadService) reload(id uint) (*models.Thread, error) {
+	if refresher, ok := s.repo.(repository.ThreadCacheRefresher); ok {
+		if err := refresher.RefreshCache(id); err != nil {
+			return nil, err
+		}
+	}
+	t, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrThreadNotFound
+	}
+	return t, nil
 }
 
 func (s *ThreadService) Delete(userID, id uint) error {
@@ -243,5 +519,17 @@ func (s *ThreadService) Delete(userID, id uint) error {
 		return ErrForbidden
 	}
 
-	return s.repo.DeleteByID(id)
+	if err := s.repo.DeleteByID(id); err != nil {
+		return err
+	}
+	if purger, ok := s.counter.(repository.LikeCountPurger); ok {
+		_ = purger.PurgeLikeCount(id)
+	}
+	if purger, ok := s.downvotes.(repository.LikeCountPurger); ok {
+		_ = purger.PurgeLikeCount(id)
+	}
+	if s.reactions != nil {
+		_ = s.reactions.PurgeReactionCounts(id)
+	}
+	return nil
 }
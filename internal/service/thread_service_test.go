@@ -4,6 +4,7 @@ import (
 	"errors"
 	"exchangeapp/internal/dto"
 	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
 	"testing"
 	"time"
 )
@@ -23,10 +24,10 @@ func TestThreadServiceUpdate(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			repo := &fakeThreadRepo{findResult: c.thread}
-			svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+			svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 
 			req := dto.UpdateThreadReq{Title: "t", Content: "c"}
-			_, err := svc.Update(c.userID, 1, req)
+			_, err := svc.Update(c.userID, 1, 0, req)
 
 			if !errors.Is(err, c.wantErr) {
 				t.Fatalf("expected %v, got %v", c.wantErr, err)
@@ -35,23 +36,164 @@ func TestThreadServiceUpdate(t *testing.T) {
 	}
 }
 
+// 详情缓存中是旧版本，RefreshCache 后读到库中的版本
+type fakeStaleThreadRepo struct {
+	*fakeThreadRepo
+	latest    *models.Thread
+	refreshed int
+}
+
+func (f *fakeStaleThreadRepo) RefreshCache(id uint) error {
+	f.refreshed++
+	f.findResult = f.latest
+	return nil
+}
+
+func TestThreadServiceUpdateChecksVersionAgainstDB(t *testing.T) {
+	cached := thread(1, 1)
+	cached.Version = 1
+	latest := thread(1, 1)
+	latest.Version = 2
+	repo := &fakeStaleThreadRepo{fakeThreadRepo: &fakeThreadRepo{findResult: cached}, latest: latest}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
+	req := dto.UpdateThreadReq{Title: "t", Content: "c"}
+
+	// 客户端持有库中的最新版本，不应因缓存落后而返回冲突
+	if _, err := svc.Update(1, 1, 2, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.refreshed != 1 || repo.updated != latest {
+		t.Fatalf("expected update based on refreshed thread, got refreshed=%d", repo.refreshed)
+	}
+
+	repo.findResult = cached
+	repo.updateErr = repository.ErrVersionConflict
+	latest.Version = 3
+	_, err := svc.Update(1, 1, 1, req)
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Current != 3 {
+		t.Fatalf("expected conflict with db version 3, got %v", err)
+	}
+}
+
+func TestThreadServiceCreateContentFormat(t *testing.T) {
+	cases := []struct {
+		name       string
+		format     string
+		content    string
+		wantFormat string
+		wantHTML   string
+	}{
+		{"default_plain", "", "<b>x</b>", "plain", "<p>&lt;b&gt;x&lt;/b&gt;</p>\n"},
+		{"markdown", "markdown", "**x**", "markdown", "<p><strong>x</strong></p>\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeThreadRepo{}
+			svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
+
+			req := dto.CreateThreadReq{Title: "t", Content: c.content, ContentFormat: c.format}
+			resp, err := svc.Create(1, req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.ContentFormat != c.wantFormat || resp.ContentHTML != c.wantHTML {
+				t.Fatalf("unexpected result: %+v", resp)
+			}
+			if repo.created.ContentHTML != c.wantHTML {
+				t.Fatalf("expected rendered html stored, got %q", repo.created.ContentHTML)
+			}
+		})
+	}
+}
+
+func TestThreadServiceCreateWithAttachments(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	uploads := &fakeUploadRepo{
+		byThread: []models.Upload{{Model: gormModel(3), URL: "/uploads/x.png", ThreadID: 1}},
+	}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, uploads, nil, nil, nil, nil, nil)
+
+	req := dto.CreateThreadReq{Title: "t", Content: "c", AttachmentIDs: []uint{3}}
+	resp, err := svc.Create(1, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uploads.attachedIDs) != 1 || uploads.attachedIDs[0] != 3 {
+		t.Fatalf("expected upload 3 attached, got %v", uploads.attachedIDs)
+	}
+	if len(resp.Attachments) != 1 || resp.Attachments[0].ID != 3 {
+		t.Fatalf("unexpected attachments: %+v", resp.Attachments)
+	}
+}
+
+func TestThreadServiceCreateAttachmentError(t *testing.T) {
+	repo := &fakeThreadRepo{}
+	uploads := &fakeUploadRepo{attachErr: repository.ErrUploadNotAttachable}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, uploads, nil, nil, nil, nil, nil)
+
+	req := dto.CreateThreadReq{Title: "t", Content: "c", AttachmentIDs: []uint{3}}
+	if _, err := svc.Create(1, req); !errors.Is(err, repository.ErrUploadNotAttachable) {
+		t.Fatalf("expected ErrUploadNotAttachable, got %v", err)
+	}
+}
+
+func TestThreadServiceGetByIDRendersLegacyContent(t *testing.T) {
+	repo := &fakeThreadRepo{
+		findResult: &models.Thread{ID: 1, UserID: 1, Content: "a & b"},
+	}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.GetByID(0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ContentFormat != "plain" || resp.ContentHTML != "<p>a &amp; b</p>\n" {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+}
+
 func TestThreadServiceDelete(t *testing.T) {
 	repo := &fakeThreadRepo{
 		findResult: thread(1, 1),
 	}
-	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 
 	if err := svc.Delete(1, 1); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+type fakePurgingCounter struct {
+	*fakeThreadRepo
+	purged []uint
+}
+
+func (f *fakePurgingCounter) PurgeLikeCount(threadID uint) error {
+	f.purged = append(f.purged, threadID)
+	return nil
+}
+
+func TestThreadServiceDeletePurgesLikeCount(t *testing.T) {
+	repo := &fakeThreadRepo{findResult: thread(3, 1)}
+	counter := &fakePurgingCounter{fakeThreadRepo: repo}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, counter, nil, nil, nil, nil, nil, nil)
+
+	if err := svc.Delete(1, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.deleteID != 3 || len(counter.purged) != 1 || counter.purged[0] != 3 {
+		t.Fatalf("expected thread deleted and like count purged, got %d %+v", repo.deleteID, counter.purged)
+	}
+}
+
 func TestThreadServiceListByUserID(t *testing.T) {
 	repo := &fakeThreadRepo{
 		listResult:  []models.Thread{*thread(1, 1)},
 		countResult: 1,
 	}
-	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 
 	resp, err := svc.ListByUserID(1, 1, 10)
 	if err != nil {
@@ -62,6 +204,45 @@ func TestThreadServiceListByUserID(t *testing.T) {
 	}
 }
 
+type fakeBatchCounter struct {
+	*fakeThreadRepo
+	counts map[uint]int64
+	calls  int
+}
+
+func (f *fakeBatchCounter) GetLikeCounts(threadIDs []uint) (map[uint]int64, error) {
+	f.calls++
+	return f.counts, nil
+}
+
+func TestThreadServiceListSummaryStats(t *testing.T) {
+	lastAt := time.Unix(100, 0)
+	repo := &fakeThreadRepo{
+		listResult: []models.Thread{
+			{ID: 1, LikeCount: 3, ReplyCount: 2, LastReplyAt: &lastAt, LastReplyUserID: 9},
+			{ID: 2, LikeCount: 4},
+		},
+		countResult: 2,
+	}
+	counter := &fakeBatchCounter{fakeThreadRepo: repo, counts: map[uint]int64{1: 5}}
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, counter, nil, nil, nil, nil, nil, nil)
+
+	resp, err := svc.List(1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.calls != 1 {
+		t.Fatalf("expected one batch like count lookup, got %d", counter.calls)
+	}
+	first, second := resp.Items[0], resp.Items[1]
+	if first.LikeCount != 5 || first.ReplyCount != 2 || first.LastReplyUserID != 9 || !first.LastReplyAt.Equal(lastAt) {
+		t.Fatalf("unexpected first item: %+v", first)
+	}
+	if second.LikeCount != 4 || second.LastReplyAt != nil {
+		t.Fatalf("expected db like count fallback, got %+v", second)
+	}
+}
+
 func TestThreadServiceListAfter(t *testing.T) {
 	ts := time.Unix(0, 123)
 	repo := &fakeThreadRepo{
@@ -69,7 +250,7 @@ func TestThreadServiceListAfter(t *testing.T) {
 			{ID: 7, CreatedAt: ts, Title: "t1", UserID: 1},
 		},
 	}
-	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 
 	resp, err := svc.ListAfter(time.Unix(0, 1), 1, 10)
 	if err != nil {
@@ -91,7 +272,7 @@ func TestThreadServiceListByUserIDAfter(t *testing.T) {
 			{ID: 9, CreatedAt: ts, Title: "t2", UserID: 2},
 		},
 	}
-	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo)
+	svc := NewThreadService(repo, &fakeThreadLikeRepo{}, repo, nil, nil, nil, nil, nil, nil)
 
 	resp, err := svc.ListByUserIDAfter(2, time.Unix(0, 1), 1, 10)
 	if err != nil {
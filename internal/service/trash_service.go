@@ -0,0 +1,206 @@
+package service
+
+import (
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TrashTypeThreads = "threads"
+	TrashTypeReplies = "replies"
+)
+
+type TrashService struct {
+	trashRepo  repository.TrashRepository
+	threadRepo repository.ThreadRepository
+	replyRepo  repository.ReplyRepository
+	counter    repository.LikeCounter
+	// 为 nil 时未开启踩
+	downvotes repository.LikeCounter
+	retention time.Duration
+}
+
+func NewTrashService(
+	trashRepo repository.TrashRepository,
+	threadRepo repository.ThreadRepository,
+	replyRepo repository.ReplyRepository,
+	counter repository.LikeCounter,
+	downvotes repository.LikeCounter,
+	retention time.Duration,
+) *TrashService {
+	return &TrashService{
+		trashRepo:  trashRepo,
+		threadRepo: threadRepo,
+		replyRepo:  replyRepo,
+		counter:    counter,
+		downvotes:  downvotes,
+		retention:  retention,
+	}
+}
+
+func (s *TrashService) List(actor Actor, kind string, all bool, cursorTime time.Time, cursorID uint, size int) (*dto.TrashListResp, error) {
+	userID := actor.UserID
+	if all {
+		if !actor.IsModerator() {
+			return nil, ErrForbidden
+		}
+		userID = 0
+	}
+
+	items := []dto.TrashItemResp{}
+	switch kind {
+	case TrashTypeReplies:
+		rs, err := s.trashRepo.ListDeletedReplies(userID, cursorTime, cursorID, size)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rs {
+			items = append(items, s.replyItem(&rs[i]))
+		}
+	default:
+		ts, err := s.trashRepo.ListDeletedThreads(userID, cursorTime, cursorID, size)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ts {
+			items = append(items, s.threadItem(&ts[i]))
+		}
+	}
+
+	next := ""
+	if len(items) > 0 {
+		last := items[len(items)-1]
+		next = fmt.Sprintf("%d_%d", last.DeletedAt.UnixNano(), last.ID)
+	}
+
+	return &dto.TrashListResp{
+		Items:      items,
+		Size:       size,
+		NextCursor: next,
+	}, nil
+}
+
+func (s *TrashService) threadItem(t *models.Thread) dto.TrashItemResp {
+	return dto.TrashItemResp{
+		Type:      "thread",
+		ID:        t.ID,
+		ThreadID:  t.ID,
+		Title:     t.Title,
+		Content:   t.Content,
+		UserID:    t.UserID,
+		CreatedAt: t.CreatedAt,
+		DeletedAt: t.DeletedAt.Time,
+		PurgeAt:   t.DeletedAt.Time.Add(s.retention),
+	}
+}
+
+func (s *TrashService) replyItem(r *models.Reply) dto.TrashItemResp {
+	return dto.TrashItemResp{
+		Type:      "reply",
+		ID:        r.ID,
+		ThreadID:  r.ThreadID,
+		Content:   r.Content,
+		UserID:    r.UserID,
+		CreatedAt: r.CreatedAt,
+		DeletedAt: r.DeletedAt.Time,
+		PurgeAt:   r.DeletedAt.Time.Add(s.retention),
+	}
+}
+
+func (s *TrashService) RestoreThread(actor Actor, id uint) error {
+	t, err := s.trashRepo.FindThread(id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrThreadNotFound
+	}
+	if !actor.CanManage(t.UserID) {
+		return ErrForbidden
+	}
+	if !t.DeletedAt.Valid {
+		return ErrNotInTrash
+	}
+
+	if err := s.trashRepo.RestoreThread(id); err != nil {
+		return err
+	}
+	s.rewarm(id)
+	return nil
+}
+
+func (s *TrashService) RestoreReply(actor Actor, id uint) error {
+	r, err := s.trashRepo.FindReply(id)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return ErrReplyNotFound
+	}
+	if !actor.CanManage(r.UserID) {
+		return ErrForbidden
+	}
+	if !r.DeletedAt.Valid {
+		return ErrNotInTrash
+	}
+
+	t, err := s.threadRepo.FindByID(r.ThreadID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrParentThreadDeleted
+	}
+
+	txer, ok1 := s.threadRepo.(repository.Transactioner)
+	trWithTx, ok2 := s.threadRepo.(repository.ThreadRepoWithTx)
+	rrWithTx, ok3 := s.replyRepo.(repository.ReplyRepoWithTx)
+	tsWithTx, ok4 := s.trashRepo.(repository.TrashRepoWithTx)
+
+	if ok1 && ok2 && ok3 && ok4 {
+		err = txer.Transaction(func(tx *gorm.DB) error {
+			return restoreReply(trWithTx.WithTx(tx), rrWithTx.WithTx(tx), tsWithTx.WithTx(tx), r)
+		})
+	} else {
+		err = restoreReply(s.threadRepo, s.replyRepo, s.trashRepo, r)
+	}
+	if err != nil {
+		return err
+	}
+	s.rewarm(r.ThreadID)
+	return nil
+}
+
+func restoreReply(
+	tr repository.ThreadRepository,
+	rr repository.ReplyRepository,
+	ts repository.TrashRepository,
+	r *models.Reply,
+) error {
+	if err := ts.RestoreReply(r.ID); err != nil {
+		return err
+	}
+	last, err := rr.FindLatestByThreadID(r.ThreadID)
+	if err != nil {
+		return err
+	}
+	return tr.UpdateReplyStats(r.ThreadID, 1, last)
+}
+
+// 恢复后主动回填详情缓存和点赞数、踩数缓存，失败不影响恢复结果
+func (s *TrashService) rewarm(threadID uint) {
+	if refresher, ok := s.threadRepo.(repository.ThreadCacheRefresher); ok {
+		_ = refresher.RefreshCache(threadID)
+	}
+	if s.counter != nil {
+		_, _ = s.counter.GetLikeCount(threadID)
+	}
+	if s.downvotes != nil {
+		_, _ = s.downvotes.GetLikeCount(threadID)
+	}
+}
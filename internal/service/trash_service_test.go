@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"exchangeapp/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type fakeTrashRepo struct {
+	threads []models.Thread
+	replies []models.Reply
+	thread  *models.Thread
+	reply   *models.Reply
+
+	listUserID      uint
+	restoredThreads []uint
+	restoredReplies []uint
+}
+
+func (f *fakeTrashRepo) ListDeletedThreads(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Thread, error) {
+	f.listUserID = userID
+	return f.threads, nil
+}
+
+func (f *fakeTrashRepo) ListDeletedReplies(userID uint, cursorTime time.Time, cursorID uint, limit int) ([]models.Reply, error) {
+	f.listUserID = userID
+	return f.replies, nil
+}
+
+func (f *fakeTrashRepo) FindThread(id uint) (*models.Thread, error) {
+	return f.thread, nil
+}
+
+func (f *fakeTrashRepo) FindReply(id uint) (*models.Reply, error) {
+	return f.reply, nil
+}
+
+func (f *fakeTrashRepo) RestoreThread(id uint) error {
+	f.restoredThreads = append(f.restoredThreads, id)
+	return nil
+}
+
+func (f *fakeTrashRepo) RestoreReply(id uint) error {
+	f.restoredReplies = append(f.restoredReplies, id)
+	return nil
+}
+
+func (f *fakeTrashRepo) PurgeThreadsBefore(time.Time, int) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeTrashRepo) PurgeRepliesBefore(time.Time, int) (int, error) {
+	return 0, nil
+}
+
+type fakeRefreshingThreadRepo struct {
+	*fakeThreadRepo
+	refreshed []uint
+}
+
+func (f *fakeRefreshingThreadRepo) RefreshCache(id uint) error {
+	f.refreshed = append(f.refreshed, id)
+	return nil
+}
+
+type fakeWarmCounter struct {
+	*fakeThreadRepo
+	warmed []uint
+}
+
+func (f *fakeWarmCounter) GetLikeCount(threadID uint) (int64, error) {
+	f.warmed = append(f.warmed, threadID)
+	return 0, nil
+}
+
+func deletedAt(t time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: t, Valid: true}
+}
+
+func TestTrashServiceListAllRequiresModerator(t *testing.T) {
+	trash := &fakeTrashRepo{}
+	svc := NewTrashService(trash, &fakeThreadRepo{}, &fakeReplyRepo{}, nil, nil, time.Hour)
+
+	if _, err := svc.List(Actor{UserID: 1}, TrashTypeThreads, true, time.Time{}, 0, 10); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	trash.threads = []models.Thread{{ID: 3, UserID: 2, DeletedAt: deletedAt(time.Unix(100, 0))}}
+	resp, err := svc.List(Actor{UserID: 1, Role: models.RoleModerator}, TrashTypeThreads, true, time.Time{}, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trash.listUserID != 0 {
+		t.Fatalf("expected unfiltered list, got user %d", trash.listUserID)
+	}
+	if len(resp.Items) != 1 || !resp.Items[0].PurgeAt.Equal(time.Unix(100, 0).Add(time.Hour)) {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+	if resp.NextCursor != "100000000000_3" {
+		t.Fatalf("unexpected next cursor: %s", resp.NextCursor)
+	}
+}
+
+func TestTrashServiceRestoreThread(t *testing.T) {
+	trash := &fakeTrashRepo{thread: &models.Thread{ID: 5, UserID: 2, DeletedAt: deletedAt(time.Now())}}
+	repo := &fakeRefreshingThreadRepo{fakeThreadRepo: &fakeThreadRepo{}}
+	counter := &fakeWarmCounter{fakeThreadRepo: repo.fakeThreadRepo}
+	downvotes := &fakeWarmCounter{fakeThreadRepo: repo.fakeThreadRepo}
+	svc := NewTrashService(trash, repo, &fakeReplyRepo{}, counter, downvotes, time.Hour)
+
+	if err := svc.RestoreThread(Actor{UserID: 3}, 5); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := svc.RestoreThread(Actor{UserID: 3, Role: models.RoleModerator}, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trash.restoredThreads) != 1 || len(repo.refreshed) != 1 || len(counter.warmed) != 1 || len(downvotes.warmed) != 1 {
+		t.Fatalf("expected restore and rewarm, got %+v %+v %+v %+v", trash.restoredThreads, repo.refreshed, counter.warmed, downvotes.warmed)
+	}
+}
+
+func TestTrashServiceRestoreThreadNotDeleted(t *testing.T) {
+	trash := &fakeTrashRepo{thread: &models.Thread{ID: 5, UserID: 2}}
+	svc := NewTrashService(trash, &fakeThreadRepo{}, &fakeReplyRepo{}, nil, nil, time.Hour)
+
+	if err := svc.RestoreThread(Actor{UserID: 2}, 5); !errors.Is(err, ErrNotInTrash) {
+		t.Fatalf("expected ErrNotInTrash, got %v", err)
+	}
+}
+
+func TestTrashServiceRestoreReplyUpdatesStats(t *testing.T) {
+	r := &models.Reply{ID: 7, ThreadID: 1, UserID: 2, DeletedAt: deletedAt(time.Now())}
+	trash := &fakeTrashRepo{reply: r}
+	threadRepo := &fakeThreadRepo{findResult: thread(1, 9)}
+	replyRepo := &fakeReplyRepo{latest: r}
+	svc := NewTrashService(trash, threadRepo, replyRepo, nil, nil, time.Hour)
+
+	if err := svc.RestoreReply(Actor{UserID: 2}, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trash.restoredReplies) != 1 || len(threadRepo.replyDeltas) != 1 || threadRepo.replyDeltas[0] != 1 {
+		t.Fatalf("expected reply restored with stats +1, got %+v", threadRepo.replyDeltas)
+	}
+	if threadRepo.lastReply != r {
+		t.Fatalf("expected last reply recomputed")
+	}
+}
+
+func TestTrashServiceRestoreReplyParentDeleted(t *testing.T) {
+	trash := &fakeTrashRepo{reply: &models.Reply{ID: 7, ThreadID: 1, UserID: 2, DeletedAt: deletedAt(time.Now())}}
+	svc := NewTrashService(trash, &fakeThreadRepo{}, &fakeReplyRepo{}, nil, nil, time.Hour)
+
+	if err := svc.RestoreReply(Actor{UserID: 2}, 7); !errors.Is(err, ErrParentThreadDeleted) {
+		t.Fatalf("expected ErrParentThreadDeleted, got %v", err)
+	}
+	if len(trash.restoredReplies) != 0 {
+		t.Fatalf("expected no restore")
+	}
+}
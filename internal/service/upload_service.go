@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"exchangeapp/internal/config"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+	"exchangeapp/internal/storage"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+const defaultUploadMaxSizeMB = 10
+
+var defaultUploadTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+}
+
+var uploadExts = map[string]string{
+	"image/jpeg":                ".jpg",
+	"image/png":                 ".png",
+	"image/gif":                 ".gif",
+	"image/webp":                ".webp",
+	"application/pdf":           ".pdf",
+	"text/plain; charset=utf-8": ".txt",
+}
+
+type UploadService struct {
+	repo    repository.UploadRepository
+	store   storage.BlobStore
+	maxSize int64
+	allowed map[string]bool
+}
+
+func NewUploadService(repo repository.UploadRepository, store storage.BlobStore, cfg config.UploadConfig) *UploadService {
+	maxMB := cfg.MaxSizeMB
+	if maxMB <= 0 {
+		maxMB = defaultUploadMaxSizeMB
+	}
+	types := cfg.AllowedTypes
+	if len(types) == 0 {
+		types = defaultUploadTypes
+	}
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[t] = true
+	}
+
+	return &UploadService{
+		repo:    repo,
+		store:   store,
+		maxSize: int64(maxMB) << 20,
+		allowed: allowed,
+	}
+}
+
+func (s *UploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *UploadService) Create(userID uint, filename string, size int64, r io.Reader) (*dto.UploadResp, error) {
+	if size > s.maxSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("读取文件失败：%w", err)
+	}
+	head = head[:n]
+
+	mimeType := http.DetectContentType(head)
+	if !s.allowed[mimeType] {
+		return nil, ErrUploadTypeNotAllowed
+	}
+
+	key, err := uploadKey(mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.maxSize+1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := s.store.Put(ctx, key, body, size, mimeType); err != nil {
+		return nil, err
+	}
+
+	u := &models.Upload{
+		UserID:   userID,
+		Key:      key,
+		URL:      s.store.URL(key),
+		Filename: filepath.Base(filename),
+		MimeType: mimeType,
+		Size:     size,
+	}
+	if err := s.repo.Create(u); err != nil {
+		_ = s.store.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	resp := newUploadResp(u)
+	return &resp, nil
+}
+
+func uploadKey(mimeType string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成文件名失败：%w", err)
+	}
+	return time.Now().Format("2006/01/02") + "/" + hex.EncodeToString(b) + uploadExts[mimeType], nil
+}
+
+func newUploadResp(u *models.Upload) dto.UploadResp {
+	return dto.UploadResp{
+		ID:        u.ID,
+		URL:       u.URL,
+		Filename:  u.Filename,
+		MimeType:  u.MimeType,
+		Size:      u.Size,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func newUploadResps(ups []models.Upload) []dto.UploadResp {
+	items := make([]dto.UploadResp, len(ups))
+	for i := range ups {
+		items[i] = newUploadResp(&ups[i])
+	}
+	return items
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"exchangeapp/internal/config"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestUploadServiceCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+		body     []byte
+		size     int64
+		wantErr  error
+	}{
+		{"too_large", "a.png", pngHeader, 2 << 20, ErrUploadTooLarge},
+		{"html_as_png", "a.png", []byte("<html><script>alert(1)</script></html>"), 40, ErrUploadTypeNotAllowed},
+		{"ok", "../../a.png", pngHeader, int64(len(pngHeader)), nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &fakeUploadRepo{}
+			store := &fakeBlobStore{}
+			svc := NewUploadService(repo, store, config.UploadConfig{MaxSizeMB: 1})
+
+			resp, err := svc.Create(1, c.filename, c.size, bytes.NewReader(c.body))
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("expected %v, got %v", c.wantErr, err)
+			}
+			if err != nil {
+				if len(store.puts) != 0 {
+					t.Fatalf("expected nothing stored, got %d", len(store.puts))
+				}
+				return
+			}
+			if resp.MimeType != "image/png" || resp.Filename != "a.png" {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+			if !strings.HasSuffix(repo.created.Key, ".png") || !bytes.Equal(store.puts[repo.created.Key], c.body) {
+				t.Fatalf("unexpected stored object: key=%s", repo.created.Key)
+			}
+		})
+	}
+}
+
+func TestUploadServiceCreateRepoErrorCleansBlob(t *testing.T) {
+	repo := &fakeUploadRepo{createErr: errors.New("boom")}
+	store := &fakeBlobStore{}
+	svc := NewUploadService(repo, store, config.UploadConfig{})
+
+	if _, err := svc.Create(1, "a.png", int64(len(pngHeader)), bytes.NewReader(pngHeader)); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if len(store.deleted) != 1 {
+		t.Fatalf("expected stored blob removed, got %v", store.deleted)
+	}
+}
@@ -19,6 +19,7 @@ type UserService struct {
 }
 
 var ErrInvalidCredentials = errors.New("用户名或密码错误")
+var ErrUserNotFound = errors.New("用户不存在")
 
 func NewUserService(repo repository.UserRepository, jwtCfg config.JWTConfig) *UserService {
 	return &UserService{
@@ -59,7 +60,7 @@ func (s *UserService) Login(req dto.LoginReq) (*dto.LoginResp, error) {
 	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(req.Password)); err != nil {
 		return nil, ErrInvalidCredentials
 	}
-	token, err := jwt.GenerateToken(u.ID, u.Username, s.jwtSecret, s.jwtExpireMinutes)
+	token, err := jwt.GenerateTokenWithRole(u.ID, u.Username, u.Role, s.jwtSecret, s.jwtExpireMinutes)
 	if err != nil {
 		return nil, fmt.Errorf("生成 token 失败：%w", err)
 	}
@@ -69,3 +70,32 @@ func (s *UserService) Login(req dto.LoginReq) (*dto.LoginResp, error) {
 		Token:    token,
 	}, nil
 }
+
+func (s *UserService) Privacy(userID uint) (*dto.PrivacyResp, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return &dto.PrivacyResp{HideLikes: u.HideLikes}, nil
+}
+
+func (s *UserService) UpdatePrivacy(userID uint, req dto.PrivacyReq) (*dto.PrivacyResp, error) {
+	if err := s.repo.UpdateHideLikes(userID, *req.HideLikes); err != nil {
+		return nil, err
+	}
+	return &dto.PrivacyResp{HideLikes: *req.HideLikes}, nil
+}
+
+func (s *UserService) Profile(userID uint) (*dto.ProfileResp, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return &dto.ProfileResp{ID: u.ID, Username: u.Username, Reputation: u.Reputation}, nil
+}
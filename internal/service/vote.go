@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"exchangeapp/internal/dto"
+	"exchangeapp/internal/models"
+	"exchangeapp/internal/repository"
+)
+
+// 赞成票即点赞，反对票即踩；同一用户对同一目标最多持有其中一票
+const (
+	VoteUp   = "up"
+	VoteDown = "down"
+)
+
+func voteReaction(vote string) string {
+	if vote == VoteDown {
+		return models.ReactionDownvote
+	}
+	return models.ReactionLike
+}
+
+type voteDeleter interface {
+	Delete(userID, targetID uint, reaction string) error
+}
+
+// 投一种票前撤掉另一种，撤掉时通过 decrement 扣减对应计数；需与投票在同一事务内执行
+func dropOppositeVote(lr voteDeleter, userID, targetID uint, reaction string, decrement func(reaction string) error) error {
+	var opposite string
+	switch reaction {
+	case models.ReactionLike:
+		opposite = models.ReactionDownvote
+	case models.ReactionDownvote:
+		opposite = models.ReactionLike
+	default:
+		return nil
+	}
+	err := lr.Delete(userID, targetID, opposite)
+	if errors.Is(err, repository.ErrLikeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return decrement(opposite)
+}
+
+func newVoteResp(mine []string, likeCount, downvoteCount int64) *dto.VoteResp {
+	resp := &dto.VoteResp{
+		LikeCount:     likeCount,
+		DownvoteCount: downvoteCount,
+		Score:         likeCount - downvoteCount,
+	}
+	for _, reaction := range mine {
+		switch reaction {
+		case models.ReactionLike:
+			resp.Vote = VoteUp
+		case models.ReactionDownvote:
+			resp.Vote = VoteDown
+		}
+	}
+	return resp
+}
+
+// 踩不属于表情回应，不出现在回应列表中
+func withoutDownvote(mine []string) []string {
+	out := make([]string, 0, len(mine))
+	for _, reaction := range mine {
+		if reaction != models.ReactionDownvote {
+			out = append(out, reaction)
+		}
+	}
+	return out
+}
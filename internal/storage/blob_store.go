@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"exchangeapp/internal/config"
+	"fmt"
+	"io"
+)
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+func NewBlobStore(cfg *config.UploadConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir, cfg.PublicBaseURL)
+	case "s3":
+		return NewS3Store(&cfg.S3, cfg.PublicBaseURL)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型：%s", cfg.Driver)
+	}
+}
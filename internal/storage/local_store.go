@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if dir == "" {
+		dir = "./data/uploads"
+	}
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("解析存储目录失败：%w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败：%w", err)
+	}
+	return &LocalStore{
+		dir:     abs,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStore) BaseURL() string {
+	return s.baseURL
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, s.dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("非法文件 key：%s", key)
+	}
+	return p, nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("创建存储目录失败：%w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败：%w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入文件失败：%w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入文件失败：%w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("保存文件失败：%w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除文件失败：%w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
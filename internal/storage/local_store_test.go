@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorePutDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir, "/uploads/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.Put(context.Background(), "2024/01/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "2024", "01", "a.txt"))
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("unexpected file content: %q, err=%v", b, err)
+	}
+	if got := s.URL("2024/01/a.txt"); got != "/uploads/2024/01/a.txt" {
+		t.Fatalf("unexpected url: %s", got)
+	}
+
+	if err := s.Delete(context.Background(), "2024/01/a.txt"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := s.Delete(context.Background(), "2024/01/a.txt"); err != nil {
+		t.Fatalf("expected deleting missing file to succeed, got %v", err)
+	}
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Put(context.Background(), "../escape.txt", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Fatalf("expected error for path traversal")
+	}
+}
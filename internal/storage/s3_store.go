@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"exchangeapp/internal/config"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Store struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+func NewS3Store(cfg *config.S3Config, baseURL string) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint 或 bucket 未配置")
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建 S3 客户端失败：%w", err)
+	}
+
+	if baseURL == "" || strings.HasPrefix(baseURL, "/") {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, cfg.Endpoint, cfg.Bucket)
+	}
+
+	return &S3Store{
+		client:  client,
+		bucket:  cfg.Bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("上传对象失败：%w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("删除对象失败：%w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -11,10 +11,15 @@ import (
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
 	jwtv5.RegisteredClaims
 }
 
 func GenerateToken(userID uint, username, secret string, expireMinutes uint) (string, error) {
+	return GenerateTokenWithRole(userID, username, "", secret, expireMinutes)
+}
+
+func GenerateTokenWithRole(userID uint, username, role, secret string, expireMinutes uint) (string, error) {
 	if secret == "" {
 		return "", errors.New("JWT 密钥为空")
 	}
@@ -26,6 +31,7 @@ func GenerateToken(userID uint, username, secret string, expireMinutes uint) (st
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwtv5.RegisteredClaims{
 			IssuedAt:  jwtv5.NewNumericDate(now),
 			ExpiresAt: jwtv5.NewNumericDate(now.Add(time.Duration(expireMinutes) * time.Minute)),
@@ -32,6 +32,20 @@ func TestGenerateTokenDefaultExpire(t *testing.T) {
 	}
 }
 
+func TestGenerateTokenWithRole(t *testing.T) {
+	tokenStr, err := GenerateTokenWithRole(1, "alice", "moderator", "secret", 60)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+	claims, err := ParseToken(tokenStr, "secret")
+	if err != nil {
+		t.Fatalf("parse token failed: %v", err)
+	}
+	if claims.Role != "moderator" {
+		t.Fatalf("expected role moderator, got %q", claims.Role)
+	}
+}
+
 func TestParseTokenWrongSecret(t *testing.T) {
 	tokenStr, err := GenerateToken(1, "alice", "secret", 60)
 	if err != nil {
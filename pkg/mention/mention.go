@@ -0,0 +1,60 @@
+package mention
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 单条内容最多解析的提及与引用数量，超出部分忽略
+const MaxRefs = 20
+
+var (
+	codeBlockRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	userRe      = regexp.MustCompile(`(^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_.\-]{3,32})`)
+	quoteRe     = regexp.MustCompile(`(^|[^>\w])>>(\d{1,19})\b`)
+)
+
+type Refs struct {
+	Usernames []string
+	ReplyIDs  []uint
+}
+
+// 解析 @username 与 >>reply_id，代码块内的内容不算；结果按出现顺序去重
+func Parse(content string) Refs {
+	content = codeBlockRe.ReplaceAllString(content, " ")
+
+	var refs Refs
+	seenUser := make(map[string]struct{})
+	for _, m := range userRe.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(m[2], ".-")
+		if len([]rune(name)) < 3 {
+			continue
+		}
+		if _, ok := seenUser[name]; ok {
+			continue
+		}
+		seenUser[name] = struct{}{}
+		refs.Usernames = append(refs.Usernames, name)
+		if len(refs.Usernames) == MaxRefs {
+			break
+		}
+	}
+
+	seenReply := make(map[uint]struct{})
+	for _, m := range quoteRe.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		if _, ok := seenReply[uint(id)]; ok {
+			continue
+		}
+		seenReply[uint(id)] = struct{}{}
+		refs.ReplyIDs = append(refs.ReplyIDs, uint(id))
+		if len(refs.ReplyIDs) == MaxRefs {
+			break
+		}
+	}
+	return refs
+}
@@ -0,0 +1,28 @@
+package mention
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	refs := Parse("hi @alice and @bob_2. cc @alice\n>>12 see >>7, >>12\nmail a@example.com `@code` >>>3")
+
+	if want := []string{"alice", "bob_2"}; !reflect.DeepEqual(refs.Usernames, want) {
+		t.Fatalf("expected usernames %v, got %v", want, refs.Usernames)
+	}
+	if want := []uint{12, 7}; !reflect.DeepEqual(refs.ReplyIDs, want) {
+		t.Fatalf("expected reply ids %v, got %v", want, refs.ReplyIDs)
+	}
+}
+
+func TestParseSkipsCodeBlocks(t *testing.T) {
+	refs := Parse("```\n@alice >>1\n```\n@小明同学")
+
+	if want := []string{"小明同学"}; !reflect.DeepEqual(refs.Usernames, want) {
+		t.Fatalf("expected usernames %v, got %v", want, refs.Usernames)
+	}
+	if len(refs.ReplyIDs) != 0 {
+		t.Fatalf("expected no quotes, got %v", refs.ReplyIDs)
+	}
+}
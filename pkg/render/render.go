@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"p", "br", "hr", "blockquote", "pre", "code",
+		"em", "strong", "del", "ul", "ol", "li",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.AllowAttrs("align").Matching(bluemonday.Paragraph).OnElements("th", "td")
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatPlain || format == FormatMarkdown
+}
+
+func ToHTML(format, content string) (string, error) {
+	switch format {
+	case "", FormatPlain:
+		return plainToHTML(content), nil
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(content), &buf); err != nil {
+			return "", fmt.Errorf("渲染 markdown 失败：%w", err)
+		}
+		return policy.Sanitize(buf.String()), nil
+	default:
+		return "", fmt.Errorf("不支持的内容格式：%s", format)
+	}
+}
+
+func plainToHTML(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	paras := strings.Split(content, "\n\n")
+	var b strings.Builder
+	for _, p := range paras {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(p), "\n", "<br>"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
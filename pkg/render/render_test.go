@@ -0,0 +1,56 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTMLPlainEscapes(t *testing.T) {
+	out, err := ToHTML(FormatPlain, "a <b>\nc\n\nd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "<p>a &lt;b&gt;<br>c</p>\n<p>d</p>\n"
+	if out != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
+
+func TestToHTMLMarkdown(t *testing.T) {
+	out, err := ToHTML(FormatMarkdown, "# t\n\n**bold** [x](https://example.com)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{"<h1>t</h1>", "<strong>bold</strong>", `href="https://example.com"`, "nofollow"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("expected %q in %q", s, out)
+		}
+	}
+}
+
+func TestToHTMLMarkdownStripsScripts(t *testing.T) {
+	cases := []string{
+		"<script>alert(1)</script>",
+		`<img src="x" onerror="alert(1)">`,
+		"[x](javascript:alert(1))",
+		`<a href="https://example.com" onclick="alert(1)">x</a>`,
+	}
+	for _, c := range cases {
+		out, err := ToHTML(FormatMarkdown, c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lower := strings.ToLower(out)
+		for _, bad := range []string{"<script", "onerror", "onclick", "javascript:"} {
+			if strings.Contains(lower, bad) {
+				t.Fatalf("unexpected %q in %q", bad, out)
+			}
+		}
+	}
+}
+
+func TestToHTMLUnknownFormat(t *testing.T) {
+	if _, err := ToHTML("rst", "x"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}